@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// browserOpenTimeout is how long an opener may take to hand the URL to the
+// browser before it is taken to have worked.
+const browserOpenTimeout = 5 * time.Second
+
+// openBrowser opens url in the default browser through the opener of the
+// OS: open on macOS, start on Windows, and on Linux wslview under WSL and
+// xdg-open on a desktop. It reports false when there is no opener, as on a
+// headless server, or the opener failed.
+func openBrowser(url string) bool {
+	var name string
+	var args []string
+	switch runtime.GOOS {
+	case "darwin":
+		name, args = "open", []string{url}
+	case "windows":
+		// start is built into cmd; the empty title keeps it from taking a
+		// quoted url for the window title.
+		name, args = "cmd", []string{"/c", "start", "", url}
+	default:
+		switch {
+		case isWSL():
+			name, args = "wslview", []string{url}
+		case os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != "":
+			// Without a display xdg-open would fall back to a text browser
+			// in the terminal dev runs in.
+			name, args = "xdg-open", []string{url}
+		default:
+			return false
+		}
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return false
+	}
+	c := exec.Command(name, args...)
+	if err := c.Start(); err != nil {
+		return false
+	}
+	done := make(chan error, 1)
+	go func() { done <- c.Wait() }()
+	select {
+	case err := <-done:
+		return err == nil
+	case <-time.After(browserOpenTimeout):
+		return true
+	}
+}
+
+// isWSL reports whether reavix runs under the Windows Subsystem for Linux,
+// whose kernel names Microsoft in its version.
+func isWSL() bool {
+	version, err := os.ReadFile("/proc/version")
+	return err == nil && strings.Contains(strings.ToLower(string(version)), "microsoft")
+}
@@ -3,75 +3,749 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 	
 	"github.com/spf13/cobra"
 
 	
-	utils "github.com/Reavix-framework/cli/internal/utils"
+	"github.com/Reavix-framework/cli/internal/logs"
+	"github.com/Reavix-framework/cli/internal/project"
 	
 )
 
+var buildOpts struct {
+	preset       string
+	andRun       bool
+	serial       bool
+	skipFrontend bool
+	skipBackend  bool
+	debug        bool
+	release      bool
+	clean        bool
+	force        bool
+	out          string
+	jobs         int
+	reconfigure  bool
+	generator    string
+	toolchain    string
+	triples      []string
+	sanitize     []string
+	lto          bool
+	static       bool
+	strip        bool
+	version      string
+	noCompress   bool
+	report       string
+	embedAssets  bool
+	ccache       bool
+	mode         string
+	watch        bool
+	frozen       bool
+}
+
 var buildCmd = &cobra.Command{
 	Use: "build",
 	Short: "Build production version",
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: "Build the frontend and the server side by side and collect the artifacts in the output\n" +
+		"directory: the server binary and the frontend in its static/. A half whose sources have not\n" +
+		"changed since the last build into the output directory is reused rather than rebuilt. The\n" +
+		"preBuild and postBuild hooks of reavix.json run around the build with REAVIX_VERSION,\n" +
+		"REAVIX_COMMIT, REAVIX_MODE, REAVIX_OUT_DIR and the like set.\n\n" +
+		"Exit status: 2 when the frontend build fails, 3 when the server build fails, 4 when the\n" +
+		"artifacts cannot be copied to the output directory, 1 for any other error. When both builds\n" +
+		"fail, both errors are reported and the exit status is 2.",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		if err := checkInspect(); err != nil {
+			return err
+		}
+		if buildOpts.watch && inspectFormat == "" {
+			return watchBuild(cmd)
+		}
+		started := time.Now()
+		buildPhases.reset()
+		reportPath, err := absPathFlag(buildOpts.report)
+		if err != nil {
+			return err
+		}
+		// The report is written however the build ends, before --and-run
+		// hands over to the server.
+		report := &buildReport{SchemaVersion: buildReportSchema, CLIVersion: version, StartedAt: started.UTC().Format(time.RFC3339), Artifacts: []reportArtifact{}}
+		reported := false
+		writeReport := func(err error) {
+			if reportPath == "" || reported {
+				return
+			}
+			reported = true
+			report.finish(err, started)
+			if err := report.save(reportPath); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not write the build report: %v\n", err)
+			}
+		}
+		defer func() { writeReport(err) }()
+
+		if buildOpts.skipFrontend && buildOpts.skipBackend {
+			return fmt.Errorf("--skip-frontend and --skip-backend cannot be combined; that leaves nothing to build")
+		}
+		// --out is relative to where the command was started, so resolve it
+		// before requireProject changes into the project root.
+		flagOut, err := absPathFlag(buildOpts.out)
+		if err != nil {
+			return err
+		}
+		toolchain, err := absPathFlag(buildOpts.toolchain)
+		if err != nil {
+			return err
+		}
+		if err := checkCrossOptions(toolchain, buildOpts.triples); err != nil {
+			return err
+		}
+		sanitize, err := resolveSanitizers(buildOpts.sanitize)
+		if err != nil {
+			return err
+		}
+		if len(sanitize) > 0 {
+			switch {
+			case buildOpts.release || buildOpts.preset != "":
+				return fmt.Errorf("--sanitize builds are Debug builds; drop --release and --preset")
+			case len(buildOpts.triples) > 0:
+				return fmt.Errorf("--sanitize cannot be combined with --target-triple")
+			case buildOpts.static:
+				return fmt.Errorf("--sanitize cannot be combined with --static; the sanitizer runtimes link dynamically")
+			}
+		}
+		if buildOpts.mode != "" && !buildMode.MatchString(buildOpts.mode) {
+			return fmt.Errorf("invalid mode %q: use letters, digits, '.', '_' and '-', as in staging", buildOpts.mode)
+		}
+		m, err := requireProject()
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		if buildOpts.embedAssets {
+			switch {
+			case !m.HasFrontend() || !m.HasBackend():
+				return fmt.Errorf("--embed-assets compiles the frontend into the server, so it needs a project with both")
+			case buildOpts.skipBackend:
+				return fmt.Errorf("--embed-assets cannot be combined with --skip-backend; the frontend is part of the server build")
+			case buildOpts.skipFrontend && !fileExists(filepath.Join(m.Frontend, "dist")):
+				return fmt.Errorf("--skip-frontend --embed-assets needs an earlier build in %s", filepath.Join(m.Frontend, "dist"))
+			}
+		}
+		outDir := m.OutDir
+		if flagOut != "" {
+			outDir = flagOut
+		}
+		if buildOpts.preset != "" && !hasCMakePresets(m.Backend) {
+			return fmt.Errorf("--preset needs %s", filepath.Join(m.Backend, cmakePresetsFile))
+		}
+		config := newResolvedConfig(cmd, m)
+		settings, err := resolveServerSettings(config, m, "Release")
+		if err != nil {
+			return err
+		}
+		buildType, preset, jobs, generator, ccache := settings.buildType, settings.preset, settings.jobs, settings.generator, settings.ccache
+		if len(sanitize) > 0 {
+			buildType = "Debug"
+		}
+		buildsFrozenApp := buildOpts.frozen && m.HasFrontend() && !buildOpts.skipFrontend
+		if buildsFrozenApp {
+			if err := checkFrozenLockfile(m); err != nil {
+				return err
+			}
+		}
+		if buildOpts.frozen && os.Getenv("SOURCE_DATE_EPOCH") == "" {
+			// The last commit dates the build, so it comes out the same
+			// every time; every tool run from here on sees it too.
+			if epoch := gitOutput(".", "log", "-1", "--format=%ct"); epoch != "" {
+				os.Setenv("SOURCE_DATE_EPOCH", epoch)
+			} else {
+				fmt.Fprintln(os.Stderr, "Warning: outside git, --frozen builds carry the time they were made unless SOURCE_DATE_EPOCH is set.")
+			}
+		}
+		info, err := resolveBuildInfo(".", buildOpts.version, m.AppVersion)
+		if err != nil {
+			return err
+		}
+		info.Mode = buildOpts.mode
+		if inspectFormat != "" {
+			// Nothing is built, so there is nothing to report.
+			reportPath = ""
+			return inspectBuild(config, m, outDir, info, sanitize)
+		}
+		report.Build = &info
+		var defines []string
+		if buildOpts.lto {
+			defines = append(defines, "-DCMAKE_INTERPROCEDURAL_OPTIMIZATION=ON")
+		}
+		if buildOpts.static {
+			defines = append(defines, "-DCMAKE_EXE_LINKER_FLAGS=-static")
+		}
+		if buildOpts.embedAssets {
+			defines = append(defines, embedDefine)
+		}
+		// The server is built once for the host, or once per target triple,
+		// each in its own build directory.
+		triples := buildOpts.triples
+		var serverDirs []string
+		if m.HasBackend() && len(sanitize) > 0 {
+			serverDirs = []string{sanitizeBuildDir(m.Backend, sanitize)}
+		} else if m.HasBackend() && len(triples) == 0 {
+			dir, err := serverBuildDir(m.Backend, preset)
+			if err != nil {
+				return err
+			}
+			serverDirs = []string{dir}
+		}
+		if m.HasBackend() {
+			for _, triple := range triples {
+				serverDirs = append(serverDirs, crossBuildDir(m.Backend, triple))
+			}
+		}
+		// Failures from here on are build failures, not usage errors.
+		cmd.SilenceUsage = true
 		fmt.Println("Building production version...")
 
-		frontendCmd := exec.Command("npm","run","build")
-		frontendCmd.Dir = "app"
-		frontendCmd.Stdout = os.Stdout
-		frontendCmd.Stderr = os.Stderr
+		// Projects created with --only have a single half; build just that
+		// one. A skipped half reuses the output of an earlier build.
+		buildsApp := m.HasFrontend() && !buildOpts.skipFrontend
+		buildsServer := m.HasBackend() && !buildOpts.skipBackend
 
-		if err := frontendCmd.Run(); err != nil {
-			fmt.Println("App build error: %v\n", err)
-			return
+		absOut, err := filepath.Abs(outDir)
+		if err != nil {
+			return err
+		}
+		mode := buildOpts.mode
+		if mode == "" {
+			// Vite's own default for a build.
+			mode = "production"
+		}
+		hooks := projectHooks(m)
+		hooksEnv := hookEnv(".", map[string]string{
+			"VERSION": info.Version, "COMMIT": info.Commit, "BUILD_TIME": info.BuildTime,
+			"MODE": mode, "OUT_DIR": absOut,
+		})
+		// The hooks may generate sources, so they run before anything is
+		// hashed.
+		if err := runHooks("preBuild", hooks.PreBuild, ".", hooksEnv); err != nil {
+			return err
 		}
 
-		backendDir := filepath.Join("server","build")
-		os.MkdirAll(backendDir, 0755)
+		// A half whose inputs hash the same as at the last build into outDir,
+		// and whose output is still there, is reused instead of rebuilt.
+		// The build time is left out; a new version, commit or mode alone
+		// makes both halves stale. The env files are in the hashed trees.
+		hashes := loadBuildHashes(outDir)
+		stamp := info.Version + "\x00" + info.Commit + "\x00" + info.Mode
+		var appHash, serverHash string
+		if buildsApp {
+			if appHash, err = frontendHash(m, outDir, stamp); err != nil {
+				return fmt.Errorf("hashing the frontend: %w", err)
+			}
+		}
+		if buildsServer {
+			target := preset
+			if len(triples) > 0 {
+				target = strings.Join(append([]string{toolchain}, triples...), "\x00")
+			} else if len(sanitize) > 0 {
+				target = "sanitize=" + strings.Join(sanitize, ",")
+			}
+			target += "\x00" + strings.Join(defines, "\x00") + "\x00" + stamp
+			if buildOpts.embedAssets {
+				// The embedded frontend is a server input too: the hash of
+				// its sources, or of the dist an earlier build left.
+				embedded := appHash
+				if embedded == "" {
+					if embedded, err = hashTree(filepath.Join(m.Frontend, "dist"), "", skipPaths(nil)); err != nil {
+						return fmt.Errorf("hashing the frontend: %w", err)
+					}
+				}
+				target += "\x00" + embedded
+			}
+			if serverHash, err = backendHash(m, outDir, target, buildType); err != nil {
+				return fmt.Errorf("hashing the server: %w", err)
+			}
+		}
+		if !buildOpts.force && !buildOpts.clean {
+			if dist := filepath.Join(m.Frontend, "dist"); buildsApp && appHash == hashes.Frontend && fileExists(dist) {
+				fmt.Printf("frontend unchanged, reusing %s\n", displayPath(dist))
+				buildsApp = false
+			}
+			if buildsServer && serverHash == hashes.Backend && allBuilt(serverDirs) {
+				for _, dir := range serverDirs {
+					fmt.Printf("server unchanged, reusing %s\n", displayPath(filepath.Join(dir, "server")))
+				}
+				buildsServer = false
+			}
+		}
+		if err := runPreflight(projectTools(m, preset, generator, buildsApp, buildsServer)); err != nil {
+			return err
+		}
+		if buildsApp && buildOpts.frozen {
+			if err := frozenInstall(stdOutput, m); err != nil {
+				return &exitError{code: exitFrontendBuild, err: err}
+			}
+		}
+		if reportPath != "" {
+			report.Toolchain = toolchainVersions(projectTools(m, preset, generator, m.HasFrontend(), m.HasBackend()))
+		}
+		if buildsServer {
+			if err := recordGenerator(m, generator); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not record the generator in %s: %v\n", project.FileName, err)
+			}
+		}
 
-		cmds := []*exec.Cmd{
-			exec.Command("cmake", ".."),
-			exec.Command("make"),
-			exec.Command("./server"),
+		// The mode's env file of the server is exported to CMake and the
+		// build tool; a mode without one leaves their environment alone.
+		var serverEnv []string
+		if buildsServer && buildOpts.mode != "" {
+			envFile := filepath.Join(m.Backend, ".env."+buildOpts.mode)
+			serverEnv, err = loadEnvFile(envFile)
+			if os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "Warning: %s does not exist, so the server is built without it.\n", displayPath(envFile))
+			} else if err != nil {
+				return err
+			}
 		}
 
-		for _, c := range cmds{
-			c.Dir = backendDir
-			c.Stdout = os.Stdout
-			c.Stderr = os.Stderr
+		// Without --verbose each half's output is only shown if it fails.
+		buildApp := func(out cmdOutput) error {
+			fmt.Fprintln(out.stdout, "Building the frontend...")
+			var viteArgs []string
+			if buildOpts.mode != "" {
+				viteArgs = append(viteArgs, "--mode", buildOpts.mode)
+			}
+			if verbose {
+				viteArgs = append(viteArgs, "--logLevel", "info")
+			}
+			scriptArgs := runScriptArgs(m.PackageManager, "build", viteArgs...)
+			err := buildPhases.track(phaseFrontend, func() error {
+				return quietUnlessVerbose(out, func(out cmdOutput) error {
+					return runInEnv(out, m.Frontend, info.viteEnv(), scriptArgs[0], scriptArgs[1:]...)
+				})
+			})
+			if err != nil {
+				return withExitCode(exitFrontendBuild, err, "app build failed: %w")
+			}
+			return nil
+		}
 
-			if err := c.Run(); err != nil {
-				fmt.Println("Server build error: %v\n", err)
-				return
+		var builtType string
+		serverOpts := serverBuildOptions{preset: preset, buildType: buildType, jobs: jobs, generator: generator, reconfigure: buildOpts.reconfigure, defines: defines, env: serverEnv}
+		if ccache {
+			// The launcher does not change what is built, so it is kept out
+			// of the server hash above.
+			serverOpts.defines = append(append([]string(nil), defines...), ccacheDefines(m.BackendLang)...)
+		}
+		// A failed static link is summed up instead of replaying the linker.
+		var explain func([]byte) error
+		if buildOpts.static {
+			explain = explainStaticLink
+		}
+		var warnings warningCounter
+		buildBackend := func(out cmdOutput) error {
+			if buildOpts.embedAssets {
+				err := buildPhases.track(phaseEmbed, func() error {
+					generated, err := generateAssets(filepath.Join(m.Frontend, "dist"), m.Backend, m.BackendLang)
+					if generated {
+						fmt.Fprintf(out.stdout, "Generated %s from %s\n", displayPath(filepath.Join(m.Backend, embedDir)), displayPath(filepath.Join(m.Frontend, "dist")))
+					}
+					return err
+				})
+				if err != nil {
+					return withExitCode(exitBackendBuild, err, "embedding the frontend: %w")
+				}
 			}
+			if len(sanitize) > 0 {
+				fmt.Fprintf(out.stdout, "Building the server with -fsanitize=%s...\n", strings.Join(sanitize, ","))
+				err := quietExplained(out, explain, func(out cmdOutput) error {
+					_, err := buildSanitized(warnings.wrap(out), m.Backend, sanitize, serverOpts)
+					return err
+				})
+				if err != nil {
+					return withExitCode(exitBackendBuild, err, "server build failed: %w")
+				}
+				builtType = "Debug with -fsanitize=" + strings.Join(sanitize, ",")
+				return nil
+			}
+			if len(triples) == 0 {
+				fmt.Fprintln(out.stdout, "Building the server...")
+				var built *serverBuild
+				err := quietExplained(out, explain, func(out cmdOutput) error {
+					var err error
+					built, err = buildServer(warnings.wrap(out), m.Backend, serverOpts)
+					return err
+				})
+				if err != nil {
+					return withExitCode(exitBackendBuild, err, "server build failed: %w")
+				}
+				builtType = built.buildType
+				return nil
+			}
+			// Cross builds run one after the other, each already using
+			// every CPU.
+			for _, triple := range triples {
+				fmt.Fprintf(out.stdout, "Building the server for %s...\n", triple)
+				err := quietExplained(out, explain, func(out cmdOutput) error {
+					_, err := buildServerFor(warnings.wrap(out), m.Backend, triple, toolchain, serverOpts)
+					return err
+				})
+				if err != nil {
+					return withExitCode(exitBackendBuild, err, "server build for %s failed: %w", triple)
+				}
+			}
+			builtType = buildType
+			return nil
+		}
+
+		if buildOpts.clean {
+			var stale []string
+			if buildsServer {
+				stale = append(stale, serverArtifacts(m)...)
+			}
+			if buildsApp {
+				stale = append(stale, frontendArtifacts(m)...)
+			}
+			if _, _, err := removeArtifacts(stale); err != nil {
+				return err
+			}
+		}
+		if buildsServer {
+			for _, dir := range serverDirs {
+				if err := info.writeHeader(dir); err != nil {
+					return withExitCode(exitBackendBuild, err, "writing the build info: %w")
+				}
+			}
+		}
+		var ccacheBefore ccacheStats
+		cached := false
+		if buildsServer && ccache {
+			ccacheBefore, cached = readCCacheStats()
+		}
+		// Embedded assets come from the frontend build, so it goes first.
+		if buildsApp && buildsServer && !buildOpts.serial && !buildOpts.embedAssets {
+			if err := buildParallel(buildApp, buildBackend); err != nil {
+				return err
+			}
+		} else {
+			if buildsApp {
+				if err := buildApp(stdOutput); err != nil {
+					return err
+				}
+			}
+			if buildsServer {
+				if err := buildBackend(stdOutput); err != nil {
+					return err
+				}
+			}
+		}
+		report.Warnings = warnings.total()
+		if err := os.MkdirAll(outDir, 0755); err != nil {
+			return withExitCode(exitArtifactCopy, err, "creating the build directory: %w")
 		}
+		// The artifacts are hashed as they are copied, for the manifest.
+		artifacts := newArtifactHasher(outDir)
 
-		if err := os.MkdirAll("build", 0755); err != nil {
-			fmt.Println("Error creating build directory: %v\n", err)
-			return
+		var built, reused, binaries []string
+		for i, dir := range serverDirs {
+			server := filepath.Join(dir, "server")
+			name, binary := "server", m.Binary
+			if len(triples) > 0 {
+				name, binary = "server for "+triples[i], m.Binary+"-"+triples[i]
+			}
+			switch {
+			case buildsServer && builtType != "":
+				built = append(built, name+" ("+builtType+")")
+			case buildsServer:
+				built = append(built, name)
+			case fileExists(server):
+				reused = append(reused, name+" ("+displayPath(server)+")")
+			default:
+				fmt.Fprintf(os.Stderr, "Warning: %s does not exist yet, so %s was not updated; run `reavix build` without --skip-backend once.\n", displayPath(server), displayPath(filepath.Join(outDir, binary)))
+				continue
+			}
+			err := buildPhases.track(phaseCopy, func() error {
+				return artifacts.copyFile(server, filepath.Join(outDir, binary))
+			})
+			if err != nil {
+				return withExitCode(exitArtifactCopy, err, "copying the server: %w")
+			}
+			if buildOpts.strip {
+				triple := ""
+				if len(triples) > 0 {
+					triple = triples[i]
+				}
+				err := buildPhases.track(phaseStrip, func() error {
+					return stripBinary(filepath.Join(outDir, binary), triple)
+				})
+				if err != nil {
+					return withExitCode(exitArtifactCopy, err, "stripping %s: %w", binary)
+				}
+			}
+			binaries = append(binaries, binary)
 		}
 
-		if err := utils.CopyFile(
-			filepath.Join(backendDir,"server"),
-			filepath.Join("build","reavix-app"),
-		); err != nil {
-			fmt.Println("Error copying server: %v\n", err)
+		var compressed string
+		if m.HasFrontend() && buildOpts.embedAssets {
+			if buildsApp {
+				built = append(built, "app (embedded)")
+			} else {
+				reused = append(reused, "app ("+displayPath(filepath.Join(m.Frontend, "dist"))+", embedded)")
+			}
+			// The server has the frontend built in; a static/ left by an
+			// earlier build would only go stale.
+			if err := os.RemoveAll(filepath.Join(outDir, "static")); err != nil {
+				return withExitCode(exitArtifactCopy, err, "removing the old static/: %w")
+			}
+		} else if m.HasFrontend() {
+			dist := filepath.Join(m.Frontend, "dist")
+			switch {
+			case buildsApp:
+				built = append(built, "app")
+			case fileExists(dist):
+				reused = append(reused, "app ("+displayPath(dist)+")")
+			default:
+				fmt.Fprintf(os.Stderr, "Warning: %s does not exist yet, so %s was not updated; run `reavix build` without --skip-frontend once.\n", displayPath(dist), displayPath(filepath.Join(outDir, "static")))
+				dist = ""
+			}
+			if dist != "" {
+				err := buildPhases.track(phaseCopy, func() error {
+					return artifacts.copyDir(dist, filepath.Join(outDir, "static"))
+				})
+				if err != nil {
+					return withExitCode(exitArtifactCopy, err, "copying the frontend: %w")
+				}
+				// --no-compress still clears out the siblings of an earlier
+				// build, which would be stale now.
+				if buildOpts.noCompress {
+					err = prunePrecompressed(dist, filepath.Join(outDir, "static"))
+				} else {
+					var stats compressStats
+					err = buildPhases.track(phaseCompress, func() error {
+						var err error
+						stats, err = precompressStatic(dist, filepath.Join(outDir, "static"))
+						return err
+					})
+					compressed = stats.summary()
+				}
+				if err != nil {
+					return withExitCode(exitArtifactCopy, err, "precompressing the frontend: %w")
+				}
+			}
 		}
 
-		if err := utils.CopyDir(
-			filepath.Join("app","dist"),
-			filepath.Join("build","static"),
-		); err != nil {
-			fmt.Println("Error copying frontend: %v\n", err)
+		sizes := map[string]int64{}
+		order := binaries
+		for _, binary := range binaries {
+			sizes[binary] = pathSize(filepath.Join(outDir, binary))
+		}
+		if static := filepath.Join(outDir, "static"); m.HasFrontend() && fileExists(static) {
+			sizes["static/"] = pathSize(static)
+			order = append(order, "static/")
+		}
+		if len(order) > 0 {
+			printSizeReport(order, sizes, hashes.Sizes)
+		}
+		hashes.Sizes = sizes
+		for _, name := range order {
+			path, err := filepath.Abs(filepath.Join(outDir, name))
+			if err != nil {
+				path = filepath.Join(outDir, name)
+			}
+			report.Artifacts = append(report.Artifacts, reportArtifact{Name: name, Path: path, Size: sizes[name]})
 		}
 
+		if buildsApp {
+			hashes.Frontend = appHash
+		}
+		if buildsServer {
+			hashes.Backend = serverHash
+		}
+		if err := info.save(outDir); err != nil {
+			return withExitCode(exitArtifactCopy, err, "writing %s: %w", buildInfoFile)
+		}
+		if err := artifacts.save(cmd, append(order, buildInfoFile)); err != nil {
+			return withExitCode(exitArtifactCopy, err, "writing %s: %w", artifactManifestFile)
+		}
+		if err := saveBuildHashes(outDir, hashes); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not record the build inputs, so the next build starts over: %v\n", err)
+		}
+		if buildsApp && !buildOpts.frozen {
+			if err := recordLockfileHash(m); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not record the lockfile hash in %s: %v\n", project.FileName, err)
+			}
+		}
+		if err := runHooks("postBuild", hooks.PostBuild, ".", hooksEnv); err != nil {
+			return err
+		}
+
+		if compressed != "" {
+			fmt.Printf("Static files: %s.\n", compressed)
+		}
+		printTimings(buildPhases.phases(), time.Since(started))
+		if cached {
+			printCCacheSummary(ccacheBefore)
+		}
+		if len(reused) > 0 || buildOpts.skipFrontend || buildOpts.skipBackend {
+			fmt.Printf("Built: %s. Reused: %s.\n", listOrNone(built), listOrNone(reused))
+		} else if builtType != "" {
+			fmt.Printf("Built the server as %s.\n", builtType)
+		}
+		if !m.HasBackend() {
+			fmt.Printf("Build complete! The frontend is in %s\n", displayPath(filepath.Join(outDir, "static")))
+			return nil
+		}
+		if len(triples) > 0 {
+			fmt.Printf("Build complete! The cross-compiled servers are in %s: %s\n", displayPath(outDir), listOrNone(binaries))
+			return nil
+		}
+
+		if buildOpts.andRun {
+			fmt.Println("Build complete!")
+			writeReport(nil)
+			runOpts.out = flagOut
+			return runCmd.RunE(runCmd, nil)
+		}
+		if flagOut != "" {
+			fmt.Printf("Build complete! Run with: reavix run --out %s\n", displayPath(flagOut))
+			return nil
+		}
 		fmt.Println("Build complete! Run with: reavix run")
+		return nil
 	},
 }
 
+// checkCrossOptions validates --toolchain, already absolute, and the
+// --target-triple values of build.
+func checkCrossOptions(toolchain string, triples []string) error {
+	for _, triple := range triples {
+		if !targetTriple.MatchString(triple) {
+			return fmt.Errorf("invalid target triple %q: use letters, digits, '.', '_' and '-', as in aarch64-linux-gnu", triple)
+		}
+	}
+	if toolchain != "" {
+		if len(triples) == 0 {
+			return fmt.Errorf("--toolchain needs --target-triple, which names the build directory and the binary")
+		}
+		info, err := os.Stat(toolchain)
+		if err != nil {
+			return fmt.Errorf("toolchain file %s does not exist", toolchain)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("toolchain file %s is a directory", toolchain)
+		}
+	}
+	if len(triples) > 0 && buildOpts.preset != "" {
+		return fmt.Errorf("--preset cannot be combined with --target-triple; cross builds configure CMake directly")
+	}
+	if len(triples) > 0 && buildOpts.andRun {
+		return fmt.Errorf("--and-run cannot run cross-compiled servers")
+	}
+	return nil
+}
+
+// allBuilt reports whether every server build directory holds a binary.
+func allBuilt(dirs []string) bool {
+	for _, dir := range dirs {
+		if !fileExists(filepath.Join(dir, "server")) {
+			return false
+		}
+	}
+	return len(dirs) > 0
+}
+
+// absPathFlag makes a path flag absolute, leaving it empty when unset.
+func absPathFlag(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	return filepath.Abs(path)
+}
+
+// displayPath shows path relative to the project root when it is inside it.
+func displayPath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(wd, abs); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+func listOrNone(items []string) string {
+	if len(items) == 0 {
+		return "nothing"
+	}
+	return strings.Join(items, ", ")
+}
+
+// buildParallel runs the frontend and the server build at the same time,
+// each with its output prefixed, and waits for both. A failure of one does
+// not stop the other, so both are reported.
+func buildParallel(buildApp, buildBackend func(cmdOutput) error) error {
+	var (
+		wg        sync.WaitGroup
+		appErr    error
+		serverErr error
+	)
+	mux := outputMux("")
+	run := func(stream *logs.Stream, build func(cmdOutput) error, errp *error) {
+		defer wg.Done()
+		*errp = build(streamOutput(stream))
+		stream.Flush()
+	}
+	wg.Add(2)
+	go run(mux.Stream("app", logs.Cyan), buildApp, &appErr)
+	go run(mux.Stream("server", logs.Magenta), buildBackend, &serverErr)
+	wg.Wait()
+
+	if appErr != nil && serverErr != nil {
+		return &exitError{code: exitFrontendBuild, err: fmt.Errorf("%w; %v", appErr, serverErr)}
+	}
+	if appErr != nil {
+		return appErr
+	}
+	return serverErr
+}
+
 func init(){
+	buildCmd.Flags().BoolVar(&buildOpts.frozen, "frozen", false, "Build without network access, from the package manager cache and the lockfile reavix.json recorded, dated by the last commit, for a reproducible build")
+	buildCmd.Flags().BoolVar(&buildOpts.watch, "watch", false, "Keep running and rebuild into the output directory, swapped in with a rename, whenever the sources or configs change")
+	buildCmd.Flags().StringVar(&buildOpts.mode, "mode", "", "Build for this environment, like staging: Vite's --mode, and server/.env.<mode> exported to the server build")
+	buildCmd.Flags().BoolVar(&buildOpts.ccache, "ccache", false, "Compile the server through ccache (default: when ccache is installed, unless cache.ccache in reavix.json is false)")
+	buildCmd.Flags().BoolVar(&buildOpts.embedAssets, "embed-assets", false, "Compile the frontend into the server binary from generated C sources instead of copying it to static/")
+	buildCmd.Flags().StringVar(&buildOpts.report, "report", "", "Write a JSON report of the build to this file, even when it fails: status, phase timings, artifacts, tool versions, compiler warnings")
+	buildCmd.Flags().BoolVar(&buildOpts.noCompress, "no-compress", false, "Don't write precompressed .gz and .br siblings of the files in static/")
+	buildCmd.Flags().StringVar(&buildOpts.version, "version", "", "Version compiled into the server, served at /api/version and passed to Vite (default: appVersion in reavix.json, else what git describe --tags says)")
+	buildCmd.Flags().BoolVar(&buildOpts.lto, "lto", false, "Build the server with link-time optimization (CMAKE_INTERPROCEDURAL_OPTIMIZATION)")
+	buildCmd.Flags().BoolVar(&buildOpts.static, "static", false, "Link the server statically, so it runs without the target's shared libraries")
+	buildCmd.Flags().BoolVar(&buildOpts.strip, "strip", false, "Strip the symbols from the server binary in the output directory")
+	buildCmd.Flags().StringSliceVar(&buildOpts.sanitize, "sanitize", nil, "Build a Debug server with sanitizers: address, undefined or thread (repeatable or comma-separated)")
+	buildCmd.Flags().StringArrayVar(&buildOpts.triples, "target-triple", nil, "Cross-compile the server for this target, like aarch64-linux-gnu (repeatable); the binary is named <binary>-<triple>")
+	buildCmd.Flags().StringVar(&buildOpts.toolchain, "toolchain", "", "CMake toolchain file for --target-triple, passed as CMAKE_TOOLCHAIN_FILE")
+	buildCmd.Flags().StringVar(&buildOpts.generator, "generator", "", "CMake generator for the server: ninja, make or auto (default: generator in reavix.json, else ninja when installed; with CMakePresets.json, the presets' own)")
+	buildCmd.Flags().BoolVar(&buildOpts.reconfigure, "reconfigure", false, "Run the CMake configure step even when the server's build directory is up to date")
+	buildCmd.Flags().IntVarP(&buildOpts.jobs, "jobs", "j", 0, "Parallel compile jobs for the server (default: jobs in reavix.json, else one per CPU; Ninja picks its own)")
+	buildCmd.Flags().BoolVar(&buildOpts.release, "release", false, "Build the server with CMAKE_BUILD_TYPE=Release, the default")
+	buildCmd.Flags().BoolVar(&buildOpts.debug, "debug", false, "Build the server with CMAKE_BUILD_TYPE=Debug (the dev preset with CMakePresets.json)")
+	buildCmd.Flags().BoolVar(&buildOpts.force, "force", false, "Rebuild the frontend and the server even when their sources are unchanged since the last build")
+	buildCmd.Flags().BoolVar(&buildOpts.clean, "clean", false, "Remove the server build directory and app/dist first, like `reavix clean`, for a build from scratch")
+	buildCmd.Flags().StringVarP(&buildOpts.out, "out", "o", "", "Directory to put the server binary and static/ in, created if missing (default: outDir in reavix.json, build)")
+	buildCmd.Flags().BoolVar(&buildOpts.skipFrontend, "skip-frontend", false, "Don't build the frontend; copy the existing app/dist into the output directory")
+	buildCmd.Flags().BoolVar(&buildOpts.skipBackend, "skip-backend", false, "Don't build the server; copy the previously built binary into the output directory")
+	buildCmd.Flags().BoolVar(&buildOpts.serial, "serial", false, "Build the frontend and then the server instead of both at once, for constrained machines")
+	buildCmd.Flags().StringVar(&buildOpts.preset, "preset", "", "CMake preset to build the server with when server/CMakePresets.json exists (default release)")
+	addInspectFlag(buildCmd)
+	buildCmd.Flags().BoolVar(&noHooks, "no-hooks", false, "Don't run the preBuild and postBuild hooks of reavix.json")
+	buildCmd.Flags().BoolVar(&skipChecks, "skip-checks", false, "Don't check that cmake, make, a compiler, node and the package manager are installed first")
+	buildCmd.Flags().BoolVar(&buildOpts.andRun, "and-run", false, "Start the built server like `reavix run` once the build succeeds")
 	rootCmd.AddCommand(buildCmd)
 }
\ No newline at end of file
@@ -0,0 +1,71 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/Reavix-framework/cli/internal/project"
+)
+
+// fakeToolchain puts on PATH, for the rest of the test, an npm whose build
+// script writes dist/index.html, a cmake whose configure writes a cache for
+// make and a make that writes the server binary.
+func fakeToolchain(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("the fake toolchain needs sh")
+	}
+	bin := t.TempDir()
+	tools := map[string]string{
+		"npm":   "mkdir -p dist && echo '<html></html>' > dist/index.html\n",
+		"cmake": "echo 'CMAKE_GENERATOR:INTERNAL=Unix Makefiles' > CMakeCache.txt\n",
+		"make":  "printf '#!/bin/sh\\n' > server && chmod +x server\n",
+	}
+	for tool, script := range tools {
+		if err := os.WriteFile(filepath.Join(bin, tool), []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestBuildCollectsArtifacts(t *testing.T) {
+	fakeToolchain(t)
+	root := t.TempDir()
+	m := project.Default()
+	m.PackageManager = "npm"
+	data, err := project.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, root, map[string]string{
+		project.FileName:        string(data),
+		"app/package.json":      "{}\n",
+		"app/src/main.tsx":      "export {}\n",
+		"server/CMakeLists.txt": "project(app C)\n",
+		"server/src/main.c":     "int main(void) { return 0; }\n",
+	})
+	chdir(t, root)
+	saved, savedChecks := buildOpts, skipChecks
+	t.Cleanup(func() { buildOpts, skipChecks = saved, savedChecks })
+
+	var buildErr error
+	stdout, stderr := captureOutput(t, func() {
+		rootCmd.SetArgs([]string{"build", "--skip-checks", "--generator", "make", "--no-compress"})
+		buildErr = rootCmd.Execute()
+	})
+	if buildErr != nil {
+		t.Fatalf("build = %v\nstdout:\n%s\nstderr:\n%s", buildErr, stdout, stderr)
+	}
+	binary := filepath.Join(root, m.OutDir, m.Binary)
+	if info, err := os.Stat(binary); err != nil || info.Mode()&0o111 == 0 {
+		t.Errorf("%s is not an executable: %v", binary, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, m.OutDir, "static", "index.html")); err != nil {
+		t.Errorf("static/ holds no frontend: %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// buildInfoFile, in the output directory, describes the build that put the
+// artifacts there.
+const buildInfoFile = "build-info.json"
+
+// buildInfoHeader is generated in the server's build directory; the
+// CMakeLists.txt of a project created by reavix includes it when present.
+const buildInfoHeader = "reavix_build_info.h"
+
+// devVersion is the version of a build outside git with none configured.
+const devVersion = "0.0.0-dev"
+
+// buildInfo is what build embeds in the server and the frontend. Mode is
+// the --mode of the build, if any.
+type buildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+	Mode      string `json:"mode,omitempty"`
+}
+
+// appVersion matches the versions build accepts. They end up in C string
+// literals and in JSON unescaped, so quotes and backslashes are kept out.
+var appVersion = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z.+_/-]*$`)
+
+// resolveBuildInfo finds the version, the flag else the appVersion of
+// reavix.json else `git describe` in root, and the commit and time of the
+// build. Without git the version is devVersion and the commit "unknown".
+// SOURCE_DATE_EPOCH, when set, is the build time, for reproducible builds.
+func resolveBuildInfo(root, flag, configured string) (buildInfo, error) {
+	info := buildInfo{Version: flag, Commit: "unknown"}
+	if info.Version == "" {
+		info.Version = configured
+	}
+	if info.Version != "" && !appVersion.MatchString(info.Version) {
+		return buildInfo{}, fmt.Errorf("invalid version %q: use letters, digits, '.', '+', '_', '/' and '-', as in 1.2.0-rc.1", info.Version)
+	}
+	if commit := gitOutput(root, "rev-parse", "--short", "HEAD"); commit != "" {
+		info.Commit = commit
+	}
+	if info.Version == "" {
+		info.Version = devVersion
+		if described := gitOutput(root, "describe", "--tags", "--always", "--dirty"); appVersion.MatchString(described) {
+			info.Version = described
+		}
+	}
+
+	when := time.Now()
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		secs, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return buildInfo{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: want seconds since 1970", epoch)
+		}
+		when = time.Unix(secs, 0)
+	}
+	info.BuildTime = when.UTC().Format(time.RFC3339)
+	return info, nil
+}
+
+// gitOutput runs git with args in dir and returns its trimmed output, or ""
+// when git is not installed or fails, as it does outside a repository.
+func gitOutput(dir string, args ...string) string {
+	if !gitAvailable() {
+		return ""
+	}
+	c := exec.Command("git", args...)
+	c.Dir = dir
+	out, err := c.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// viteEnv is the environment of the Vite build, which exposes the
+// VITE_-prefixed variables to the app as import.meta.env.
+func (b buildInfo) viteEnv() []string {
+	return []string{
+		"VITE_APP_VERSION=" + b.Version,
+		"VITE_APP_COMMIT=" + b.Commit,
+		"VITE_APP_BUILD_TIME=" + b.BuildTime,
+	}
+}
+
+// writeHeader generates buildInfoHeader in buildDir. The file is left alone
+// when it is already current, so make and Ninja do not recompile for it.
+func (b buildInfo) writeHeader(buildDir string) error {
+	content := fmt.Sprintf("/* Generated by reavix build. */\n"+
+		"#define REAVIX_VERSION %q\n#define REAVIX_COMMIT %q\n#define REAVIX_BUILD_TIME %q\n",
+		b.Version, b.Commit, b.BuildTime)
+	path := filepath.Join(buildDir, buildInfoHeader)
+	if current, err := os.ReadFile(path); err == nil && string(current) == content {
+		return nil
+	}
+	if err := os.MkdirAll(buildDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// save writes b to buildInfoFile in outDir.
+func (b buildInfo) save(outDir string) error {
+	raw, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outDir, buildInfoFile), append(raw, '\n'), 0644)
+}
+
+// loadBuildInfo reads the buildInfoFile the last build wrote to outDir.
+func loadBuildInfo(outDir string) (buildInfo, error) {
+	var b buildInfo
+	raw, err := os.ReadFile(filepath.Join(outDir, buildInfoFile))
+	if err != nil {
+		return b, err
+	}
+	return b, json.Unmarshal(raw, &b)
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// packageToken matches npm package names, scoped or not, in tool output.
+var packageToken = regexp.MustCompile(`@[A-Za-z0-9._-]+/[A-Za-z0-9._-]+|[A-Za-z0-9._-]+`)
+
+// reportOfflineMisses prints which scaffold packages an offline install
+// could not find in the cache, judged by which of them the package manager's
+// output names.
+func reportOfflineMisses(output string) {
+	output = strings.NewReplacer("%2f", "/", "%2F", "/").Replace(output)
+	wanted := scaffoldPackages()
+
+	seen := map[string]bool{}
+	var missing []string
+	for _, token := range packageToken.FindAllString(output, -1) {
+		if _, ok := wanted[token]; ok && !seen[token] {
+			seen[token] = true
+			missing = append(missing, token)
+		}
+	}
+	sort.Strings(missing)
+
+	fmt.Fprintln(os.Stderr, "Warning: offline install failed; the project was created without dependencies.")
+	if len(missing) > 0 {
+		fmt.Fprintln(os.Stderr, "Packages missing from the local cache:")
+		for _, name := range missing {
+			fmt.Fprintf(os.Stderr, "  - %s\n", name)
+		}
+	} else {
+		fmt.Fprintln(os.Stderr, "Could not tell which packages were missing from the package manager output above.")
+	}
+	fmt.Fprintln(os.Stderr, "Run `reavix cache warm` while online to fill the cache.")
+}
+
+// scaffoldPackages is every package any scaffold can depend on: for each
+// frontend framework, the TypeScript, Tailwind, lint, test, Storybook and
+// PWA variants together cover the JavaScript and plain CSS ones, plus every
+// --state library and --router.
+func scaffoldPackages() map[string]string {
+	saved := createOpts
+	defer func() { createOpts = saved }()
+	createOpts.lang, createOpts.noTailwind, createOpts.css = "ts", false, "tailwind"
+	createOpts.lint, createOpts.tests, createOpts.storybook, createOpts.pwa = true, true, true, true
+
+	all := map[string]string{}
+	for _, framework := range frontendFrameworks {
+		createOpts.frontend = framework.id
+		for name, v := range frontendDependencies() {
+			all[name] = v
+		}
+		for name, v := range frontendDevDependencies() {
+			all[name] = v
+		}
+	}
+	for _, library := range stateLibraries {
+		for name, v := range library.deps {
+			all[name] = v
+		}
+	}
+	for _, router := range clientRouters {
+		for name, v := range router.deps {
+			all[name] = v
+		}
+	}
+	return all
+}
+
+var cacheWarmOpts struct {
+	pm   string
+	prod bool
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the package cache used by offline creates and frozen builds",
+}
+
+var cacheWarmCmd = &cobra.Command{
+	Use:   "warm",
+	Short: "Download every package the templates need into the local package manager cache",
+	Long: "Download the exact frontend dependencies the templates pin into the local\n" +
+		"package manager cache, so `reavix create --offline` works without network access.\n\n" +
+		"--prod downloads those of the current project instead, exactly as its lockfile lists\n" +
+		"them, and records the lockfile hash in reavix.json, so `reavix build --frozen` can\n" +
+		"build it offline from the cache, here or on a machine the cache is copied to.",
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if cacheWarmOpts.prod {
+			if cacheWarmOpts.pm != "" {
+				fmt.Println("--prod uses the package manager of the project; drop --pm")
+				os.Exit(1)
+			}
+			m, err := requireProject()
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			if !m.HasFrontend() {
+				fmt.Println("This project has no frontend, so it has no packages to cache.")
+				return
+			}
+			if err := warmProjectCache(m); err != nil {
+				fmt.Printf("Error warming the %s cache: %v\n", m.PackageManager, err)
+				os.Exit(1)
+			}
+			fmt.Printf("The %s cache now holds every package of %s; `reavix build --frozen` can build offline\n", m.PackageManager, displayPath(m.Frontend))
+			return
+		}
+		pm := cacheWarmOpts.pm
+		if pm == "" {
+			pm = detectPackageManager(".")
+		}
+		if err := validatePackageManager(pm); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := warmCache(pm); err != nil {
+			fmt.Printf("Error warming the %s cache: %v\n", pm, err)
+			os.Exit(1)
+		}
+		fmt.Printf("The %s cache now holds every package reavix templates use\n", pm)
+	},
+}
+
+func init() {
+	cacheWarmCmd.Flags().BoolVar(&cacheWarmOpts.prod, "prod", false, "Cache the dependencies of the current project for reavix build --frozen instead of the templates'")
+	cacheWarmCmd.Flags().StringVar(&cacheWarmOpts.pm, "pm", "", "Package manager whose cache to fill: npm, pnpm, yarn or bun (default: auto-detect)")
+	cacheCmd.AddCommand(cacheWarmCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// warmCache installs the scaffold packages into a throwaway project, which
+// leaves them in pm's cache the same way a real create would.
+func warmCache(pm string) error {
+	dir, err := os.MkdirTemp("", "reavix-cache-warm-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	manifest, err := json.MarshalIndent(map[string]interface{}{
+		"name":            "reavix-cache-warm",
+		"private":         true,
+		"devDependencies": scaffoldPackages(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), manifest, 0644); err != nil {
+		return err
+	}
+
+	args := append(installArgs(pm), "--ignore-scripts")
+	fmt.Printf("Running %s\n", strings.Join(args, " "))
+	c := exec.Command(args[0], args[1:]...)
+	c.Dir = dir
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	return c.Run()
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+
+	"github.com/Reavix-framework/cli/internal/project"
+)
+
+// resolveCCache decides whether build and dev compile the server through
+// ccache: always with --ccache, which then needs it installed, otherwise
+// when it is on PATH and cache.ccache in reavix.json does not turn it off.
+func resolveCCache(flag bool, m *project.Manifest) (bool, error) {
+	_, err := exec.LookPath("ccache")
+	installed := err == nil
+	if flag && !installed {
+		return false, fmt.Errorf("--ccache needs ccache, which is not on PATH")
+	}
+	return flag || (installed && m.CCacheEnabled()), nil
+}
+
+// ccacheDefines are the configure arguments that compile a server in lang,
+// "c" or "cpp", through ccache. Only the project's own language gets one,
+// as CMake warns about cache variables a project does not use. A build
+// configured without them drops the launcher again, see configureOnce.
+func ccacheDefines(lang string) []string {
+	if lang == "cpp" {
+		return []string{"-DCMAKE_CXX_COMPILER_LAUNCHER=ccache"}
+	}
+	return []string{"-DCMAKE_C_COMPILER_LAUNCHER=ccache"}
+}
+
+// ccacheStats are the cache hits and misses ccache has counted so far.
+type ccacheStats struct {
+	hits, misses int64
+}
+
+// The counters of `ccache -s`: ccache 4 prints "Hits:" and "Misses:" lines,
+// the first ones being the totals, and ccache 3 one line per kind of hit.
+var (
+	ccacheHits    = regexp.MustCompile(`(?m)^\s*Hits:\s+(\d+)`)
+	ccacheMisses  = regexp.MustCompile(`(?m)^\s*Misses:\s+(\d+)`)
+	ccacheOldHits = regexp.MustCompile(`(?m)^cache hit \((?:direct|preprocessed)\)\s+(\d+)`)
+	ccacheOldMiss = regexp.MustCompile(`(?m)^cache miss\s+(\d+)`)
+)
+
+// readCCacheStats runs `ccache -s` and parses its counters. It reports false
+// when ccache fails or prints something it does not understand.
+func readCCacheStats() (ccacheStats, bool) {
+	out, err := exec.Command("ccache", "-s").Output()
+	if err != nil {
+		return ccacheStats{}, false
+	}
+	return parseCCacheStats(string(out))
+}
+
+func parseCCacheStats(out string) (ccacheStats, bool) {
+	number := func(s string) int64 {
+		n, _ := strconv.ParseInt(s, 10, 64)
+		return n
+	}
+	if hits, misses := ccacheHits.FindStringSubmatch(out), ccacheMisses.FindStringSubmatch(out); hits != nil && misses != nil {
+		return ccacheStats{hits: number(hits[1]), misses: number(misses[1])}, true
+	}
+	hits, misses := ccacheOldHits.FindAllStringSubmatch(out, -1), ccacheOldMiss.FindStringSubmatch(out)
+	if hits == nil || misses == nil {
+		return ccacheStats{}, false
+	}
+	s := ccacheStats{misses: number(misses[1])}
+	for _, h := range hits {
+		s.hits += number(h[1])
+	}
+	return s, true
+}
+
+// ccacheSummary describes the compiles between the counters before and
+// after, like "ccache: 40 hit(s), 2 miss(es) (95% hit rate)". The counters
+// are the user's, shared with every other build on the machine, so other
+// builds running at the same time are counted too. It returns "" when
+// nothing was compiled or the counters were zeroed in between.
+func ccacheSummary(before, after ccacheStats) string {
+	hits, misses := after.hits-before.hits, after.misses-before.misses
+	if hits < 0 || misses < 0 || hits+misses == 0 {
+		return ""
+	}
+	return fmt.Sprintf("ccache: %d hit(s), %d miss(es) (%d%% hit rate)", hits, misses, hits*100/(hits+misses))
+}
+
+// printCCacheSummary prints the ccacheSummary of the compiles since before.
+func printCCacheSummary(before ccacheStats) {
+	after, ok := readCCacheStats()
+	if !ok {
+		return
+	}
+	if summary := ccacheSummary(before, after); summary != "" {
+		fmt.Println(summary)
+	}
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// cmakeMinimum is the cmake_minimum_required of the server CMakeLists
+// unless a --c-std needs a newer CMake.
+const cmakeMinimum = "3.10"
+
+// cStandard is a --c-std choice. version is its CMAKE_C_STANDARD value and
+// extensions sets CMAKE_C_EXTENSIONS; cmake is the first CMake release
+// that knows the standard, empty when cmakeMinimum already does.
+type cStandard struct {
+	id         string
+	version    string
+	extensions bool
+	cmake      string
+}
+
+var cStandards = []cStandard{
+	{id: "c99", version: "99"},
+	{id: "c11", version: "11"},
+	{id: "c17", version: "17", cmake: "3.21"},
+	{id: "gnu99", version: "99", extensions: true},
+	{id: "gnu11", version: "11", extensions: true},
+	{id: "gnu17", version: "17", extensions: true, cmake: "3.21"},
+}
+
+// lookupCStandard resolves a --c-std value; "" selects the template's c11.
+func lookupCStandard(id string) (*cStandard, error) {
+	if id == "" {
+		id = "c11"
+	}
+	var ids []string
+	for i := range cStandards {
+		if cStandards[i].id == id {
+			return &cStandards[i], nil
+		}
+		ids = append(ids, cStandards[i].id)
+	}
+	return nil, fmt.Errorf("unknown C standard %q (supported: %s)", id, strings.Join(ids, ", "))
+}
+
+// resolveCStandard checks --c-std against the server language.
+func resolveCStandard() error {
+	if _, err := lookupCStandard(createOpts.cStd); err != nil {
+		return err
+	}
+	if createOpts.cStd != "" && createOpts.backend != "c" {
+		return fmt.Errorf("--c-std sets the C standard and cannot be combined with --backend %s", createOpts.backend)
+	}
+	return nil
+}
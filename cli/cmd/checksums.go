@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	utils "github.com/Reavix-framework/cli/internal/utils"
+)
+
+// artifactManifestFile, in the output directory, lists the files a build
+// put there with their sizes and hashes, for run --verify and verify.
+const artifactManifestFile = "manifest.json"
+
+// artifactManifestSchema is the schemaVersion of artifactManifestFile,
+// changed only when a field is removed or changes meaning.
+const artifactManifestSchema = 1
+
+// artifactManifest is what artifactManifestFile holds. Paths are relative
+// to the output directory, with forward slashes. Flags are the flags the
+// build was run with, like "--mode=staging".
+type artifactManifest struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	CLIVersion    string          `json:"cliVersion"`
+	Flags         []string        `json:"flags"`
+	Files         []artifactEntry `json:"files"`
+}
+
+type artifactEntry struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// artifactHasher hashes the files build copies into root as it copies
+// them, so that writing the manifest afterwards only reads the files
+// changed since, like a stripped binary or the precompressed siblings.
+type artifactHasher struct {
+	root string
+	mu   sync.Mutex
+	seen map[string]hashedArtifact
+}
+
+// hashedArtifact is a file as it was when copied: a file whose size or
+// time differ from these has been written again since.
+type hashedArtifact struct {
+	size    int64
+	modTime time.Time
+	sum     string
+}
+
+func newArtifactHasher(root string) *artifactHasher {
+	return &artifactHasher{root: root, seen: map[string]hashedArtifact{}}
+}
+
+// copyFile copies src to dst like utils.CopyFile, hashing it on the way.
+func (a *artifactHasher) copyFile(src, dst string) error {
+	h := sha256.New()
+	if err := utils.CopyFileTee(src, dst, h); err != nil {
+		return err
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.seen[dst] = hashedArtifact{size: info.Size(), modTime: info.ModTime(), sum: hex.EncodeToString(h.Sum(nil))}
+	a.mu.Unlock()
+	return nil
+}
+
+// copyDir copies src to dst like utils.CopyDir, hashing every file.
+func (a *artifactHasher) copyDir(src, dst string) error {
+	return utils.CopyDirWith(src, dst, a.copyFile)
+}
+
+// manifest lists the files of names, files or directories in the output
+// directory, rehashing only those written since they were copied.
+func (a *artifactHasher) manifest(names []string) ([]artifactEntry, error) {
+	var entries []artifactEntry
+	for _, name := range names {
+		err := filepath.WalkDir(filepath.Join(a.root, name), func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(a.root, path)
+			if err != nil {
+				return err
+			}
+			a.mu.Lock()
+			copied, ok := a.seen[path]
+			a.mu.Unlock()
+			sum := copied.sum
+			if !ok || copied.size != info.Size() || !copied.modTime.Equal(info.ModTime()) {
+				if sum, err = hashFile(path); err != nil {
+					return err
+				}
+			}
+			entries = append(entries, artifactEntry{Path: filepath.ToSlash(rel), Size: info.Size(), SHA256: sum})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, nil
+}
+
+// save writes the artifactManifestFile of names, see manifest, with the
+// flags of cmd.
+func (a *artifactHasher) save(cmd *cobra.Command, names []string) error {
+	files, err := a.manifest(names)
+	if err != nil {
+		return err
+	}
+	flags := []string{}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		flags = append(flags, "--"+f.Name+"="+f.Value.String())
+	})
+	raw, err := json.MarshalIndent(artifactManifest{SchemaVersion: artifactManifestSchema, CLIVersion: version, Flags: flags, Files: files}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(a.root, artifactManifestFile), append(raw, '\n'), 0644)
+}
+
+// hashFile returns the hex SHA-256 of the file at path.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyArtifacts hashes the files the artifactManifestFile in dir lists
+// again. It returns how many there are and what differs, like "modified:
+// static/index.html": the files whose size or hash changed, those missing,
+// and those added to a directory the manifest lists files of, like static/.
+func verifyArtifacts(dir string) (int, []string, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, artifactManifestFile))
+	if os.IsNotExist(err) {
+		return 0, nil, fmt.Errorf("%s has no %s; build it again with `reavix build`", displayPath(dir), artifactManifestFile)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	var m artifactManifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", displayPath(filepath.Join(dir, artifactManifestFile)), err)
+	}
+
+	var changes []string
+	listed := map[string]bool{}
+	dirs := map[string]bool{}
+	for _, f := range m.Files {
+		listed[f.Path] = true
+		if top, _, nested := strings.Cut(f.Path, "/"); nested {
+			dirs[top] = true
+		}
+		path := filepath.Join(dir, filepath.FromSlash(f.Path))
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			changes = append(changes, "missing: "+f.Path)
+			continue
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		if info.Size() != f.Size {
+			changes = append(changes, "modified: "+f.Path)
+			continue
+		}
+		sum, err := hashFile(path)
+		if err != nil {
+			return 0, nil, err
+		}
+		if sum != f.SHA256 {
+			changes = append(changes, "modified: "+f.Path)
+		}
+	}
+	var tops []string
+	for top := range dirs {
+		tops = append(tops, top)
+	}
+	sort.Strings(tops)
+	for _, top := range tops {
+		err := filepath.WalkDir(filepath.Join(dir, top), func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			if !listed[filepath.ToSlash(rel)] {
+				changes = append(changes, "added: "+filepath.ToSlash(rel))
+			}
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return 0, nil, err
+		}
+	}
+	return len(m.Files), changes, nil
+}
+
+// checkArtifacts runs verifyArtifacts on dir and fails, listing the
+// differences, unless every file is as built.
+func checkArtifacts(dir string) error {
+	n, changes, err := verifyArtifacts(dir)
+	if err != nil {
+		return err
+	}
+	if len(changes) > 0 {
+		for _, change := range changes {
+			fmt.Fprintf(os.Stderr, "  %s\n", change)
+		}
+		return fmt.Errorf("%d file(s) in %s differ from its %s", len(changes), displayPath(dir), artifactManifestFile)
+	}
+	fmt.Printf("Verified the %d file(s) of %s against its %s.\n", n, displayPath(dir), artifactManifestFile)
+	return nil
+}
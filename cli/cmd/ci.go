@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ciProviders maps each --ci value to the files it adds to the project.
+// Supporting another provider only needs a new entry and its templates.
+var ciProviders = map[string][]projectFile{
+	"github": {
+		{path: ".github/workflows/ci.yml", template: "shared/ci-github.yml.tmpl"},
+	},
+}
+
+// ciFiles returns the files for provider, which may be "" for no CI.
+func ciFiles(provider string) ([]projectFile, error) {
+	if provider == "" || provider == "none" {
+		return nil, nil
+	}
+	files, ok := ciProviders[provider]
+	if !ok {
+		var names []string
+		for name := range ciProviders {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown CI provider %q (supported: %s)", provider, strings.Join(names, ", "))
+	}
+	return files, nil
+}
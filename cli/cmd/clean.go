@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Reavix-framework/cli/internal/project"
+	"github.com/spf13/cobra"
+)
+
+var cleanOpts struct {
+	deep bool
+}
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove build output",
+	Long: "Remove the server's CMake build directory, the frontend's dist/ and the output directory of\n" +
+		"`reavix build` (outDir in reavix.json, build/ by default), and with --deep also the frontend's\n" +
+		"node_modules. Only paths inside the project root are touched.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		m, err := requireProject()
+		if err != nil {
+			return err
+		}
+
+		targets := append(serverArtifacts(m), frontendArtifacts(m)...)
+		if outsideRoot(m.OutDir) {
+			fmt.Printf("Skipping %s, which is outside the project root.\n", m.OutDir)
+		} else {
+			targets = append(targets, m.OutDir)
+		}
+		if cleanOpts.deep && m.HasFrontend() {
+			targets = append(targets, filepath.Join(m.Frontend, "node_modules"))
+		}
+		removed, reclaimed, err := removeArtifacts(targets)
+		if err != nil {
+			return err
+		}
+		if removed == 0 {
+			fmt.Println("Nothing to clean.")
+			return nil
+		}
+		fmt.Printf("Reclaimed %s.\n", formatSize(reclaimed))
+		return nil
+	},
+}
+
+// serverArtifacts lists what a server build leaves in the project: the
+// build directory, those of the generated presets and of cross and
+// sanitized builds, the sources of --embed-assets and the
+// compile_commands.json link next to the sources.
+func serverArtifacts(m *project.Manifest) []string {
+	if !m.HasBackend() {
+		return nil
+	}
+	paths := []string{filepath.Join(m.Backend, "build")}
+	cross, _ := filepath.Glob(crossBuildDir(m.Backend, "*"))
+	paths = append(paths, cross...)
+	if hasCMakePresets(m.Backend) {
+		for _, preset := range []string{"dev", "release"} {
+			if dir, err := serverBuildDir(m.Backend, preset); err == nil {
+				paths = append(paths, dir)
+			}
+		}
+	}
+	return append(paths, filepath.Join(m.Backend, embedDir), filepath.Join(m.Backend, "compile_commands.json"))
+}
+
+// frontendArtifacts lists what a frontend build leaves in the project.
+func frontendArtifacts(m *project.Manifest) []string {
+	if !m.HasFrontend() {
+		return nil
+	}
+	return []string{filepath.Join(m.Frontend, "dist")}
+}
+
+// removeArtifacts deletes each existing path, printing it with its size, and
+// returns how many were removed and the bytes reclaimed. It must run in the
+// project root and refuses paths that lead outside it.
+func removeArtifacts(paths []string) (removed int, reclaimed int64, err error) {
+	root, err := os.Getwd()
+	if err != nil {
+		return 0, 0, err
+	}
+	seen := map[string]bool{}
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return removed, reclaimed, err
+		}
+		if seen[abs] {
+			continue
+		}
+		seen[abs] = true
+		rel, err := filepath.Rel(root, abs)
+		if err != nil || rel == "." || outsideRoot(p) {
+			return removed, reclaimed, fmt.Errorf("refusing to remove %s: it is not inside the project root %s", p, root)
+		}
+		if _, err := os.Lstat(abs); os.IsNotExist(err) {
+			continue
+		}
+
+		size := pathSize(abs)
+		if err := os.RemoveAll(abs); err != nil {
+			return removed, reclaimed, fmt.Errorf("removing %s: %w", rel, err)
+		}
+		fmt.Printf("Removed %s (%s)\n", filepath.ToSlash(rel), formatSize(size))
+		removed++
+		reclaimed += size
+	}
+	return removed, reclaimed, nil
+}
+
+// outsideRoot reports whether path, relative to the working directory or
+// absolute, leads out of it.
+func outsideRoot(path string) bool {
+	root, err := os.Getwd()
+	if err != nil {
+		return true
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return true
+	}
+	rel, err := filepath.Rel(root, abs)
+	return err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// pathSize adds up the sizes of the files below path without following
+// symlinks, as node_modules is full of them.
+func pathSize(path string) int64 {
+	var size int64
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// formatSize renders a byte count with a binary unit, like "3.2 MiB".
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func init() {
+	cleanCmd.Flags().BoolVar(&cleanOpts.deep, "deep", false, "Also remove the frontend's node_modules")
+	rootCmd.AddCommand(cleanCmd)
+}
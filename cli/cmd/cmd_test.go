@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Reavix-framework/cli/internal/project"
+)
+
+// chdir changes into dir for the rest of the test, as requireProject does
+// into the project root.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// captureOutput runs f with os.Stdout and os.Stderr going to pipes and
+// returns what it wrote to each.
+func captureOutput(t *testing.T, f func()) (string, string) {
+	t.Helper()
+	read := func(dst **os.File) (func() string, func()) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		saved := *dst
+		*dst = w
+		var buf bytes.Buffer
+		done := make(chan struct{})
+		go func() {
+			io.Copy(&buf, r)
+			close(done)
+		}()
+		return func() string { <-done; return buf.String() }, func() { w.Close(); *dst = saved }
+	}
+	stdout, restoreOut := read(&os.Stdout)
+	stderr, restoreErr := read(&os.Stderr)
+	func() {
+		defer restoreOut()
+		defer restoreErr()
+		f()
+	}()
+	return stdout(), stderr()
+}
+
+// freePort returns a port nothing listens on.
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// writeFiles writes files, paths relative to root mapped to contents.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// scaffoldProject creates a project with the defaults of create in a temp
+// dir, without installing or committing anything, changes into it and
+// returns its root and manifest.
+func scaffoldProject(t *testing.T) (string, *project.Manifest) {
+	t.Helper()
+	saved := createOpts
+	t.Cleanup(func() { createOpts = saved })
+	createOpts.noInstall, createOpts.git = true, false
+	root := filepath.Join(t.TempDir(), "app")
+	captureOutput(t, func() {
+		if err := createProject(root, "app"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	chdir(t, root)
+	m, err := project.Load(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root, m
+}
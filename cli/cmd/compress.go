@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// compressMinSize is the smallest file build precompresses; below it the
+// savings do not pay for the extra request the server has to make for the
+// sibling.
+const compressMinSize = 1024
+
+// compressibleExts are the static file types worth precompressing. Images,
+// fonts like woff2 and other formats that are compressed already are left
+// out, since gzip would only make them bigger.
+var compressibleExts = map[string]bool{
+	".html": true, ".htm": true, ".css": true, ".js": true, ".mjs": true,
+	".cjs": true, ".json": true, ".map": true, ".svg": true, ".txt": true,
+	".xml": true, ".webmanifest": true, ".wasm": true, ".ico": true,
+	".ttf": true, ".otf": true,
+}
+
+// precompressedExts are the extensions of the siblings precompressStatic
+// writes, for gzip and brotli.
+var precompressedExts = []string{".gz", ".br"}
+
+// compressStats sums up what precompressStatic did: how many files got a
+// .gz and their size before and after. brotli is their size when the
+// server prefers the .br where brotliFiles of them have one.
+type compressStats struct {
+	files, brotliFiles     int
+	original, gzip, brotli int64
+}
+
+// precompressStatic writes a gzip .gz sibling, and a .br one when the brotli
+// command is on PATH, next to every compressible file in static of at least
+// compressMinSize, using one worker per CPU. A sibling that would not be
+// smaller than its file is not kept. Siblings left in static by an earlier
+// build are removed first unless dist, the frontend build static was copied
+// from, has them too, so none of them is stale.
+func precompressStatic(dist, static string) (compressStats, error) {
+	var stats compressStats
+	if err := prunePrecompressed(dist, static); err != nil {
+		return stats, err
+	}
+	var files []string
+	err := filepath.WalkDir(static, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() || !compressibleExts[strings.ToLower(filepath.Ext(path))] {
+			return err
+		}
+		if info, err := d.Info(); err != nil || info.Size() < compressMinSize {
+			return err
+		}
+		if !fileExists(path + ".gz") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+	_, lookErr := exec.LookPath("brotli")
+	brotli := lookErr == nil
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	jobs := make(chan string)
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				original, gz, br, err := compressFile(path, brotli)
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = fmt.Errorf("compressing %s: %w", displayPath(path), err)
+				}
+				if err == nil && gz > 0 {
+					stats.files++
+					stats.original += original
+					stats.gzip += gz
+					if br > 0 {
+						stats.brotliFiles++
+						stats.brotli += br
+					} else {
+						stats.brotli += gz
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, path := range files {
+		jobs <- path
+	}
+	close(jobs)
+	wg.Wait()
+	return stats, firstErr
+}
+
+// compressFile writes the .gz, and with brotli the .br, sibling of path and
+// returns the sizes of path and of the siblings it kept, 0 for one that was
+// not smaller than path.
+func compressFile(path string, brotli bool) (original, gz, br int64, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	original = info.Size()
+	if gz, err = keepIfSmaller(path+".gz", original, func(dst string) error {
+		return gzipFile(path, dst)
+	}); err != nil || gz == 0 || !brotli {
+		return original, gz, 0, err
+	}
+	br, err = keepIfSmaller(path+".br", original, func(dst string) error {
+		return quietUnlessVerbose(stdOutput, func(out cmdOutput) error {
+			return runIn(out, ".", "brotli", "--force", "--output="+dst, path)
+		})
+	})
+	return original, gz, br, err
+}
+
+// keepIfSmaller runs write to create dst and removes dst again unless it
+// came out smaller than original, returning its size or 0.
+func keepIfSmaller(dst string, original int64, write func(dst string) error) (int64, error) {
+	if err := write(dst); err != nil {
+		os.Remove(dst)
+		return 0, err
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		return 0, err
+	}
+	if info.Size() >= original {
+		return 0, os.Remove(dst)
+	}
+	return info.Size(), nil
+}
+
+// gzipFile compresses src into dst at the best compression. The header has
+// no name or time, so unchanged files compress to the same bytes.
+func gzipFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	zw, err := gzip.NewWriterLevel(out, gzip.BestCompression)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	if _, err := io.Copy(zw, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// prunePrecompressed removes the .gz and .br files in static that dist does
+// not have, the ones an earlier precompressStatic wrote.
+func prunePrecompressed(dist, static string) error {
+	return filepath.WalkDir(static, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		for _, ext := range precompressedExts {
+			if !strings.HasSuffix(path, ext) {
+				continue
+			}
+			rel, err := filepath.Rel(static, path)
+			if err != nil {
+				return err
+			}
+			if !fileExists(filepath.Join(dist, rel)) {
+				return os.Remove(path)
+			}
+		}
+		return nil
+	})
+}
+
+// summary describes stats for the build summary, like "precompressed 12
+// files: 480.0 KiB to 131.2 KiB with gzip (27%)".
+func (s compressStats) summary() string {
+	if s.files == 0 {
+		return "none were worth precompressing"
+	}
+	percent := func(n int64) int64 { return n * 100 / s.original }
+	line := fmt.Sprintf("precompressed %d file(s): %s to %s with gzip (%d%%)",
+		s.files, formatSize(s.original), formatSize(s.gzip), percent(s.gzip))
+	if s.brotliFiles > 0 {
+		line += fmt.Sprintf(", %s with brotli (%d%%)", formatSize(s.brotli), percent(s.brotli))
+	}
+	return line
+}
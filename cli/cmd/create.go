@@ -1,157 +1,684 @@
 package cmd
 
 import (
-    "fmt"
-    "os"
-    "os/exec"
-    "path/filepath"
-    "text/template"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
 
-    "github.com/spf13/cobra"
-)
+	"github.com/spf13/cobra"
 
-var (
-	readmeTmpl = readFile("readme.tmpl")
-	gitignoreTmpl = readFile("gitignore.tmpl")
-	indexCssTmpl = readFile("index.css.tmpl")
-	viteConfigTmpl = readFile("vite.config.tmpl")
-	tailwindConfigTmpl = readFile("tailwind.config.tmpl")
-	postcssConfigTmpl = readFile("postcss.config.tmpl")
-	mainTsxTmpl = readFile("main.tsx.tmpl")
-	appTsxTmpl = readFile("app.tsx.tmpl")
-	connectionStatusTmpl = readFile("connection_status.tmpl")
-	routerCTmpl = readFile("router.c.tmpl")
-	mainCTmpl = readFile("main.c.tmpl")
-	routerHTmpl = readFile("router.h.tmpl")
-	utilsCTmpl = readFile("utils.c.tmpl")
-	cmakeTmpl = readFile("CMakeLists.txt.tmpl")
+	"github.com/Reavix-framework/cli/internal/project"
+	"github.com/Reavix-framework/cli/templates"
 )
 
-func readFile(filename string) string {
-	templateDir := "/home/kasimlyee/Reavix/cli/templates"
-	absPath := filepath.Join(templateDir, filename)
+var createCMD = &cobra.Command{
+	Use:   "create [app-name]",
+	Short: "Create a new Reavix application",
+	Long:  "Create a new Reavix application.\nRun without arguments in a terminal to answer the options interactively.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if createOpts.listTemplates {
+			if err := listTemplates(createOpts.json); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if createOpts.preset != "" {
+			if err := applyPreset(cmd, createOpts.preset); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		}
 
-	data, err := os.ReadFile(absPath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading file %s: %v\n", absPath, err)
-		return ""
+		var target string
+		if len(args) == 1 {
+			target = args[0]
+		} else if isInteractive() {
+			answer, err := runCreateWizard()
+			if err != nil {
+				fmt.Printf("Error creating project: %v\n", err)
+				os.Exit(1)
+			}
+			target = answer
+		} else {
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		if createOpts.lang != "ts" && createOpts.lang != "js" {
+			fmt.Printf("unknown language %q (supported: ts, js)\n", createOpts.lang)
+			os.Exit(1)
+		}
+		if createOpts.backend != "c" && createOpts.backend != "cpp" {
+			fmt.Printf("unknown backend language %q (supported: c, cpp)\n", createOpts.backend)
+			os.Exit(1)
+		}
+		if err := resolveCStandard(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := resolveDB(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := resolveExample(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := validatePackageManager(createOpts.packageManager); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if _, err := lookupLicense(createOpts.license); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if _, err := lookupFrontend(createOpts.frontend); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if _, err := ciFiles(createOpts.ci); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		variant, err := loadVariant(createOpts.template)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := checkVariantFlags(cmd, variant); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := resolveCSS(variant); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := resolveState(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := resolveRouter(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := resolveRealtime(cmd, variant); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := checkOnly(cmd); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if createOpts.tls && createOpts.backend != "c" {
+			fmt.Println("--tls needs --backend c")
+			os.Exit(1)
+		}
+		if createOpts.storybook && frontendID() != "react" {
+			fmt.Println("--storybook needs --frontend react")
+			os.Exit(1)
+		}
+
+		dir, appName, err := resolveTarget(target)
+		if err != nil {
+			fmt.Printf("Error creating project: %v\n", err)
+			os.Exit(1)
+		}
+		if err := validateAppName(appName); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if createOpts.packageManager == "" {
+			createOpts.packageManager = detectPackageManager(dir)
+		}
+		if createOpts.vars, err = resolveTemplateVars(variant, createOpts.vars); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if createOpts.savePreset != "" {
+			if err := savePreset(cmd, createOpts.savePreset); err != nil {
+				fmt.Printf("Error saving preset: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Saved preset %s\n", createOpts.savePreset)
+		}
+
+		if createOpts.dryRun {
+			if err := dryRunProject(dir, appName); err != nil {
+				fmt.Printf("Error creating project: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		// Scaffolding itself needs nothing; installing the dependencies
+		// needs node and the package manager.
+		if !createOpts.noInstall && hasFrontend() {
+			if err := runPreflight(frontendTools(createOpts.packageManager)); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				fmt.Println("Use --no-install to scaffold without installing the dependencies")
+				os.Exit(1)
+			}
+		}
+
+		if !createOpts.force {
+			if err := ensureEmptyTarget(dir); err != nil {
+				fmt.Printf("Error creating project: %v\n", err)
+				fmt.Println("Use --force to scaffold into it anyway")
+				os.Exit(1)
+			}
+		}
+
+		if err := createProject(dir, appName); err != nil {
+			fmt.Printf("Error creating project: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Project %s created successfully\n", appName)
+		if createOpts.noInstall && hasFrontend() {
+			fmt.Printf("Run `%s` in %s before `reavix dev`\n", strings.Join(installArgs(createOpts.packageManager), " "), filepath.Join(dir, "app"))
+		}
+		if dir != "." {
+			fmt.Printf("Run `cd %s` and then `reavix build` to build the project\n", dir)
+		} else {
+			fmt.Printf("Run `reavix build` to build the project\n")
+		}
+	},
+}
+
+// resolveTarget splits the create argument into the directory to scaffold
+// into and the app name used for templating. A plain name creates a new
+// subdirectory; "." or a "./dir" path scaffolds into that directory and takes
+// the name from its basename.
+func resolveTarget(arg string) (dir, name string, err error) {
+	if arg != "." && !strings.HasPrefix(arg, "./") && !strings.HasPrefix(arg, "."+string(filepath.Separator)) {
+		return arg, arg, nil
 	}
 
-	return string(data)
+	dir = filepath.Clean(arg)
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+	return dir, filepath.Base(abs), nil
 }
 
-var createCMD = &cobra.Command{
-    Use:   "create <app-name>",
-    Short: "Create a new Reavix application",
-    Args:  cobra.ExactArgs(1),
-    Run: func(cmd *cobra.Command, args []string) {
-        appName := args[0]
-        if err := createProject(appName); err != nil {
-            fmt.Printf("Error creating project: %v\n", err)
-            os.Exit(1)
-        }
-
-        fmt.Printf("Project %s created successfully\n", appName)
-        fmt.Printf("Run `reavix build` to build the project\n")
-    },
+type createOptions struct {
+	keepOnFailure  bool
+	dryRun         bool
+	force          bool
+	noInstall      bool
+	packageManager string
+	git            bool
+	lang           string
+	noTailwind     bool
+	license        string
+	noHeaders      bool
+	author         string
+	description    string
+	docker         bool
+	ci             string
+	devcontainer   bool
+	lint           bool
+	tests          bool
+	storybook      bool
+	pwa            bool
+	only           string
+	backend        string
+	cStd           string
+	strict         bool
+	tls            bool
+	db             string
+	realtime       string
+	example        string
+	vscode         bool
+	template       string
+	listTemplates  bool
+	refresh        bool
+	vars           map[string]string
+	noOverrides    bool
+	json           bool
+	preset         string
+	savePreset     string
+	offline        bool
+	frontend       string
+	css            string
+	state          string
+	router         string
 }
 
+var createOpts createOptions
+
 func init() {
-    rootCmd.AddCommand(createCMD)
+	createCMD.Flags().BoolVar(&createOpts.keepOnFailure, "keep-on-failure", false, "Keep partially created files when create fails")
+	createCMD.Flags().BoolVar(&createOpts.force, "force", false, "Scaffold into a non-empty directory and overwrite existing files (originals are saved to .reavix-backup/)")
+	createCMD.Flags().BoolVar(&createOpts.dryRun, "dry-run", false, "Print what would be created without writing anything")
+	createCMD.Flags().BoolVar(&createOpts.noInstall, "no-install", false, "Skip installing frontend dependencies")
+	createCMD.Flags().StringVar(&createOpts.packageManager, "pm", "", "Package manager for frontend dependencies: npm, pnpm, yarn or bun (default: auto-detect)")
+	createCMD.Flags().BoolVar(&createOpts.git, "git", gitAvailable(), "Initialize a git repository with an initial commit (default when git is installed)")
+	createCMD.Flags().StringVar(&createOpts.lang, "lang", "ts", "Frontend language: ts or js")
+	createCMD.Flags().StringVar(&createOpts.backend, "backend", "c", "Server language: c or cpp (C++17)")
+	createCMD.Flags().StringVar(&createOpts.cStd, "c-std", "", "C standard for the server: c99, c11, c17 or their gnu variants (default: the template's C11)")
+	createCMD.Flags().StringVar(&createOpts.db, "db", "none", "Database for the server: sqlite (with an example /api/notes route) or none; needs --backend c")
+	createCMD.Flags().BoolVar(&createOpts.tls, "tls", false, "Serve HTTPS from the server with OpenSSL when REAVIX_TLS_CERT and REAVIX_TLS_KEY are set, plus scripts/gen-dev-cert.sh for a local certificate; needs --backend c")
+	createCMD.Flags().BoolVar(&createOpts.strict, "strict", false, "Build the server with -Wall -Wextra -Werror and sanitize Debug builds with ASan and UBSan")
+	createCMD.Flags().StringVar(&createOpts.frontend, "frontend", "react", "Frontend framework: react, preact or solid")
+	createCMD.Flags().BoolVar(&createOpts.noTailwind, "no-tailwind", false, "Scaffold the frontend with plain CSS instead of Tailwind")
+	createCMD.Flags().StringVar(&createOpts.css, "css", "", "Styling: tailwind, modules (CSS modules) or vanilla (default: tailwind, vanilla with --no-tailwind)")
+	createCMD.Flags().StringVar(&createOpts.state, "state", "none", "State management: zustand, redux (Redux Toolkit) or none; needs --frontend react")
+	createCMD.Flags().StringVar(&createOpts.router, "router", "none", "Client-side router: react-router, tanstack (TanStack Router) or none; needs --frontend react")
+	createCMD.Flags().StringVar(&createOpts.example, "example", "none", "Example to scaffold: crud (a /api/todos server route with a TodoList component) or none; needs --backend c")
+	createCMD.Flags().StringVar(&createOpts.realtime, "realtime", "sse", "Live backend connection for ConnectionStatus: sse (Server-Sent Events), ws (WebSocket) or none (a one-off health check)")
+	createCMD.Flags().StringVar(&createOpts.license, "license", "none", "License to generate: mit, apache-2.0, bsd-3 or none")
+	createCMD.Flags().BoolVar(&createOpts.noHeaders, "no-headers", false, "With --license, don't prepend SPDX license headers to the generated source files")
+	createCMD.Flags().StringVar(&createOpts.author, "author", "", "Copyright holder for the license (default: git config user.name)")
+	createCMD.Flags().StringVar(&createOpts.description, "description", "", "Short project description for the README, package.json and server sources")
+	createCMD.Flags().BoolVar(&createOpts.docker, "docker", false, "Also generate a Dockerfile, docker-compose.yml and .dockerignore")
+	createCMD.Flags().StringVar(&createOpts.ci, "ci", "", "Generate a CI workflow for the given provider (github)")
+	createCMD.Flags().BoolVar(&createOpts.devcontainer, "devcontainer", false, "Generate a .devcontainer setup with node, cmake, gcc and the Reavix CLI")
+	createCMD.Flags().BoolVar(&createOpts.lint, "lint", false, "Set up ESLint and Prettier for the frontend")
+	createCMD.Flags().BoolVar(&createOpts.tests, "tests", false, "Set up Vitest and Testing Library with an example App test")
+	createCMD.Flags().BoolVar(&createOpts.storybook, "storybook", false, "Set up Storybook with an example ConnectionStatus story; needs --frontend react")
+	createCMD.Flags().BoolVar(&createOpts.pwa, "pwa", false, "Make the frontend an installable PWA with vite-plugin-pwa and a service worker")
+	createCMD.Flags().StringVar(&createOpts.only, "only", "", "Scaffold just one half of the project: frontend (app/ only) or backend (server/ only)")
+	createCMD.Flags().BoolVar(&createOpts.vscode, "vscode", false, "Generate VS Code debug configuration for the C server")
+	createCMD.Flags().StringVar(&createOpts.template, "template", defaultVariant, "Project template to scaffold (see --list-templates)")
+	createCMD.Flags().BoolVar(&createOpts.listTemplates, "list-templates", false, "List the available project templates and exit")
+	createCMD.Flags().BoolVar(&createOpts.refresh, "refresh", false, "Fetch a remote --template again instead of using the cached clone")
+	createCMD.Flags().StringToStringVar(&createOpts.vars, "var", nil, "Value for a variable declared by a remote template (name=value, repeatable)")
+	createCMD.Flags().BoolVar(&createOpts.noOverrides, "no-overrides", false, "Ignore template overrides in the user config directory (~/.config/reavix/templates)")
+	createCMD.Flags().BoolVar(&createOpts.json, "json", false, "With --list-templates, print the templates as JSON")
+	createCMD.Flags().StringVar(&createOpts.preset, "preset", "", "Replay the flags saved in a preset; flags given explicitly still win")
+	createCMD.Flags().StringVar(&createOpts.savePreset, "save-preset", "", "Save this command's flags as a preset in ~/.config/reavix/presets.json")
+	createCMD.Flags().BoolVar(&noHooks, "no-hooks", false, "Don't run the postCreate hooks the template writes into reavix.json")
+	createCMD.Flags().BoolVar(&skipChecks, "skip-checks", false, "Don't check that node and the package manager are installed before installing the frontend dependencies")
+	createCMD.Flags().BoolVar(&createOpts.offline, "offline", false, "Install frontend dependencies from the local package manager cache only (see `reavix cache warm`)")
+	rootCmd.AddCommand(createCMD)
+}
+
+// projectFile maps a path inside the new project to the template it is
+// rendered from. fsys is nil for templates embedded in the CLI; raw files are
+// copied as they are instead of being rendered, and generate, when set,
+// produces the content in place of a template.
+type projectFile struct {
+	path     string
+	template string
+	fsys     fs.FS
+	raw      bool
+	generate func(ProjectData) ([]byte, error)
+}
+
+func projectDirs() []string {
+	variant, _ := loadVariant(createOpts.template)
+	var dirs []string
+	for _, d := range variant.Dirs {
+		if !inSkippedHalf(d) {
+			dirs = append(dirs, d)
+		}
+	}
+	return dirs
+}
+
+func projectFiles() []projectFile {
+	variant, _ := loadVariant(createOpts.template)
+	files := variant.files()
+
+	if license, _ := lookupLicense(createOpts.license); license != nil {
+		files = append(files, projectFile{path: "LICENSE", template: license.template})
+	}
+
+	if hasBackend() {
+		files = append(files, projectFile{path: ".clang-format", template: "shared/clang-format.tmpl"})
+	}
+	files = append(files, projectFile{path: "scripts/format.sh", template: "shared/format.sh.tmpl"})
+
+	if createOpts.docker {
+		files = append(files,
+			projectFile{path: "Dockerfile", template: "shared/Dockerfile.tmpl"},
+			projectFile{path: "docker-compose.yml", template: "shared/docker-compose.yml.tmpl"},
+			projectFile{path: ".dockerignore", template: "shared/dockerignore.tmpl"},
+		)
+	}
+
+	if createOpts.devcontainer {
+		files = append(files,
+			projectFile{path: ".devcontainer/devcontainer.json", template: "shared/devcontainer.json.tmpl"},
+			projectFile{path: ".devcontainer/Dockerfile", template: "shared/devcontainer.Dockerfile.tmpl"},
+		)
+	}
+
+	if createOpts.lint {
+		files = append(files,
+			projectFile{path: "app/eslint.config.js", template: "shared/eslint.config.js.tmpl"},
+			projectFile{path: "app/.prettierrc", template: "shared/prettierrc.tmpl"},
+		)
+	}
+
+	if createOpts.tests {
+		ext := createOpts.lang
+		files = append(files,
+			projectFile{path: "app/vitest.config." + ext, template: "shared/vitest.config.tmpl"},
+			projectFile{path: "app/src/setupTests." + ext, template: "shared/setupTests.tmpl"},
+			projectFile{path: "app/src/App.test." + ext + "x", template: "shared/app.test.tmpl"},
+		)
+	}
+
+	if createOpts.storybook {
+		ext := createOpts.lang
+		files = append(files,
+			projectFile{path: "app/.storybook/main." + ext, template: "shared/storybook-main.tmpl"},
+			projectFile{path: "app/.storybook/preview." + ext, template: "shared/storybook-preview.tmpl"},
+			projectFile{path: "app/src/components/ConnectionStatus.stories." + ext + "x", template: "shared/connection_status.stories.tmpl"},
+		)
+	}
+
+	if createOpts.pwa {
+		files = append(files, pwaFiles()...)
+	}
+
+	if createOpts.vscode {
+		files = append(files,
+			projectFile{path: ".vscode/launch.json", template: "shared/vscode-launch.json.tmpl"},
+			projectFile{path: ".vscode/tasks.json", template: "shared/vscode-tasks.json.tmpl"},
+			projectFile{path: ".vscode/settings.json", template: "shared/vscode-settings.json.tmpl"},
+		)
+	}
+
+	ci, _ := ciFiles(createOpts.ci)
+	files = append(files, ci...)
+
+	files = append(files, projectFile{path: project.FileName, template: "(generated)", generate: projectManifest})
+
+	kept := files[:0]
+	for _, f := range files {
+		if !inSkippedHalf(f.path) {
+			kept = append(kept, f)
+		}
+	}
+	files = kept
+
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+	return files
+}
+
+// createProject scaffolds the project and, unless --keep-on-failure is set,
+// removes everything it created when a step fails.
+func createProject(dir, name string) (err error) {
+	created := &createdPaths{}
+	defer func() {
+		if err == nil || createOpts.keepOnFailure {
+			return
+		}
+		if undoErr := created.undo(); undoErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not fully remove partial project: %v\n", undoErr)
+		}
+	}()
+
+	// Render everything before the first write so a broken or missing
+	// template leaves nothing behind.
+	data := newProjectData(name)
+	files := projectFiles()
+	contents, err := renderProjectFiles(files, data)
+	if err != nil {
+		return err
+	}
+
+	if err := created.mkdirAll(dir); err != nil {
+		return err
+	}
+
+	for _, sub := range projectDirs() {
+		fullPath := filepath.Join(dir, sub)
+		if err := created.mkdirAll(fullPath); err != nil {
+			fmt.Printf("Error creating directory %s: %v\n", fullPath, err)
+			return err
+		}
+	}
+
+	backupDir := filepath.Join(dir, ".reavix-backup", time.Now().Format("20060102-150405"))
+	var newFiles, overwritten []string
+	for i, file := range files {
+		path := filepath.Join(dir, file.path)
+		existed := fileExists(path)
+		if existed && !createOpts.force && preservedFiles[file.path] {
+			fmt.Printf("Keeping existing %s\n", file.path)
+			continue
+		}
+		if existed && createOpts.force {
+			if err := created.backup(path, filepath.Join(backupDir, file.path)); err != nil {
+				return fmt.Errorf("failed to back up %s: %w", file.path, err)
+			}
+		}
+		if err := writeProjectFile(created, path, contents[i]); err != nil {
+			return fmt.Errorf("failed to create file %s: %w", file.path, err)
+		}
+		if existed {
+			overwritten = append(overwritten, file.path)
+		} else {
+			newFiles = append(newFiles, file.path)
+		}
+	}
+
+	if createOpts.force {
+		printWriteSummary(newFiles, overwritten, backupDir)
+	}
+
+	if !createOpts.noInstall && hasFrontend() {
+		if err := initFrontendDeps(dir); err != nil {
+			return fmt.Errorf("failed to initialize frontend dependencies: %w", err)
+		}
+	}
+
+	variant, _ := loadVariant(createOpts.template)
+	for _, c := range postCreateCommands(variant, dir) {
+		fmt.Printf("Running %s\n", strings.Join(c.Args, " "))
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		if err := c.Run(); err != nil {
+			return fmt.Errorf("post-create command %q failed: %w", strings.Join(c.Args, " "), err)
+		}
+	}
+	if m, err := project.Load(dir); err == nil {
+		if err := runHooks("postCreate", projectHooks(m).PostCreate, dir, hookEnv(dir, nil)); err != nil {
+			return err
+		}
+	}
+
+	if createOpts.git {
+		if err := initGitRepo(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: git setup failed: %v\n", err)
+		}
+	}
+
+	return nil
 }
 
-func createProject(name string) error {
-    dirs := []string{
-        "app/src/components",
-        "app/src/hooks",
-        "server/src",
-        "server/include",
-        "build",
-        "script",
-    }
-
-    for _, dir := range dirs {
-        fullPath := filepath.Join(name, dir)
-        if err := os.MkdirAll(fullPath, 0755); err != nil {
-            fmt.Printf("Error creating directory %s: %v\n", fullPath, err)
-            return err
-        }
-    }
-
-    files := map[string]struct {
-        content string
-        data    interface{}
-    }{
-        "app/vite.config.ts":                  {content: viteConfigTmpl},
-        "app/tailwind.config.js":              {content: tailwindConfigTmpl},
-        "app/postcss.config.js":               {content: postcssConfigTmpl},
-        "app/src/main.tsx":                    {content: mainTsxTmpl},
-        "app/src/App.tsx":                     {content: appTsxTmpl},
-        "app/src/index.css":                   {content: indexCssTmpl},
-        "app/src/components/ConnectionStatus.tsx": {content: connectionStatusTmpl},
-        "server/src/main.c":                   {content: mainCTmpl},
-        "server/src/router.c":                 {content: routerCTmpl},
-        "server/src/utils.c":                  {content: utilsCTmpl},
-        "server/include/router.h":             {content: routerHTmpl},
-        //"scripts/build.sh":                    {content: buildScriptTmpl, data: map[string]string{"AppName": name}},
-        "server/CMakeLists.txt":               {content: cmakeTmpl},
-        "README.md":                           {content: readmeTmpl, data: map[string]string{"AppName": name}},
-        ".gitignore":                          {content: gitignoreTmpl},
-    }
-
-    for file, templateInfo := range files {
-        if err := createFileFromTemplate(
-            filepath.Join(name, file),
-            templateInfo.content,
-            templateInfo.data,
-        ); err != nil {
-            return fmt.Errorf("failed to create file %s: %w", file, err)
-        }
-    }
-
-    if err := initFrontendDeps(name); err != nil {
-        return fmt.Errorf("failed to initialize frontend dependencies: %w", err)
-    }
-
-    return nil
+func printWriteSummary(newFiles, overwritten []string, backupDir string) {
+	fmt.Printf("Created %d file(s):\n", len(newFiles))
+	for _, f := range newFiles {
+		fmt.Printf("  + %s\n", f)
+	}
+	if len(overwritten) == 0 {
+		return
+	}
+	fmt.Printf("Overwrote %d file(s), originals saved in %s:\n", len(overwritten), backupDir)
+	for _, f := range overwritten {
+		fmt.Printf("  ~ %s\n", f)
+	}
+}
+
+// dryRunProject renders every template in memory and prints what
+// createProject would do, without touching the filesystem.
+func dryRunProject(dir, name string) error {
+	fmt.Printf("Dry run: project %s would be created in %s with\n\n", name, dir)
+
+	fmt.Println("Directories:")
+	fmt.Printf("  %s/\n", dir)
+	for _, sub := range projectDirs() {
+		fmt.Printf("  %s/\n", filepath.Join(dir, sub))
+	}
+
+	fmt.Println("\nFiles:")
+	data := newProjectData(name)
+	for _, file := range projectFiles() {
+		content, err := renderTemplate(file, data)
+		if err != nil {
+			return fmt.Errorf("failed to render file %s: %w", file.path, err)
+		}
+		fmt.Printf("  %-50s %6d bytes\n", filepath.Join(dir, file.path), len(content))
+	}
+
+	fmt.Println("\nCommands:")
+	if !createOpts.noInstall && hasFrontend() {
+		for _, c := range frontendCommands(dir) {
+			fmt.Printf("  (in %s) %s\n", c.Dir, strings.Join(c.Args, " "))
+		}
+	}
+	variant, _ := loadVariant(createOpts.template)
+	for _, c := range postCreateCommands(variant, dir) {
+		fmt.Printf("  (in %s) %s\n", c.Dir, strings.Join(c.Args, " "))
+	}
+	if createOpts.git {
+		for _, args := range gitSetupCommands(dir) {
+			fmt.Printf("  (in %s) git %s\n", dir, strings.Join(args, " "))
+		}
+	}
+
+	fmt.Println("\nNothing was written.")
+	return nil
+}
+
+func frontendCommands(projectDir string) []*exec.Cmd {
+	appDir := filepath.Join(projectDir, "app")
+	pm := createOpts.packageManager
+
+	steps := [][]string{installArgs(pm)}
+	if createOpts.offline {
+		steps[0] = offlineInstallArgs(pm)
+	}
+	if !createOpts.noTailwind {
+		steps = append(steps, execArgs(pm, "tailwindcss", "init", "-p"))
+	}
+
+	var cmds []*exec.Cmd
+	for _, args := range steps {
+		c := exec.Command(args[0], args[1:]...)
+		c.Dir = appDir
+		cmds = append(cmds, c)
+	}
+	return cmds
 }
 
 func initFrontendDeps(projectDir string) error {
-    cmd := exec.Command("npm", "install", "-D", "vite", "@vitejs/plugin-react", "tailwindcss", "postcss", "autoprefixer", "typescript", "@types/react", "@types/react-dom")
-    cmd.Dir = filepath.Join(projectDir, "app")
-    cmd.Stdout = os.Stdout
-    cmd.Stderr = os.Stderr
-
-    if err := cmd.Run(); err != nil {
-        return fmt.Errorf("failed to install frontend dependencies: %w", err)
-    }
-
-    cmd = exec.Command("npx", "tailwindcss", "init", "-p")
-    cmd.Dir = filepath.Join(projectDir, "app")
-    cmd.Stdout = os.Stdout
-    cmd.Stderr = os.Stderr
-    return cmd.Run()
+	cmds := frontendCommands(projectDir)
+
+	install := cmds[0]
+	var output bytes.Buffer
+	install.Stdout = io.MultiWriter(os.Stdout, &output)
+	install.Stderr = io.MultiWriter(os.Stderr, &output)
+	if err := install.Run(); err != nil {
+		if !createOpts.offline {
+			return fmt.Errorf("failed to install frontend dependencies: %w", err)
+		}
+		// An offline miss is expected to be fixable later, so keep the
+		// scaffold and fall back to --no-install.
+		reportOfflineMisses(output.String())
+		createOpts.noInstall = true
+		return nil
+	}
+
+	for _, cmd := range cmds[1:] {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func createFileFromTemplate(path, content string, data interface{}) error {
-    if data == nil {
-        data = map[string]interface{}{}
-    }
-    tmpl, err := template.New("file").Parse(content)
-    if err != nil {
-        return err
-    }
-
-    if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-        return err
-    }
-
-    file, err := os.Create(path)
-    if err != nil {
-        return err
-    }
-	defer file.Close()
-
-    return tmpl.Execute(file, data)
+// renderTemplate executes the template behind file against data. Raw files
+// are returned unchanged and generated ones come from file.generate.
+func renderTemplate(file projectFile, data ProjectData) ([]byte, error) {
+	if file.generate != nil {
+		return file.generate(data)
+	}
+	fsys, name, source := templateSource(file, templates.FS)
+	if verbose {
+		fmt.Printf("  %s <- %s (%s)\n", file.path, file.template, source)
+	}
+	src, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load template %s: %w", file.template, err)
+	}
+	if file.raw {
+		return src, nil
+	}
+	if len(bytes.TrimSpace(src)) == 0 {
+		return nil, fmt.Errorf("template %s (%s) is empty", file.template, source)
+	}
+
+	left, right := templateDelims(file.path)
+	tmpl, err := template.New(path.Base(name)).Delims(left, right).Funcs(templateFuncs).Parse(string(src))
+	if err != nil {
+		if source != "embedded" {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return addLicenseHeader(file.path, buf.Bytes(), data), nil
 }
 
+// renderProjectFiles renders every file up front, returning the contents in
+// the same order or the first template that fails to load or parse.
+func renderProjectFiles(files []projectFile, data ProjectData) ([][]byte, error) {
+	contents := make([][]byte, len(files))
+	for i, file := range files {
+		content, err := renderTemplate(file, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render file %s: %w", file.path, err)
+		}
+		contents[i] = content
+	}
+	return contents, nil
+}
+
+// writeProjectFile writes rendered content to path. It refuses to replace an
+// existing file unless --force was given.
+func writeProjectFile(created *createdPaths, path string, content []byte) error {
+	if !createOpts.force && fileExists(path) {
+		return fmt.Errorf("%s already exists (use --force to overwrite it)", path)
+	}
+
+	out, err := created.create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := out.Write(content); err != nil {
+		return err
+	}
+	// Shell scripts are scaffolded ready to run.
+	if filepath.Ext(path) == ".sh" {
+		return out.Chmod(0755)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Reavix-framework/cli/templates"
+)
+
+// variantWithout registers, under a name of its own, a copy of the full
+// variant rendered from a template FS that lacks the template missing.
+func variantWithout(t *testing.T, missing string) string {
+	t.Helper()
+	full, err := loadBuiltinVariant(defaultVariant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{}
+	for _, f := range full.Files {
+		if f.Template == missing {
+			continue
+		}
+		data, err := fs.ReadFile(templates.FS, f.Template)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fsys[f.Template] = &fstest.MapFile{Data: data}
+	}
+	variant := *full
+	variant.id, variant.fsys = "missing-template", fsys
+	loadedVariants[variant.id] = &variant
+	t.Cleanup(func() { delete(loadedVariants, variant.id) })
+	return variant.id
+}
+
+func TestCreateProjectMissingTemplateLeavesNothing(t *testing.T) {
+	saved := createOpts
+	t.Cleanup(func() { createOpts = saved })
+	createOpts.template = variantWithout(t, "full/router.c.tmpl")
+	createOpts.noInstall = true
+
+	t.Run("new directory", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "app")
+		err := createProject(dir, "app")
+		if err == nil || !strings.Contains(err.Error(), "full/router.c.tmpl") {
+			t.Fatalf("createProject = %v, want an error naming the missing template", err)
+		}
+		if _, err := os.Lstat(dir); !os.IsNotExist(err) {
+			t.Errorf("%s was left behind", dir)
+		}
+	})
+
+	t.Run("existing directory", func(t *testing.T) {
+		dir := t.TempDir()
+		readme := filepath.Join(dir, "README.md")
+		if err := os.WriteFile(readme, []byte("mine\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		createOpts.force = true
+		defer func() { createOpts.force = false }()
+		if err := createProject(dir, "app"); err == nil {
+			t.Fatal("createProject succeeded without full/router.c.tmpl")
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].Name() != "README.md" {
+			var names []string
+			for _, e := range entries {
+				names = append(names, e.Name())
+			}
+			t.Errorf("%s holds %v, want only README.md", dir, names)
+		}
+		if data, _ := os.ReadFile(readme); string(data) != "mine\n" {
+			t.Errorf("README.md = %q, want it untouched", data)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// cssStrategies are the --css values. tailwind is the default unless
+// --no-tailwind is given; modules and vanilla both imply --no-tailwind.
+var cssStrategies = []string{"tailwind", "modules", "vanilla"}
+
+// resolveCSS settles createOpts.css against --no-tailwind and what variant
+// supports, keeping noTailwind in step with the result.
+func resolveCSS(variant *projectVariant) error {
+	if createOpts.css == "" {
+		createOpts.css = "tailwind"
+		if createOpts.noTailwind || !variant.Tailwind {
+			createOpts.css = "vanilla"
+		}
+	}
+	if !containsString(cssStrategies, createOpts.css) {
+		return fmt.Errorf("unknown CSS strategy %q (supported: %s)", createOpts.css, strings.Join(cssStrategies, ", "))
+	}
+	if createOpts.css == "tailwind" && createOpts.noTailwind {
+		return fmt.Errorf("--css tailwind cannot be combined with --no-tailwind")
+	}
+	if createOpts.css == "tailwind" && !variant.Tailwind {
+		return fmt.Errorf("template %s does not support Tailwind (use --css vanilla)", variant.id)
+	}
+	createOpts.noTailwind = createOpts.css != "tailwind"
+	return nil
+}
+
+// moduleClass is the CSS modules key for a plain class name: app-header
+// becomes appHeader.
+func moduleClass(plain string) string {
+	return camelCase(plain)
+}
+
+// classAttr renders a JSX class attribute value for the CSS strategy: the
+// Tailwind utilities, the plain class or a CSS modules lookup.
+func classAttr(css, tailwind, plain string) string {
+	switch css {
+	case "tailwind":
+		return fmt.Sprintf("%q", tailwind)
+	case "modules":
+		return "{styles." + moduleClass(plain) + "}"
+	default:
+		return fmt.Sprintf("%q", plain)
+	}
+}
+
+// classValue is classAttr as a JavaScript expression, for class names held
+// in variables.
+func classValue(css, tailwind, plain string) string {
+	if css == "modules" {
+		return "styles." + moduleClass(plain)
+	}
+	return classAttr(css, tailwind, plain)
+}
@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Reavix-framework/cli/internal/project"
+)
+
+// pidFile is where `reavix run --detach` keeps the server it started, at
+// the project root, until `reavix stop` stops it.
+const pidFile = ".reavix/app.pid"
+
+// detachedRunEnv is set in the environment of the run that --supervise
+// --detach starts in the background, to tell it to supervise the server
+// rather than detach again.
+const detachedRunEnv = "REAVIX_RUN_DETACHED"
+
+// killWait is how long stopDaemon waits for a daemon to go after SIGKILL,
+// which only a process stuck in the kernel survives.
+const killWait = 5 * time.Second
+
+// daemon is the server `reavix run --detach` started, or with --supervise
+// the run supervising it, name says which: its process, the port the
+// server listens on, the log their output goes to and, from the time of
+// the pid file, when it started. exited is closed once the process
+// detachServer started exits; it is nil for one read from pidFile.
+type daemon struct {
+	pid     int
+	name    string
+	port    int
+	log     string
+	started time.Time
+	exited  chan struct{}
+}
+
+// alive reports whether the process of d still runs. The process this run
+// started is waited for, so it is gone once it exits, zombie or not.
+func (d *daemon) alive() bool {
+	if d.exited == nil {
+		return processAlive(d.pid)
+	}
+	select {
+	case <-d.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+// writePidFile writes d to pidFile: the PID on the first line, as init
+// systems and scripts read it, then the port, the log and the name.
+func writePidFile(d *daemon) error {
+	if err := os.MkdirAll(filepath.Dir(pidFile), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(pidFile, []byte(fmt.Sprintf("%d\n%d\n%s\n%s\n", d.pid, d.port, d.log, d.name)), 0o644)
+}
+
+// runningDaemon returns the server of pidFile, or nil when there is none.
+// A pid file whose process is gone is removed, and said to be.
+func runningDaemon() (*daemon, error) {
+	f, err := os.Open(pidFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	d := &daemon{name: "server", started: info.ModTime()}
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimSpace(scanner.Text()))
+	}
+	if len(lines) > 0 {
+		d.pid, _ = strconv.Atoi(lines[0])
+	}
+	if d.pid <= 0 {
+		return nil, fmt.Errorf("%s has no PID on its first line; remove it if no server runs", pidFile)
+	}
+	if len(lines) > 1 {
+		d.port, _ = strconv.Atoi(lines[1])
+	}
+	if len(lines) > 2 {
+		d.log = lines[2]
+	}
+	if len(lines) > 3 && lines[3] != "" {
+		d.name = lines[3]
+	}
+	if !processAlive(d.pid) {
+		f.Close()
+		if err := os.Remove(pidFile); err != nil {
+			return nil, err
+		}
+		fmt.Printf("Removed %s, as its %s, pid %d, is no longer running.\n", pidFile, d.name, d.pid)
+		return nil, nil
+	}
+	return d, nil
+}
+
+// detachServer starts c, the server or the supervisor of it, name says,
+// in a session of its own, with stdin from the null device and its output
+// going to a log of run in logDir, records it in pidFile and returns
+// without waiting for it to exit, which d.exited tells. The log has no
+// times and is not split by logs.maxSizeMb, as reavix is not there to
+// write it.
+func detachServer(c *exec.Cmd, name string, m *project.Manifest, port int, logDir string) (*daemon, error) {
+	log, err := openDetachedLog(logDir, time.Now(), m)
+	if err != nil {
+		return nil, err
+	}
+	defer log.Close()
+	c.Stdout, c.Stderr = log, log
+	detachProcess(c)
+	if err := c.Start(); err != nil {
+		return nil, withExitCode(exitNotRunnable, err, "starting the %s: %w", name)
+	}
+	d := &daemon{pid: c.Process.Pid, name: name, port: port, log: log.Name(), exited: make(chan struct{})}
+	go func() {
+		c.Wait()
+		close(d.exited)
+	}()
+	if err := writePidFile(d); err != nil {
+		return nil, fmt.Errorf("the %s runs as pid %d, but %s could not be written: %w", name, d.pid, pidFile, err)
+	}
+	fmt.Printf("The %s runs as pid %d, on port %d, logging to %s.\n", name, d.pid, port, displayPath(d.log))
+	fmt.Println("`reavix status` shows how it is doing and `reavix stop` stops it.")
+	return d, nil
+}
+
+// awaitDetachedHealthy waits for the server of d to answer url, as
+// awaitHealthy does, for at most timeout. A server that does not is stopped
+// as stopDaemon does, with shutdown as its timeout, and the last lines of
+// its log shown.
+func awaitDetachedHealthy(d *daemon, url string, timeout, shutdown time.Duration) error {
+	err := awaitHealthy(url, timeout, d.exited)
+	if err == nil {
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Stopping the %s, pid %d, as the server is not healthy...\n", d.name, d.pid)
+	if err := stopDaemon(d, shutdown); err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if lines, err := logTail(d.log, devTailLines); err == nil {
+		printTail("its log", lines)
+	}
+	return err
+}
+
+// stopDaemon stops d as systemd's KillMode=mixed does: SIGTERM to d, which
+// a supervisor passes on to its server, then, once d is gone or still runs
+// timeout later, SIGKILL to what is left of its process group. It removes
+// pidFile once d is gone, and gives up when d outlives the SIGKILL by
+// killWait.
+func stopDaemon(d *daemon, timeout time.Duration) error {
+	p, err := os.FindProcess(d.pid)
+	if err != nil {
+		return err
+	}
+	if err := signalProcess(p, false, syscall.SIGTERM); err != nil && d.alive() {
+		return fmt.Errorf("stopping the %s, pid %d: %w", d.name, d.pid, err)
+	}
+	deadline := time.Now().Add(timeout)
+	for d.alive() && (timeout == 0 || time.Now().Before(deadline)) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if d.alive() {
+		fmt.Fprintf(os.Stderr, "Warning: the %s did not stop within %s, so it was killed.\n", d.name, timeout)
+	}
+	signalProcess(p, true, syscall.SIGKILL)
+	for deadline := time.Now().Add(killWait); d.alive(); time.Sleep(100 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("the %s, pid %d, still runs %s after SIGKILL; %s is left in place", d.name, d.pid, killWait, pidFile)
+		}
+	}
+	return os.Remove(pidFile)
+}
+
+// processRSS returns the resident memory of the process pid in bytes, from
+// /proc on Linux, else from ps, or -1 when neither tells.
+func processRSS(pid int) int64 {
+	if status, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "status")); err == nil {
+		for _, line := range strings.Split(string(status), "\n") {
+			// VmRSS:     12345 kB
+			if fields := strings.Fields(line); len(fields) == 3 && fields[0] == "VmRSS:" {
+				if kb, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+					return kb << 10
+				}
+			}
+		}
+	}
+	out, err := exec.Command("ps", "-o", "rss=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return -1
+	}
+	kb, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return kb << 10
+}
+
+// logTail returns the last n lines of the log at path.
+func logTail(path string, n int) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Reavix-framework/cli/internal/project"
+)
+
+const (
+	defaultAppPort    = project.DefaultAppPort
+	defaultServerPort = project.DefaultServerPort
+)
+
+// ProjectData is the data every scaffolding template is rendered with.
+type ProjectData struct {
+	Name        string
+	Description string
+	Author      string
+	Year        string
+
+	// HasFrontend and HasBackend are false for the half --only leaves out.
+	HasFrontend bool
+	HasBackend  bool
+
+	// BackendLang is the server language, "c" or "cpp". CStd is the -std
+	// of the C server and CStdVersion its CMAKE_C_STANDARD; CExtensions is
+	// the CMAKE_C_EXTENSIONS value, empty to keep CMake's default when no
+	// --c-std was given. CMakeMinimum is the cmake_minimum_required version
+	// and Strict enables the warning and sanitizer flags of --strict. TLS
+	// adds the OpenSSL layer of --tls.
+	BackendLang  string
+	CStd         string
+	CStdVersion  string
+	CExtensions  string
+	CMakeMinimum string
+	Strict       bool
+	TLS          bool
+	// DB is the server's database, "sqlite" or empty without --db.
+	// Realtime is the --realtime mechanism, "sse" or "ws", and RealtimePath
+	// the endpoint both halves use for it; both are empty for none. Example
+	// is the --example slice, "crud" or empty.
+	DB           string
+	Realtime     string
+	RealtimePath string
+	Example      string
+
+	// Lang is the frontend language, "ts" or "js". Frontend is the UI
+	// framework id and FrontendName its display name; VitePluginImport and
+	// VitePlugin wire the framework's plugin into the Vite config.
+	Lang             string
+	Frontend         string
+	FrontendName     string
+	VitePluginImport string
+	VitePlugin       string
+
+	Tailwind bool
+	// CSS is the styling strategy: "tailwind", "modules" or "vanilla".
+	CSS string
+	// State is the state library: "zustand", "redux" or "none". Router is
+	// the client-side router id and RouterModule the package its components
+	// are imported from, empty without a router.
+	State        string
+	Router       string
+	RouterModule string
+
+	Docker bool
+	Lint   bool
+	// Tests enables the Vitest setup; TestingLibrary is the Testing Library
+	// package for the chosen framework.
+	Tests          bool
+	TestingLibrary string
+	Storybook      bool
+	// PWA enables vite-plugin-pwa; ThemeColor is the web app manifest's
+	// theme color.
+	PWA        bool
+	ThemeColor string
+
+	// License is the SPDX identifier and LicenseName its display name; both
+	// are empty when no license was requested. LicenseHeaders prefixes the
+	// generated sources with an SPDX header, unless --no-headers was given.
+	License        string
+	LicenseName    string
+	LicenseHeaders bool
+
+	// AppPort is the Vite dev server port, ServerPort the port the C server
+	// listens on and BinaryName the name of the built server executable.
+	AppPort    int
+	ServerPort int
+	BinaryName string
+
+	// PM is the package manager command and PackageManager the
+	// "name@version" recorded in package.json.
+	PM              string
+	PackageManager  string
+	Dependencies    string
+	DevDependencies string
+
+	// Vars holds the variables declared by a remote template.
+	Vars map[string]string
+}
+
+func newProjectData(name string) ProjectData {
+	data := ProjectData{
+		Name:            name,
+		Description:     createOpts.description,
+		Author:          resolveAuthor(name),
+		Year:            strconv.Itoa(time.Now().Year()),
+		HasFrontend:     hasFrontend(),
+		HasBackend:      hasBackend(),
+		BackendLang:     createOpts.backend,
+		CMakeMinimum:    cmakeMinimum,
+		Strict:          createOpts.strict,
+		TLS:             createOpts.tls,
+		Lang:            createOpts.lang,
+		Tailwind:        !createOpts.noTailwind,
+		CSS:             createOpts.css,
+		State:           createOpts.state,
+		Docker:          createOpts.docker,
+		Lint:            createOpts.lint,
+		Tests:           createOpts.tests,
+		Storybook:       createOpts.storybook,
+		PWA:             createOpts.pwa,
+		ThemeColor:      pwaThemeColor,
+		AppPort:         defaultAppPort,
+		ServerPort:      defaultServerPort,
+		BinaryName:      project.DefaultBinary,
+		PM:              createOpts.packageManager,
+		PackageManager:  packageManagerSpec(createOpts.packageManager),
+		Dependencies:    dependencyJSON(frontendDependencies()),
+		DevDependencies: dependencyJSON(frontendDevDependencies()),
+		Vars:            createOpts.vars,
+	}
+	if framework, _ := lookupFrontend(createOpts.frontend); framework != nil {
+		data.Frontend = framework.id
+		data.FrontendName = framework.name
+		data.VitePluginImport = framework.vitePluginImport
+		data.VitePlugin = framework.vitePlugin
+		data.TestingLibrary = framework.testingLibrary
+	}
+	if createOpts.db != "none" {
+		data.DB = createOpts.db
+	}
+	if createOpts.example != "none" {
+		data.Example = createOpts.example
+	}
+	if std, _ := lookupCStandard(createOpts.cStd); std != nil {
+		data.CStd = std.id
+		data.CStdVersion = std.version
+		if createOpts.cStd != "" {
+			data.CExtensions = "OFF"
+			if std.extensions {
+				data.CExtensions = "ON"
+			}
+		}
+		if std.cmake != "" {
+			data.CMakeMinimum = std.cmake
+		}
+	}
+	if mechanism, _ := lookupRealtime(createOpts.realtime); mechanism != nil && mechanism.path != "" {
+		data.Realtime = mechanism.id
+		data.RealtimePath = mechanism.path
+	}
+	if router, _ := lookupRouter(createOpts.router); router != nil {
+		data.Router = router.id
+		data.RouterModule = router.module
+	}
+	if license, _ := lookupLicense(createOpts.license); license != nil {
+		data.License = license.spdx
+		data.LicenseName = license.name
+		data.LicenseHeaders = !createOpts.noHeaders
+	}
+	return data
+}
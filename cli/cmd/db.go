@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// serverDatabases are the --db values; none scaffolds no storage layer.
+var serverDatabases = []string{"none", "sqlite"}
+
+// resolveDB checks --db against the server language. The SQLite layer and
+// its /api/notes route are written against the C server.
+func resolveDB() error {
+	if createOpts.db == "" {
+		createOpts.db = "none"
+	}
+	if !containsString(serverDatabases, createOpts.db) {
+		return fmt.Errorf("unknown database %q (supported: %s)", createOpts.db, strings.Join(serverDatabases, ", "))
+	}
+	if createOpts.db != "none" && createOpts.backend != "c" {
+		return fmt.Errorf("--db %s needs --backend c", createOpts.db)
+	}
+	return nil
+}
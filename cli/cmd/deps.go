@@ -0,0 +1,91 @@
+package cmd
+
+import "encoding/json"
+
+// Pinned versions of the frontend packages the scaffold depends on, so a
+// project created with --no-install resolves the same tree later.
+func frontendDependencies() map[string]string {
+	framework, _ := lookupFrontend(createOpts.frontend)
+	deps := map[string]string{}
+	for name, v := range framework.deps {
+		deps[name] = v
+	}
+	if library, _ := lookupState(createOpts.state); library != nil {
+		for name, v := range library.deps {
+			deps[name] = v
+		}
+	}
+	if router, _ := lookupRouter(createOpts.router); router != nil {
+		for name, v := range router.deps {
+			deps[name] = v
+		}
+	}
+	return deps
+}
+
+func frontendDevDependencies() map[string]string {
+	framework, _ := lookupFrontend(createOpts.frontend)
+	deps := map[string]string{
+		"vite": "5.4.10",
+	}
+	for name, v := range framework.devDeps {
+		deps[name] = v
+	}
+	if createOpts.lang != "js" {
+		for name, v := range framework.tsDevDeps {
+			deps[name] = v
+		}
+	}
+	if !createOpts.noTailwind {
+		deps["autoprefixer"] = "10.4.20"
+		deps["postcss"] = "8.4.47"
+		deps["tailwindcss"] = "3.4.14"
+	}
+	if createOpts.lang != "js" {
+		deps["typescript"] = "5.6.3"
+	}
+	if createOpts.tests {
+		deps["@testing-library/jest-dom"] = "6.6.2"
+		deps["jsdom"] = "25.0.1"
+		deps["vitest"] = "2.1.4"
+		for name, v := range framework.testDevDeps {
+			deps[name] = v
+		}
+	}
+	if createOpts.pwa {
+		deps["vite-plugin-pwa"] = "0.20.5"
+	}
+	if createOpts.storybook {
+		deps["@storybook/addon-essentials"] = "8.4.2"
+		deps["@storybook/react"] = "8.4.2"
+		deps["@storybook/react-vite"] = "8.4.2"
+		deps["storybook"] = "8.4.2"
+	}
+	if createOpts.lint {
+		deps["@eslint/js"] = "9.13.0"
+		deps["eslint"] = "9.13.0"
+		if framework.id == "solid" {
+			deps["eslint-plugin-solid"] = "0.14.3"
+		} else {
+			deps["eslint-plugin-react"] = "7.37.2"
+			deps["eslint-plugin-react-hooks"] = "5.0.0"
+			deps["eslint-plugin-react-refresh"] = "0.4.14"
+		}
+		deps["globals"] = "15.11.0"
+		deps["prettier"] = "3.3.3"
+		if createOpts.lang != "js" {
+			deps["typescript-eslint"] = "8.11.0"
+		}
+	}
+	return deps
+}
+
+// dependencyJSON renders deps as a JSON object indented to sit inside the
+// top-level object of package.json.
+func dependencyJSON(deps map[string]string) string {
+	out, err := json.MarshalIndent(deps, "  ", "  ")
+	if err != nil {
+		return "{}"
+	}
+	return string(out)
+}
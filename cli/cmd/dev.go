@@ -2,55 +2,516 @@ package cmd
 
 import (
 	"fmt"
+	"net/url"
 	"os"
-	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 	
+	"github.com/Reavix-framework/cli/internal/logs"
+	"github.com/Reavix-framework/cli/internal/project"
 	"github.com/spf13/cobra"
 
 )
 
+var devOpts struct {
+	debug                bool
+	release              bool
+	jobs                 int
+	reconfigure          bool
+	generator            string
+	sanitize             []string
+	ccache               bool
+	noWatch              bool
+	ignoreBackendFailure bool
+	filter               string
+	appPort              int
+	serverPort           int
+	portAuto             bool
+	only                 string
+	api                  string
+	mode                 string
+	envFiles             []string
+	open                 bool
+	https                bool
+	debugServer          bool
+	waitDebugger         bool
+	mockAPI              string
+	logDir               string
+	notify               bool
+}
+
 var devCmd = &cobra.Command{
 	Use: "dev",
 	Short: "Start development server",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("Starting development server...")
-
-		go func(){
-			backendDir := filepath.Join("server","build")
-			os.MkdirAll(backendDir, 0755)
-
-			cmds := []*exec.Cmd{
-				exec.Command("cmake", ".."),
-				exec.Command("make"),
-				exec.Command("./server"),
+	Long: "Build the server and start it next to the Vite dev server of the frontend, rebuilding and\n" +
+		"restarting it whenever its sources change. Both get the variables of the .env files of --mode\n" +
+		"at the project root. In a terminal, r rebuilds and restarts the server, c clears the screen,\n" +
+		"o opens the app in the browser, h lists these keys and q stops dev, as Ctrl+C does.\n\n" +
+		"Exit status: 3 when the server fails to build, unless --ignore-backend-failure is given, 2\n" +
+		"when the Vite dev server fails, 128+N when signal N stopped dev, 1 for any other error.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		started := time.Now()
+		if err := checkInspect(); err != nil {
+			return err
+		}
+		envFiles, err := absPathFlags(devOpts.envFiles)
+		if err != nil {
+			return err
+		}
+		mockFlag, err := absPathFlag(devOpts.mockAPI)
+		if err != nil {
+			return err
+		}
+		logDir := defaultLogDir
+		if cmd.Flags().Changed("log-dir") {
+			if logDir, err = absPathFlag(devOpts.logDir); err != nil {
+				return err
+			}
+		}
+		sanitize, err := resolveSanitizers(devOpts.sanitize)
+		if err != nil {
+			return err
+		}
+		mode := "development"
+		if devOpts.mode != "" {
+			if !buildMode.MatchString(devOpts.mode) {
+				return fmt.Errorf("invalid --mode %q: use letters, digits, '.', '_' and '-'", devOpts.mode)
+			}
+			mode = devOpts.mode
+		}
+		if len(sanitize) > 0 && devOpts.release {
+			return fmt.Errorf("--sanitize builds are Debug builds; drop --release")
+		}
+		switch devOpts.filter {
+		case "", "app", "server":
+		default:
+			return fmt.Errorf("invalid --filter %q: use app or server", devOpts.filter)
+		}
+		m, err := requireProject()
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		if err := applyDevOnly(cmd, m, devOpts.only); err != nil {
+			return err
+		}
+		api, err := resolveDevAPI(m, devOpts.api)
+		if err != nil {
+			return err
+		}
+		if api != "" && mockFlag != "" {
+			return fmt.Errorf("--api and --mock-api both answer /api; pass one of them")
+		}
+		// The remote server of --api makes mock/api.json of no use.
+		var mockFile string
+		if api == "" {
+			if mockFile, err = resolveMockFile(mockFlag); err != nil {
+				return err
+			}
+		}
+		if devOpts.filter == "app" && !m.HasFrontend() || devOpts.filter == "server" && !m.HasBackend() {
+			return fmt.Errorf("--filter %s shows nothing, as this project was created without it", devOpts.filter)
+		}
+		config := newResolvedConfig(cmd, m)
+		settings, err := resolveServerSettings(config, m, "Debug")
+		if err != nil {
+			return err
+		}
+		buildType, preset, jobs, generator, ccache := settings.buildType, settings.preset, settings.jobs, settings.generator, settings.ccache
+		if inspectFormat != "" {
+			return inspectDev(config, m, mode, envFiles, api, mockFile, logDir, sanitize)
+		}
+		cmd.SilenceUsage = true
+		if m.HasBackend() {
+			if err := checkServerDebug(); err != nil {
+				return err
+			}
+			checkNotify()
+		}
+		if err := runPreflight(projectTools(m, preset, generator, m.HasFrontend(), m.HasBackend())); err != nil {
+			return err
+		}
+		if m.HasBackend() {
+			if err := recordGenerator(m, generator); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not record the generator in %s: %v\n", project.FileName, err)
+			}
+		}
+		if hooks := projectHooks(m); len(hooks.PreDev) > 0 && !noHooks {
+			info, err := resolveBuildInfo(".", "", m.AppVersion)
+			if err != nil {
+				return err
+			}
+			outDir, err := filepath.Abs(m.OutDir)
+			if err != nil {
+				return err
+			}
+			env := hookEnv(".", map[string]string{
+				"VERSION": info.Version, "COMMIT": info.Commit, "MODE": mode, "OUT_DIR": outDir,
+			})
+			if err := runHooks("preDev", hooks.PreDev, ".", env); err != nil {
+				return err
+			}
+		}
+		ports, err := resolveDevPorts(m, devOpts.appPort, devOpts.serverPort, devOpts.portAuto)
+		if err != nil {
+			return err
+		}
+		vars, err := resolveProjectEnv(mode, envFiles)
+		if err != nil {
+			return err
+		}
+		projectEnv := childEnv(vars)
+		var cert devCert
+		if devOpts.https {
+			if !m.HasFrontend() && !m.TLS {
+				return fmt.Errorf("--https with --only backend needs a server created with `reavix create --tls`")
+			}
+			if cert, err = ensureDevCert(); err != nil {
+				return err
+			}
+			ports.appTLS = m.HasFrontend()
+			ports.serverTLS = m.HasBackend() && m.TLS
+			if m.HasFrontend() && !viteConfigReads(m, "REAVIX_HTTPS_CERT") {
+				fmt.Fprintf(os.Stderr, "Warning: the Vite config in %s does not read REAVIX_HTTPS_CERT, so the app stays on HTTP; see the server.https of a new project.\n", m.Frontend)
+				ports.appTLS = false
 			}
+			if m.HasBackend() && !m.TLS {
+				fmt.Println("The server stays on HTTP behind the Vite proxy, as it was created without --tls.")
+			}
+		}
+		fmt.Printf("Starting development server (%s)...\n", devHalves(m))
+		printLoadedEnv(vars)
+		// r restarts the watched server; see readDevKeys.
+		var restart chan struct{}
+		if m.HasBackend() && !devOpts.noWatch {
+			restart = make(chan struct{}, 1)
+		}
+		openURL := ports.serverURL()
+		if m.HasFrontend() {
+			openURL = ports.appURL()
+		}
+		readDevKeys(openURL, restart)
+		defer restoreTerminal()
+		stopDevOnSignal()
 
-			for _, c := range cmds{
-				c.Dir = backendDir
-				c.Stdout = os.Stdout
-				c.Stderr = os.Stderr
-				if err := c.Run(); err != nil{
-					fmt.Println("Server error: %v\n", err)
-					return
+		// Each line of the two dev servers, and of the server's builds, says
+		// which one it came from.
+		mux := outputMux(devOpts.filter)
+		appLog, serverLog := mux.Stream("app", logs.Cyan), mux.Stream("server", logs.Magenta)
+		ready := watchReadiness(appLog, serverLog)
+		if logDir != "" {
+			var names []string
+			if m.HasFrontend() {
+				names = append(names, "app")
+			}
+			if m.HasBackend() {
+				names = append(names, "server")
+			}
+			sessionLogs := startSessionLogs(logDir, "dev", names, started, m)
+			if l := sessionLogs["app"]; l != nil {
+				appLog.Tee(l.writer())
+			}
+			if l := sessionLogs["server"]; l != nil {
+				serverLog.Tee(l.writer())
+			}
+		}
+		ready.serverSuspended = devOpts.waitDebugger
+		var mock *mockAPI
+		startMock := func() error {
+			var mockCert *devCert
+			if devOpts.https && m.TLS {
+				// Vite proxies to a server created with --tls over HTTPS.
+				mockCert = &cert
+			}
+			var err error
+			if mock, err = startMockAPI(mockFile, ports.server, mockCert, mux.Stream("mock", logs.Yellow)); err != nil {
+				return err
+			}
+			ready.mock = displayPath(mockFile)
+			return nil
+		}
+		if !m.HasBackend() && mockFile != "" {
+			if err := startMock(); err != nil {
+				return err
+			}
+		}
+		buildOnce := func(reconfigure bool) (*serverBuild, error) {
+			var built *serverBuild
+			var before ccacheStats
+			cached := false
+			if ccache {
+				before, cached = readCCacheStats()
+			}
+			err := quietUnlessVerbose(streamOutput(serverLog), func(out cmdOutput) error {
+				var err error
+				opts := serverBuildOptions{preset: preset, buildType: buildType, jobs: jobs, generator: generator, reconfigure: devOpts.reconfigure || reconfigure}
+				if ccache {
+					opts.defines = ccacheDefines(m.BackendLang)
+				}
+				if len(sanitize) > 0 {
+					// Run the sanitized binary, so memory errors surface
+					// while developing.
+					built, err = buildSanitized(out, m.Backend, sanitize, opts)
+					return err
+				}
+				built, err = buildServer(out, m.Backend, opts)
+				return err
+			})
+			serverLog.Flush()
+			if err != nil {
+				return nil, withExitCode(exitBackendBuild, err, "server build failed: %w")
+			}
+			if cached {
+				printCCacheSummary(before)
+			}
+			return built, nil
+		}
+		// The server builds before the frontend starts, so a failure is not
+		// lost among the output of Vite.
+		var built *serverBuild
+		if m.HasBackend() {
+			fmt.Println("Building the server...")
+			built, err = buildOnce(false)
+			if err != nil {
+				keepGoing := (devOpts.ignoreBackendFailure || mockFile != "") && (m.HasFrontend() || !devOpts.noWatch)
+				if !keepGoing {
+					hint := "Fix it and run `reavix dev` again."
+					if m.HasFrontend() {
+						hint = "Fix it and run `reavix dev` again, or pass --ignore-backend-failure to run the frontend alone."
+					}
+					printBox(os.Stderr, "The server failed to build: "+err.Error(), hint)
+					notifyDesktop("Reavix: the server build failed", strings.TrimSuffix(hint, "."))
+					return &exitError{code: exitBackendBuild, err: err, reported: true}
+				}
+				status := "The API is down; only the frontend is running."
+				if !devOpts.noWatch {
+					status = "The API is down until a change to its sources builds it."
+				}
+				if mockFile != "" {
+					status = "The API is mocked from " + displayPath(mockFile) + "."
+					if !devOpts.noWatch {
+						status = "The API is mocked from " + displayPath(mockFile) + " until a change to its sources builds it."
+					}
+				}
+				printBox(os.Stderr, "The server failed to build: "+err.Error(), status)
+				notifyDesktop("Reavix: the server build failed", status)
+				if mockFile != "" {
+					if err := startMock(); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		// Both get the variables of the env files. The server listens on
+		// REAVIX_PORT, and Vite proxies /api to REAVIX_SERVER_PORT.
+		serverEnv := append(append([]string(nil), projectEnv...), "REAVIX_PORT="+strconv.Itoa(ports.server))
+		if ports.serverTLS {
+			serverEnv = append(serverEnv, "REAVIX_TLS_CERT="+cert.cert, "REAVIX_TLS_KEY="+cert.key)
+		}
+		// The pages of the app hear of the restarts of the watched server,
+		// so they need no refresh to reach it again.
+		var events *devEvents
+		if m.HasFrontend() && m.HasBackend() && !devOpts.noWatch {
+			var eventsCert *devCert
+			if ports.appTLS {
+				eventsCert = &cert
+			}
+			if events, err = startDevEvents(eventsCert, m.Dev != nil && m.Dev.ReloadOnRestart); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: the app will not hear of server restarts: %v\n", err)
+			}
+		}
+		runServer := func() error {
+			if !devOpts.noWatch {
+				server := &devServer{log: serverLog, env: serverEnv, mock: mock, ready: ready, crashed: make(chan struct{}, 1),
+					autoRestart: !devOpts.debugServer && !devOpts.waitDebugger}
+				if events != nil {
+					server.healthURL, server.restarted = devHealthURL(m, ports), events.serverRestarted
 				}
+				watchServer(m, server, built, buildOnce, restart)
+				return nil
 			}
-		}()
+			if built == nil {
+				return nil
+			}
+			argv := serverArgv()
+			c, err := startCommand(streamOutput(serverLog), built.dir, serverEnv, true, argv[0], argv[1:]...)
+			if err == nil {
+				announceServer(c.Process.Pid)
+				err = waitCommand(c)
+			}
+			serverLog.Flush()
+			running.waitIfStopping()
+			if err != nil {
+				return fmt.Errorf("server stopped with %w", err)
+			}
+			return nil
+		}
 
-		frontendCmd := exec.Command("npm", "run", "dev")
-		frontendCmd.Dir = "app"
-		frontendCmd.Stdout = os.Stdout
-		frontendCmd.Stderr = os.Stderr
+		open := m.Dev != nil && m.Dev.Open
+		if cmd.Flags().Changed("open") {
+			open = devOpts.open
+		}
+		go awaitDevReady(m, ports, api, built != nil, started, ready, open)
 
-		if err := frontendCmd.Run(); err != nil{
-			fmt.Println("App error: %v\n", err)
-			return
+		// Projects created with --only, and dev --only, have a single half;
+		// run just that one.
+		if !m.HasFrontend() {
+			return runServer()
 		}
-	
-	
+		if m.HasBackend() {
+			// The Vite dev server keeps running when the server stops, so
+			// the frontend can still be worked on.
+			go func() {
+				if err := runServer(); err != nil {
+					printBox(os.Stderr, "The "+err.Error()+", so the API is down.", "Only the frontend is running now.")
+				}
+			}()
+		}
+
+		// --strictPort makes Vite fail rather than move on by itself, which
+		// only happens when the port was taken since it was checked.
+		viteArgs := []string{"--port", strconv.Itoa(ports.app), "--strictPort"}
+		if devOpts.mode != "" {
+			viteArgs = append(viteArgs, "--mode", devOpts.mode)
+		}
+		scriptArgs := runScriptArgs(m.PackageManager, "dev", viteArgs...)
+		appEnv := append(append([]string(nil), projectEnv...), "REAVIX_SERVER_PORT="+strconv.Itoa(ports.server))
+		if api != "" {
+			appEnv = append(appEnv, "REAVIX_API_URL="+api)
+		}
+		if events != nil {
+			appEnv = append(appEnv, "VITE_REAVIX_DEV_EVENTS="+events.url)
+		}
+		if ports.appTLS {
+			appEnv = append(appEnv, "REAVIX_HTTPS_CERT="+cert.cert, "REAVIX_HTTPS_KEY="+cert.key)
+		}
+		err = runInGroup(streamOutput(appLog), m.Frontend, appEnv, scriptArgs[0], scriptArgs[1:]...)
+		appLog.Flush()
+		running.waitIfStopping()
+		if err != nil {
+			return withExitCode(exitFrontendBuild, err, "app dev server failed: %w")
+		}
+		return nil
 },
 }
 
+// resolveDevAPI validates --api, the URL of the remote server the frontend
+// proxies /api to, which needs the local server left out. It returns ""
+// without --api.
+func resolveDevAPI(m *project.Manifest, api string) (string, error) {
+	if api == "" {
+		return "", nil
+	}
+	if m.HasBackend() {
+		return "", fmt.Errorf("--api replaces the local server, so it needs --only frontend")
+	}
+	u, err := url.Parse(api)
+	if err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return "", fmt.Errorf("invalid --api %q: want an http:// or https:// URL, like https://staging.example.com", api)
+	}
+	// Vite configs older than --api do not read REAVIX_API_URL and keep
+	// proxying to the local port.
+	if !viteConfigReads(m, "REAVIX_API_URL") {
+		fmt.Fprintf(os.Stderr, "Warning: the Vite config in %s does not read REAVIX_API_URL, so --api is ignored; set the /api proxy target to process.env.REAVIX_API_URL.\n", m.Frontend)
+	}
+	return strings.TrimSuffix(api, "/"), nil
+}
+
+// viteConfigReads reports whether the Vite config of m mentions the
+// variable dev passes it, which configs made before the variable existed
+// do not, or whether there is no Vite config to tell.
+func viteConfigReads(m *project.Manifest, variable string) bool {
+	configs, _ := filepath.Glob(filepath.Join(m.Frontend, "vite.config.*"))
+	for _, config := range configs {
+		if data, err := os.ReadFile(config); err == nil && strings.Contains(string(data), variable) {
+			return true
+		}
+	}
+	return len(configs) == 0
+}
+
+// devHalves says which dev servers dev starts for m.
+func devHalves(m *project.Manifest) string {
+	switch {
+	case !m.HasBackend():
+		return "the frontend only"
+	case !m.HasFrontend():
+		return "the server only"
+	}
+	return "the frontend and the server"
+}
+
+// devStopTimeout is how long dev gives the dev servers to stop on Ctrl+C
+// before it kills them.
+const devStopTimeout = 5 * time.Second
+
+// stopDevOnSignal stops the dev servers on SIGINT or SIGTERM. They run in
+// process groups of their own, see runInGroup, so the signal only reaches
+// dev, which passes SIGTERM on to both groups, grandchildren like esbuild
+// included, and kills what is left after devStopTimeout. A server left
+// running would hold its port, so the next dev could not start. dev then
+// exits with 128 plus the number of the signal, as a shell reports a
+// command the signal ended.
+func stopDevOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		code := exitFailure
+		if s, ok := sig.(syscall.Signal); ok {
+			code = 128 + int(s)
+		}
+		stopDev(code)
+	}()
+}
+
+var devStopOnce sync.Once
+
+// stopDev stops the dev servers, restores the terminal and exits with
+// code. Should a signal and q both ask for it, the first one wins.
+func stopDev(code int) {
+	devStopOnce.Do(func() {
+		restoreTerminal()
+		fmt.Println("\nStopping the dev servers...")
+		if !running.stopAll(devStopTimeout) {
+			fmt.Fprintf(os.Stderr, "Warning: the dev servers did not stop within %s, so they were killed.\n", devStopTimeout)
+		}
+		os.Exit(code)
+	})
+}
+
 func init(){
+	devCmd.Flags().BoolVar(&devOpts.ccache, "ccache", false, "Compile the server through ccache (default: when ccache is installed, unless cache.ccache in reavix.json is false)")
+	devCmd.Flags().StringSliceVar(&devOpts.sanitize, "sanitize", nil, "Build and run the server with sanitizers: address, undefined or thread (repeatable or comma-separated)")
+	devCmd.Flags().StringVar(&devOpts.generator, "generator", "", "CMake generator for the server: ninja, make or auto (default: generator in reavix.json, else ninja when installed; with CMakePresets.json, the presets' own)")
+	devCmd.Flags().BoolVar(&devOpts.reconfigure, "reconfigure", false, "Run the CMake configure step even when the server's build directory is up to date")
+	devCmd.Flags().IntVarP(&devOpts.jobs, "jobs", "j", 0, "Parallel compile jobs for the server (default: jobs in reavix.json, else one per CPU; Ninja picks its own)")
+	devCmd.Flags().BoolVar(&devOpts.debug, "debug", false, "Build the server with CMAKE_BUILD_TYPE=Debug, the default")
+	devCmd.Flags().BoolVar(&devOpts.release, "release", false, "Build the server with CMAKE_BUILD_TYPE=Release (the release preset with CMakePresets.json)")
+	devCmd.Flags().BoolVar(&devOpts.noWatch, "no-watch", false, "Build and start the server once instead of rebuilding and restarting it when its sources change")
+	devCmd.Flags().IntVar(&devOpts.appPort, "app-port", 0, "Port of the Vite dev server (default: ports.app in reavix.json)")
+	devCmd.Flags().IntVar(&devOpts.serverPort, "server-port", 0, "Port of the server, passed to it as REAVIX_PORT (default: ports.server in reavix.json)")
+	devCmd.Flags().BoolVar(&devOpts.portAuto, "port-auto", false, "Move to the next free port when a port is in use instead of failing")
+	devCmd.Flags().StringVar(&devOpts.only, "only", "", "Start one half of the project only: frontend or backend")
+	devCmd.Flags().StringVar(&devOpts.api, "api", "", "With --only frontend, proxy /api to the server at this URL instead of a local one")
+	devCmd.Flags().StringVar(&devOpts.mode, "mode", "", "Mode whose .env.<mode> files are read, also passed to Vite (default: development)")
+	devCmd.Flags().StringArrayVar(&devOpts.envFiles, "env-file", nil, "Read this env file instead of those at the project root (repeatable, later files win)")
+	devCmd.Flags().BoolVar(&devOpts.debugServer, "debug-server", false, "Run the server under gdb, or lldb on macOS, so a crash prints a backtrace, with core dumps on")
+	devCmd.Flags().BoolVar(&devOpts.waitDebugger, "wait-debugger", false, "Start the server stopped and print its process ID for a debugger to attach to, with core dumps on")
+	devCmd.Flags().BoolVar(&devOpts.https, "https", false, "Serve the app over HTTPS with a certificate for localhost made in .reavix/certs, and the server too when created with --tls")
+	devCmd.Flags().BoolVar(&devOpts.open, "open", false, "Open the app in the browser once it is ready (default: dev.open in reavix.json)")
+	devCmd.Flags().StringVar(&devOpts.mockAPI, "mock-api", "", "Serve the canned responses of this file on the server's port when the server is left out or fails to build (default: mock/api.json, if there)")
+	devCmd.Flags().StringVar(&devOpts.logDir, "log-dir", defaultLogDir, "Directory of the session logs of the dev servers, kept for logs.maxAgeDays of reavix.json; empty for none")
+	devCmd.Flags().BoolVar(&devOpts.notify, "notify", false, "Show a desktop notification when the server fails to build or is crash-looping, through notify-send or osascript")
+	devCmd.Flags().StringVar(&devOpts.filter, "filter", "", "Show the output of one dev server only: app or server")
+	devCmd.Flags().BoolVar(&devOpts.ignoreBackendFailure, "ignore-backend-failure", false, "Start the frontend even when the server fails to build, with the API down, or mocked, until it builds")
+	addInspectFlag(devCmd)
+	devCmd.Flags().BoolVar(&noHooks, "no-hooks", false, "Don't run the preDev hooks of reavix.json")
+	devCmd.Flags().BoolVar(&skipChecks, "skip-checks", false, "Don't check that cmake, make, a compiler, node and the package manager are installed first")
 	rootCmd.AddCommand(devCmd)
 }
\ No newline at end of file
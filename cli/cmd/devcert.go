@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// devCertDir is where dev --https keeps its certificates, at the project
+// root: a local certificate authority, made once so that trusting it
+// lasts, and the certificate for localhost it signs.
+const devCertDir = ".reavix/certs"
+
+// The lifetimes of the certificates of dev --https. The one for localhost
+// is made again once it has less than devCertRenewal left; browsers refuse
+// certificates valid for longer than 398 days.
+const (
+	devCAValidity   = 10 * 365 * 24 * time.Hour
+	devCertValidity = 365 * 24 * time.Hour
+	devCertRenewal  = 30 * 24 * time.Hour
+)
+
+// devCert is the certificate and key of dev --https, as absolute paths,
+// since the server does not run at the project root.
+type devCert struct {
+	cert, key string
+}
+
+// ensureDevCert returns the certificate for localhost of dev --https,
+// making the certificate authority and the certificate in devCertDir when
+// they are missing, and the certificate again when it expires within
+// devCertRenewal. A new authority comes with how to trust it, printed
+// then only.
+func ensureDevCert() (devCert, error) {
+	dir, err := filepath.Abs(devCertDir)
+	if err != nil {
+		return devCert{}, err
+	}
+	caPath, caKeyPath := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "ca-key.pem")
+	out := devCert{cert: filepath.Join(dir, "localhost.pem"), key: filepath.Join(dir, "localhost-key.pem")}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return devCert{}, err
+	}
+
+	ca, caKey, err := loadCertPair(caPath, caKeyPath)
+	if err != nil || time.Until(ca.NotAfter) < devCertValidity {
+		ca, caKey, err = writeCert(caPath, caKeyPath, &x509.Certificate{
+			Subject:               pkix.Name{CommonName: "Reavix development CA", Organization: []string{"Reavix"}},
+			NotAfter:              time.Now().Add(devCAValidity),
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+			MaxPathLenZero:        true,
+		}, nil, nil)
+		if err != nil {
+			return devCert{}, fmt.Errorf("creating the development certificate authority: %w", err)
+		}
+		printTrustInstructions(caPath)
+		// A certificate of the previous authority is of no use now.
+		os.Remove(out.cert)
+	}
+
+	if cert, _, err := loadCertPair(out.cert, out.key); err == nil && time.Until(cert.NotAfter) > devCertRenewal {
+		return out, nil
+	}
+	_, _, err = writeCert(out.cert, out.key, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "localhost", Organization: []string{"Reavix development"}},
+		NotAfter:    time.Now().Add(devCertValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:    []string{"localhost"},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}, ca, caKey)
+	if err != nil {
+		return devCert{}, fmt.Errorf("creating the development certificate: %w", err)
+	}
+	fmt.Printf("Created a certificate for localhost in %s, valid until %s.\n", devCertDir, time.Now().Add(devCertValidity).Format("2006-01-02"))
+	return out, nil
+}
+
+// loadCertPair reads the PEM certificate at certPath and its ECDSA key at
+// keyPath.
+func loadCertPair(certPath, keyPath string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	certBlock, _ := pem.Decode(certPEM)
+	keyBlock, _ := pem.Decode(keyPEM)
+	if certBlock == nil || keyBlock == nil {
+		return nil, nil, errors.New("not PEM")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// writeCert makes a P-256 key and the certificate template signed by
+// parent with parentKey, or self-signed when parent is nil, and writes both
+// as PEM, the key readable by its owner only.
+func writeCert(certPath, keyPath string, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		return nil, nil, err
+	}
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	return cert, key, err
+}
+
+// printTrustInstructions says how to make the browsers of this OS trust
+// the certificate authority at caPath, so they stop warning about the
+// certificates it signs.
+func printTrustInstructions(caPath string) {
+	ca := displayPath(caPath)
+	lines := []string{
+		"Created a certificate authority for dev --https in " + devCertDir + ".",
+		"Browsers warn about its certificates until you trust it, once:",
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		lines = append(lines, "  sudo security add-trusted-cert -d -r trustRoot -k /Library/Keychains/System.keychain "+ca)
+	case "windows":
+		lines = append(lines, "  certutil -user -addstore Root "+ca)
+	default:
+		lines = append(lines,
+			"  sudo cp "+ca+" /usr/local/share/ca-certificates/reavix-dev.crt && sudo update-ca-certificates",
+			"  and, for Chrome and Firefox, import "+ca+" as an authority in their certificate settings.")
+	}
+	lines = append(lines, "Keep "+devCertDir+" out of version control; its keys are for this machine only.")
+	printBox(os.Stdout, lines...)
+}
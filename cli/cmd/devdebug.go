@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// serverDebugger is the debugger --debug-server runs the server under and
+// --wait-debugger suggests attaching with: lldb on macOS, gdb elsewhere.
+func serverDebugger() string {
+	if runtime.GOOS == "darwin" {
+		return "lldb"
+	}
+	return "gdb"
+}
+
+// checkServerDebug validates --debug-server and --wait-debugger and, with
+// either, says where the core dumps they enable go.
+func checkServerDebug() error {
+	if !devOpts.debugServer && !devOpts.waitDebugger {
+		return nil
+	}
+	if devOpts.debugServer && devOpts.waitDebugger {
+		return fmt.Errorf("--debug-server runs the server under %s and --wait-debugger waits for one to attach; pass one of them", serverDebugger())
+	}
+	if runtime.GOOS == "windows" {
+		return fmt.Errorf("--debug-server and --wait-debugger are not supported on Windows; attach the Visual Studio debugger to the server instead")
+	}
+	if devOpts.debugServer {
+		if _, err := exec.LookPath(serverDebugger()); err != nil {
+			return fmt.Errorf("--debug-server needs %s, which is not installed", serverDebugger())
+		}
+	}
+	if devOpts.release {
+		fmt.Fprintln(os.Stderr, "Warning: a --release server has no debug info, so backtraces show addresses rather than lines.")
+	}
+	if pattern, err := os.ReadFile("/proc/sys/kernel/core_pattern"); err == nil {
+		fmt.Printf("Core dumps of the server are on; the kernel writes them as %s.\n", strings.TrimSpace(string(pattern)))
+	}
+	return nil
+}
+
+// serverArgv is the command dev runs the server built in its directory
+// with: ./server, or under --debug-server the debugger running it, which
+// prints a backtrace into the server's output when it crashes. With either
+// debug flag a shell raises the core size limit for it first and, with
+// --wait-debugger, stops itself before it execs the server, which keeps
+// its process ID, so that a debugger can attach and continue it.
+func serverArgv() []string {
+	argv := []string{"./server"}
+	if devOpts.debugServer {
+		if serverDebugger() == "lldb" {
+			argv = []string{"lldb", "--batch", "-o", "run", "-k", "bt", "-k", "quit", "--", "./server"}
+		} else {
+			argv = []string{"gdb", "-q", "-batch", "-return-child-result", "-ex", "run", "-ex", "bt", "--args", "./server"}
+		}
+	}
+	if !devOpts.debugServer && !devOpts.waitDebugger {
+		return argv
+	}
+	script := "ulimit -c unlimited 2>/dev/null; "
+	if devOpts.waitDebugger {
+		script += "kill -STOP $$; "
+	}
+	return append([]string{"sh", "-c", script + `exec "$@"`, "sh"}, argv...)
+}
+
+// announceServer tells, with --wait-debugger, the process ID of the server
+// that was just started and waits for a debugger, and how to attach.
+func announceServer(pid int) {
+	if !devOpts.waitDebugger {
+		return
+	}
+	id := strconv.Itoa(pid)
+	lines := []string{
+		"The server is stopped as process " + id + " until a debugger continues it. Attach with:",
+		"  " + serverDebugger() + " -p " + id,
+		"then continue from the debugger.",
+	}
+	if scope, err := os.ReadFile("/proc/sys/kernel/yama/ptrace_scope"); err == nil && strings.TrimSpace(string(scope)) != "0" {
+		lines = append(lines, "ptrace_scope keeps debuggers from attaching to processes they did not start; attach with sudo.")
+	}
+	printBox(os.Stdout, lines...)
+}
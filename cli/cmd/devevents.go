@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// websocketGUID is what the key of a WebSocket handshake is hashed with for
+// the accept header, as RFC 6455 says.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// devEvents is the WebSocket dev tells the pages of the app through that it
+// restarted the server, on a port of its own. Vite hands its URL to the app
+// as VITE_REAVIX_DEV_EVENTS, which the devEvents module of new projects
+// connects to; a page then checks the API again, or reloads with reload,
+// the dev.reloadOnRestart of reavix.json.
+type devEvents struct {
+	url    string
+	reload bool
+	mu     sync.Mutex
+	conns  map[net.Conn]bool
+}
+
+// startDevEvents serves the events of dev on a free port of localhost, over
+// TLS with cert when it is set, as a page served over HTTPS cannot open a
+// plain WebSocket.
+func startDevEvents(cert *devCert, reload bool) (*devEvents, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	scheme := "ws"
+	if cert != nil {
+		scheme = "wss"
+	}
+	e := &devEvents{
+		url:    scheme + "://localhost:" + strconv.Itoa(ln.Addr().(*net.TCPAddr).Port),
+		reload: reload,
+		conns:  map[net.Conn]bool{},
+	}
+	// WebSockets need HTTP/1.1, so HTTP/2 stays off.
+	srv := &http.Server{Handler: e, TLSNextProto: map[string]func(*http.Server, *tls.Conn, http.Handler){}}
+	if cert != nil {
+		go srv.ServeTLS(ln, cert.cert, cert.key)
+	} else {
+		go srv.Serve(ln)
+	}
+	return e, nil
+}
+
+// ServeHTTP takes a page's WebSocket handshake and keeps its connection
+// until the page goes away.
+func (e *devEvents) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, "This is where reavix dev tells the app of server restarts, over a WebSocket.", http.StatusUpgradeRequired)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "cannot take over the connection", http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return
+	}
+	e.mu.Lock()
+	e.conns[conn] = true
+	e.mu.Unlock()
+	// A page sends nothing but the close of its connection, so reading is
+	// only for noticing that.
+	go func() {
+		io.Copy(io.Discard, rw)
+		e.drop(conn)
+	}()
+}
+
+// drop closes conn and forgets it.
+func (e *devEvents) drop(conn net.Conn) {
+	e.mu.Lock()
+	delete(e.conns, conn)
+	e.mu.Unlock()
+	conn.Close()
+}
+
+// serverRestarted tells the pages connected that the server restarted and
+// answers again.
+func (e *devEvents) serverRestarted() {
+	e.broadcast("server-restarted")
+}
+
+// broadcast sends the event of type kind to every page connected, dropping
+// those that do not take it within a second.
+func (e *devEvents) broadcast(kind string) {
+	payload, _ := json.Marshal(struct {
+		Type   string `json:"type"`
+		Reload bool   `json:"reload"`
+	}{kind, e.reload})
+	frame := websocketFrame(payload)
+	e.mu.Lock()
+	conns := make([]net.Conn, 0, len(e.conns))
+	for conn := range e.conns {
+		conns = append(conns, conn)
+	}
+	e.mu.Unlock()
+	for _, conn := range conns {
+		conn.SetWriteDeadline(time.Now().Add(time.Second))
+		if _, err := conn.Write(frame); err != nil {
+			e.drop(conn)
+		}
+	}
+}
+
+// websocketFrame frames payload as one unmasked text message, as a server
+// sends them.
+func websocketFrame(payload []byte) []byte {
+	frame := []byte{0x81}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 126, 0, 0)
+		binary.BigEndian.PutUint16(frame[2:], uint16(n))
+	default:
+		frame = append(frame, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(frame[2:], uint64(n))
+	}
+	return append(frame, payload...)
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// restoreTerminal puts the terminal back the way it was before dev read
+// keys from it, if it did. It can be called any number of times.
+var restoreTerminal = func() {}
+
+// devKeyHelp is what h prints.
+var devKeyHelp = []string{
+	"Shortcuts:",
+	"  r  rebuild and restart the server",
+	"  c  clear the screen",
+	"  o  open the app in the browser",
+	"  q  quit",
+	"  h  show this help",
+}
+
+// readDevKeys handles the shortcuts of dev pressed in its terminal when
+// stdin is one, like the dev server of Vite: r sends on restart, which
+// rebuilds and restarts the server the way a change to its sources does,
+// c clears the screen, o opens url in the browser, q stops dev as Ctrl+C
+// does but with exit status 0, and h lists them. The terminal is put in
+// cbreak mode for the keys to arrive as they are pressed, and restored by
+// restoreTerminal, which dev calls however it ends. restart is nil when
+// no server is watched. Without a terminal, nothing happens.
+func readDevKeys(url string, restart chan<- struct{}) {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return
+	}
+	restore, err := cbreakTerminal(os.Stdin)
+	if err != nil {
+		return
+	}
+	var once sync.Once
+	restoreTerminal = func() { once.Do(restore) }
+	fmt.Println("Press h for the keyboard shortcuts.")
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				restoreTerminal()
+				panic(r)
+			}
+		}()
+		key := make([]byte, 1)
+		for {
+			if n, err := os.Stdin.Read(key); err != nil || n == 0 {
+				return
+			}
+			switch strings.ToLower(string(key)) {
+			case "r":
+				if restart == nil {
+					fmt.Println("r rebuilds the server while dev watches it, which it does not here.")
+					continue
+				}
+				select {
+				case restart <- struct{}{}:
+				default:
+					// A rebuild is already asked for.
+				}
+			case "c":
+				fmt.Print("\x1b[H\x1b[2J\x1b[3J")
+			case "o":
+				if !openBrowser(url) {
+					fmt.Printf("Open %s in your browser.\n", url)
+				}
+			case "q":
+				stopDev(0)
+			case "h":
+				fmt.Println(strings.Join(devKeyHelp, "\n"))
+			case "\x03":
+				// Ctrl+C, should the terminal not turn it into SIGINT.
+				stopDev(130)
+			}
+		}
+	}()
+}
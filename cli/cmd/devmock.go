@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Reavix-framework/cli/internal/logs"
+)
+
+// defaultMockFile is the mock API dev serves without --mock-api, when the
+// project has one there.
+const defaultMockFile = "mock/api.json"
+
+// mockRouteKey is a key of a mock file: a path, optionally behind a method.
+var mockRouteKey = regexp.MustCompile(`^(?:([A-Z]+) +)?(/\S*)$`)
+
+// mockRoute is one response of the mock API. Body is any JSON, sent as it
+// is.
+type mockRoute struct {
+	Status  int             `json:"status"`
+	Body    json.RawMessage `json:"body"`
+	DelayMs int             `json:"delayMs"`
+
+	method, path string
+}
+
+// matches reports whether r answers method and path, and how closely: an
+// exact path over a path ending in *, which matches what it starts with,
+// and a method over none.
+func (r *mockRoute) matches(method, path string) (int, bool) {
+	score := 0
+	if r.method != "" {
+		if r.method != method {
+			return 0, false
+		}
+		score++
+	}
+	if prefix := strings.TrimSuffix(r.path, "*"); prefix != r.path {
+		if !strings.HasPrefix(path, prefix) {
+			return 0, false
+		}
+		return score + 2*len(prefix), true
+	}
+	if r.path != path {
+		return 0, false
+	}
+	return score + 1<<20, true
+}
+
+// loadMockRoutes reads the mock file at path, an object from keys like
+// "GET /api/todos" or "/api/todos/*" to the responses of those requests.
+func loadMockRoutes(path string) ([]*mockRoute, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var byKey map[string]*mockRoute
+	if err := json.Unmarshal(data, &byKey); err != nil {
+		return nil, fmt.Errorf("%s: %w", displayPath(path), err)
+	}
+	var routes []*mockRoute
+	for key, route := range byKey {
+		match := mockRouteKey.FindStringSubmatch(key)
+		if match == nil || route == nil {
+			return nil, fmt.Errorf("%s: invalid route %q: want a path like /api/todos, or a method and a path like GET /api/todos, with an object for its response", displayPath(path), key)
+		}
+		if route.Status == 0 {
+			route.Status = http.StatusOK
+		}
+		if route.Status < 100 || route.Status > 599 {
+			return nil, fmt.Errorf("%s: route %q: invalid status %d", displayPath(path), key, route.Status)
+		}
+		route.method, route.path = match[1], match[2]
+		routes = append(routes, route)
+	}
+	// The order of a map is random; keep the answer to a request that
+	// two routes match equally the same between runs.
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].method+" "+routes[i].path < routes[j].method+" "+routes[j].path
+	})
+	return routes, nil
+}
+
+// mockAPI is the stand-in for the server dev serves when the server is not
+// there, answering the requests of the app from a mock file, which it reads
+// again whenever the file changes.
+type mockAPI struct {
+	file     string
+	log      *logs.Stream
+	srv      *http.Server
+	stopOnce sync.Once
+	stopped  chan struct{}
+
+	mu      sync.Mutex
+	routes  []*mockRoute
+	modTime time.Time
+}
+
+// resolveMockFile returns the mock file of dev: that of --mock-api, which
+// must exist, or else defaultMockFile when the project has one, or "".
+func resolveMockFile(flag string) (string, error) {
+	if flag != "" {
+		if !fileExists(flag) {
+			return "", fmt.Errorf("--mock-api file %s not found", displayPath(flag))
+		}
+		return flag, nil
+	}
+	if fileExists(defaultMockFile) {
+		return defaultMockFile, nil
+	}
+	return "", nil
+}
+
+// startMockAPI serves the mock file on port, over HTTPS with cert when cert
+// is not nil, logging each request to log.
+func startMockAPI(file string, port int, cert *devCert, log *logs.Stream) (*mockAPI, error) {
+	routes, err := loadMockRoutes(file)
+	if err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err != nil {
+		return nil, fmt.Errorf("serving the mock API on port %d: %w", port, err)
+	}
+	a := &mockAPI{file: file, log: log, routes: routes, stopped: make(chan struct{})}
+	if info, err := os.Stat(file); err == nil {
+		a.modTime = info.ModTime()
+	}
+	a.srv = &http.Server{Handler: a}
+	go func() {
+		var err error
+		if cert != nil {
+			err = a.srv.ServeTLS(ln, cert.cert, cert.key)
+		} else {
+			err = a.srv.Serve(ln)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(log.Stderr, "stopped serving: %v\n", err)
+		}
+	}()
+	go a.watch()
+	fmt.Fprintf(log.Stdout, "Serving %d route(s) of %s on port %d in place of the server.\n", len(routes), displayPath(file), port)
+	return a, nil
+}
+
+// watch reads the mock file again once it changes, until a is stopped. A
+// file that does not parse is reported and the routes before it are kept.
+func (a *mockAPI) watch() {
+	for {
+		select {
+		case <-a.stopped:
+			return
+		case <-time.After(watchInterval):
+		}
+		info, err := os.Stat(a.file)
+		if err != nil || info.ModTime().Equal(a.modTime) {
+			continue
+		}
+		a.modTime = info.ModTime()
+		routes, err := loadMockRoutes(a.file)
+		if err != nil {
+			fmt.Fprintf(a.log.Stderr, "kept the routes served so far: %v\n", err)
+			continue
+		}
+		a.mu.Lock()
+		a.routes = routes
+		a.mu.Unlock()
+		fmt.Fprintf(a.log.Stdout, "Read %s again: %d route(s).\n", displayPath(a.file), len(routes))
+	}
+}
+
+// ServeHTTP answers r with the route matching it most closely, or a 404
+// naming the mock file.
+func (a *mockAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	began := time.Now()
+	a.mu.Lock()
+	var route *mockRoute
+	best := -1
+	for _, candidate := range a.routes {
+		if score, ok := candidate.matches(r.Method, r.URL.Path); ok && score > best {
+			route, best = candidate, score
+		}
+	}
+	a.mu.Unlock()
+
+	if route == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		msg, _ := json.Marshal(fmt.Sprintf("no route in %s for %s %s", displayPath(a.file), r.Method, r.URL.Path))
+		fmt.Fprintf(w, "{\"error\":%s}\n", msg)
+		fmt.Fprintf(a.log.Stderr, "%s %s 404, no route\n", r.Method, r.URL.RequestURI())
+		return
+	}
+	if route.DelayMs > 0 {
+		time.Sleep(time.Duration(route.DelayMs) * time.Millisecond)
+	}
+	if len(route.Body) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(route.Status)
+	w.Write(route.Body)
+	fmt.Fprintf(a.log.Stdout, "%s %s %d in %s\n", r.Method, r.URL.RequestURI(), route.Status, time.Since(began).Round(time.Millisecond))
+}
+
+// stop stops serving, closing the connections open, so the server can take
+// the port.
+func (a *mockAPI) stop() {
+	a.stopOnce.Do(func() {
+		close(a.stopped)
+		a.srv.Close()
+		fmt.Fprintln(a.log.Stdout, "Stopped, as the server is up.")
+	})
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Reavix-framework/cli/internal/logs"
+	"github.com/Reavix-framework/cli/internal/project"
+)
+
+// devReadyTimeout is how long dev waits for the dev servers to be ready
+// before it says what is missing.
+const devReadyTimeout = 30 * time.Second
+
+// devTailLines is how many of the server's last lines a server that is not
+// ready in time is shown with.
+const devTailLines = 20
+
+// ansiEscape matches the color sequences Vite wraps its output in.
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+
+// devReadiness follows the output of the dev servers: it notices the
+// "ready in" line Vite prints once it serves the app, and keeps the last
+// devTailLines lines of the server, and of its stderr alone.
+// serverSuspended is set under --wait-debugger, whose server is not waited
+// for, as it only runs once a debugger continues it, and mock to the mock
+// file when the mock API stands in for the server.
+type devReadiness struct {
+	viteReady       chan struct{}
+	once            sync.Once
+	mu              sync.Mutex
+	tail            []string
+	stderrTail      []string
+	serverSuspended bool
+	mock            string
+}
+
+// watchReadiness returns the readiness of the dev servers writing to app
+// and server.
+func watchReadiness(app, server *logs.Stream) *devReadiness {
+	r := &devReadiness{viteReady: make(chan struct{})}
+	app.OnLine(func(line string) {
+		if strings.Contains(ansiEscape.ReplaceAllString(line, ""), "ready in") {
+			r.once.Do(func() { close(r.viteReady) })
+		}
+	})
+	server.Stdout.OnLine(func(line string) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.tail = keepTail(r.tail, line)
+	})
+	server.Stderr.OnLine(func(line string) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.tail = keepTail(r.tail, line)
+		r.stderrTail = keepTail(r.stderrTail, line)
+	})
+	return r
+}
+
+// keepTail appends line to tail, keeping the last devTailLines lines.
+func keepTail(tail []string, line string) []string {
+	tail = append(tail, line)
+	if len(tail) > devTailLines {
+		tail = tail[len(tail)-devTailLines:]
+	}
+	return tail
+}
+
+// printTail prints lines, the last of what the server wrote, to stderr,
+// said to be of what.
+func printTail(what string, lines []string) {
+	if len(lines) == 0 {
+		fmt.Fprintf(os.Stderr, "The server wrote nothing to %s yet.\n", what)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "The last %d line(s) the server wrote to %s:\n", len(lines), what)
+	for _, line := range lines {
+		fmt.Fprintln(os.Stderr, "  "+line)
+	}
+}
+
+// devHealthURL is the URL on the server's port dev polls, at the health
+// path of reavix.json, until the server answers.
+func devHealthURL(m *project.Manifest, ports devPorts) string {
+	path := m.HealthPath()
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return ports.serverURL() + path
+}
+
+// healthClient returns the client dev polls the server with. Under --https
+// the server has the certificate dev made, which is only checked by the
+// browsers that were told to trust it.
+func healthClient() *http.Client {
+	return &http.Client{Timeout: time.Second, Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+}
+
+// serverAnswers reports whether the server answers an HTTP GET of url,
+// whatever its status.
+func serverAnswers(client *http.Client, url string) bool {
+	resp, err := client.Get(url)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return true
+}
+
+// portListening reports whether something accepts connections on port.
+func portListening(port int) bool {
+	conn, err := net.DialTimeout("tcp", "localhost:"+strconv.Itoa(port), 500*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// awaitDevReady waits until the dev servers of m are ready, counting from
+// started: Vite has printed its ready line and the server, when it built,
+// answers on the health path of reavix.json. It then prints one banner with
+// the URLs of the app and the API, api when set, and how long starting
+// took, and with open opens the app in the browser, or the server without a
+// frontend. That happens once per dev, as restarts of the server do not
+// come back here. When devReadyTimeout passes first, it says instead what
+// is not ready, with whether the server's port is listening and the
+// server's last lines.
+func awaitDevReady(m *project.Manifest, ports devPorts, api string, serverBuilt bool, started time.Time, r *devReadiness, open bool) {
+	// deadline is closed, rather than sent on, so both waits below see it.
+	deadline := make(chan struct{})
+	time.AfterFunc(time.Until(started.Add(devReadyTimeout)), func() { close(deadline) })
+	appReady := !m.HasFrontend()
+	if !appReady {
+		select {
+		case <-r.viteReady:
+			appReady = true
+		case <-deadline:
+		}
+	}
+
+	appURL, serverURL, healthURL := ports.appURL(), ports.serverURL(), devHealthURL(m, ports)
+	serverReady := !m.HasBackend() || !serverBuilt || r.serverSuspended
+	if !serverReady {
+		client := healthClient()
+	poll:
+		for !serverReady {
+			if serverReady = serverAnswers(client, healthURL); serverReady {
+				break
+			}
+			select {
+			case <-time.After(200 * time.Millisecond):
+			case <-deadline:
+				break poll
+			}
+		}
+	}
+	running.waitIfStopping()
+
+	if appReady && serverReady {
+		lines := []string{fmt.Sprintf("Ready in %.1fs", time.Since(started).Seconds())}
+		if m.HasFrontend() {
+			lines = append(lines, "App: "+appURL)
+		}
+		switch {
+		case api != "":
+			lines = append(lines, "API: "+api+", proxied under /api")
+		case r.mock != "":
+			lines = append(lines, "API: "+serverURL+", mocked from "+r.mock)
+		case m.HasBackend() && !serverBuilt:
+			lines = append(lines, "API: down until the server builds")
+		case r.serverSuspended:
+			lines = append(lines, "API: "+serverURL+", once the debugger continues the server")
+		case m.HasBackend():
+			lines = append(lines, "API: "+serverURL)
+		default:
+			lines = append(lines, "API: "+serverURL+", proxied under /api but not started by dev")
+		}
+		printBox(os.Stdout, lines...)
+		if open {
+			url := serverURL
+			if m.HasFrontend() {
+				url = appURL
+			}
+			if !openBrowser(url) {
+				fmt.Printf("Open %s in your browser.\n", url)
+			}
+		}
+		return
+	}
+
+	lines := []string{}
+	if !appReady {
+		lines = append(lines, fmt.Sprintf("Vite did not say it was ready within %s.", devReadyTimeout))
+	}
+	if !serverReady {
+		listening := "is not listening"
+		if portListening(ports.server) {
+			listening = "is listening, so the server is up but does not answer HTTP there"
+		}
+		lines = append(lines,
+			fmt.Sprintf("The server did not answer %s within %s.", healthURL, devReadyTimeout),
+			fmt.Sprintf("Port %d %s.", ports.server, listening))
+	}
+	printBox(os.Stderr, lines...)
+	if !serverReady {
+		r.mu.Lock()
+		tail := append([]string(nil), r.tail...)
+		r.mu.Unlock()
+		printTail("its output", tail)
+	}
+}
@@ -0,0 +1,356 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Reavix-framework/cli/internal/logs"
+	"github.com/Reavix-framework/cli/internal/project"
+)
+
+// devWatchDebounce is how long the server sources must stay unchanged
+// before dev rebuilds the server, so that saving several files at once
+// rebuilds once, unless watch.debounceMs of reavix.json says otherwise.
+const devWatchDebounce = 300 * time.Millisecond
+
+// A server that fails within crashLoopWindow of starting more than
+// crashLoopLimit times in a row is crash-looping, and dev stops starting
+// it again by itself.
+const (
+	crashLoopWindow = 2 * time.Second
+	crashLoopLimit  = 3
+)
+
+// devServer is the server binary dev runs, in a process group of its own
+// with env added to its environment, and restarts whenever it is rebuilt.
+// With autoRestart, a server that fails is started again through crashed,
+// unless it is crash-looping; ready has the lines it wrote last. When set,
+// restarted is called each time the server, once started, answers on
+// healthURL.
+type devServer struct {
+	log          *logs.Stream
+	env          []string
+	mock         *mockAPI
+	ready        *devReadiness
+	autoRestart  bool
+	crashed      chan struct{}
+	healthURL    string
+	restarted    func()
+	mu           sync.Mutex
+	cmd          *exec.Cmd
+	exited       chan struct{}
+	stopping     bool
+	startedAt    time.Time
+	quickCrashes int
+}
+
+// start runs the server in dir. When it exits without being stopped, the
+// exit is reported and dev keeps watching, and a server that failed is
+// started again with autoRestart.
+func (s *devServer) start(dir string) error {
+	if s.mock != nil {
+		// The mock API answered in its place until now.
+		s.mock.stop()
+		s.mock = nil
+	}
+	argv := serverArgv()
+	c, err := startCommand(streamOutput(s.log), dir, s.env, true, argv[0], argv[1:]...)
+	if err != nil {
+		return err
+	}
+	announceServer(c.Process.Pid)
+	exited := make(chan struct{})
+	s.mu.Lock()
+	s.cmd, s.exited, s.stopping, s.startedAt = c, exited, false, time.Now()
+	s.mu.Unlock()
+	if s.restarted != nil {
+		go s.awaitAnswer(exited)
+	}
+	go func() {
+		err := waitCommand(c)
+		s.log.Flush()
+		running.waitIfStopping()
+		s.mu.Lock()
+		stopping := s.stopping
+		quick := time.Since(s.startedAt) < crashLoopWindow
+		if err != nil && quick {
+			s.quickCrashes++
+		} else {
+			s.quickCrashes = 0
+		}
+		looping := s.quickCrashes > crashLoopLimit
+		s.mu.Unlock()
+		close(exited)
+		if stopping {
+			return
+		}
+		status := "with exit status 0"
+		if err != nil {
+			status = "with " + err.Error()
+		}
+		switch {
+		case err == nil || !s.autoRestart:
+			printBox(os.Stderr, "The server stopped "+status+", so the API is down.", "It starts again when a change to its sources builds.")
+		case looping:
+			printBox(os.Stderr,
+				"The server is crash-looping: it stopped "+status+fmt.Sprintf(" within %s of starting %d times in a row.", crashLoopWindow, crashLoopLimit+1),
+				"dev no longer starts it again by itself; press r or change its sources to start it.")
+			s.ready.mu.Lock()
+			tail := append([]string(nil), s.ready.stderrTail...)
+			s.ready.mu.Unlock()
+			printTail("stderr", tail)
+			notifyDesktop("Reavix: the server is crash-looping", "It stopped "+status+" right after starting, "+fmt.Sprint(crashLoopLimit+1)+" times in a row.")
+		default:
+			printBox(os.Stderr, "The server stopped "+status+", so dev starts it again.")
+			select {
+			case s.crashed <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return nil
+}
+
+// awaitAnswer calls restarted once the server answers on healthURL, unless
+// it exits first or does not answer within devReadyTimeout.
+func (s *devServer) awaitAnswer(exited <-chan struct{}) {
+	client := healthClient()
+	deadline := time.After(devReadyTimeout)
+	for !serverAnswers(client, s.healthURL) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-exited:
+			return
+		case <-deadline:
+			return
+		}
+	}
+	s.restarted()
+}
+
+// down reports whether the server is not running.
+func (s *devServer) down() bool {
+	s.mu.Lock()
+	exited := s.exited
+	s.mu.Unlock()
+	if exited == nil {
+		return true
+	}
+	select {
+	case <-exited:
+		return true
+	default:
+		return false
+	}
+}
+
+// resetCrashes forgets the failures of the server so far, as a change or
+// r starts it again on purpose.
+func (s *devServer) resetCrashes() {
+	s.mu.Lock()
+	s.quickCrashes = 0
+	s.mu.Unlock()
+}
+
+// stop asks the running server, if any, to stop with SIGTERM and kills it
+// when it has not within devStopTimeout, with the rest of its process group
+// either way.
+func (s *devServer) stop() {
+	s.mu.Lock()
+	c, exited := s.cmd, s.exited
+	s.cmd, s.stopping = nil, true
+	s.mu.Unlock()
+	if c == nil {
+		return
+	}
+	signalProcess(c.Process, true, syscall.SIGTERM)
+	select {
+	case <-exited:
+	case <-time.After(devStopTimeout):
+		fmt.Fprintf(os.Stderr, "Warning: the server did not stop within %s, so it was killed.\n", devStopTimeout)
+	}
+	signalProcess(c.Process, true, syscall.SIGKILL)
+	<-exited
+}
+
+// cmakeGlob matches the file(GLOB) and file(GLOB_RECURSE) calls of CMake,
+// whose lists CMake only takes at configure.
+var cmakeGlob = regexp.MustCompile(`(?i)\bfile\s*\(\s*GLOB`)
+
+// globsSources reports whether the CMake configs directly in backend list
+// files with file(GLOB), so that adding or removing a source file needs the
+// configure step, which plain builds skip, to take it in.
+func globsSources(backend string) bool {
+	entries, err := os.ReadDir(backend)
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if !e.Type().IsRegular() || !isCMakeConfig(e.Name()) || !strings.HasSuffix(e.Name(), ".txt") && !strings.HasSuffix(e.Name(), ".cmake") {
+			continue
+		}
+		if content, err := os.ReadFile(filepath.Join(backend, e.Name())); err == nil && cmakeGlob.Match(content) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanServer snapshots the server sources dev watches: the files in src/
+// and include/ and the CMake configs of the server of m, but those settings
+// ignores.
+func scanServer(m *project.Manifest, settings *watchSettings, prev watchSnapshot) (watchSnapshot, error) {
+	snap := watchSnapshot{}
+	if err := snap.addConfigs(m.Backend, isCMakeConfig, settings.ignored, prev); err != nil {
+		return nil, err
+	}
+	for _, dir := range []string{filepath.Join(m.Backend, "src"), filepath.Join(m.Backend, "include")} {
+		if err := snap.addTree(dir, settings.ignored, prev); err != nil {
+			return nil, err
+		}
+	}
+	return snap, nil
+}
+
+// notePending adds the files changed from old to cur to pending, mapped to
+// whether one was added or removed rather than only edited since it was
+// last built, and reports whether any changed.
+func notePending(pending map[string]bool, old, cur watchSnapshot) bool {
+	changed := changedFiles(old, cur)
+	for _, path := range changed {
+		_, was := old[path]
+		_, is := cur[path]
+		pending[path] = pending[path] || was != is
+	}
+	return len(changed) > 0
+}
+
+// pendingPaths lists the paths of pending in order.
+func pendingPaths(pending map[string]bool) []string {
+	var paths []string
+	for path := range pending {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// rebuildPlan is how dev rebuilds the server in backend for pending, as
+// notePending fills it: reconfigured for a CMake config changed, or for a
+// source added or removed while the configs glob for the sources, else
+// built only. reason says what triggered it; with nothing pending, a
+// restart was requested.
+func rebuildPlan(pending map[string]bool, backend string) (reconfigure bool, reason string) {
+	paths := pendingPaths(pending)
+	if len(paths) == 0 {
+		return false, "restart requested"
+	}
+	reason = describeChanges(paths) + " changed"
+	filesAdded := false
+	for path, addedOrRemoved := range pending {
+		reconfigure = reconfigure || filepath.Dir(path) == filepath.Clean(backend)
+		filesAdded = filesAdded || addedOrRemoved
+	}
+	if !reconfigure && filesAdded && globsSources(backend) {
+		reconfigure = true
+		reason += "; reconfigured, as the CMake configs glob for the sources"
+	}
+	return reconfigure, reason
+}
+
+// watchServer runs server from the build built, if it built, then stops,
+// rebuilds with build and restarts it whenever its sources change or
+// restart receives, right away then, until dev is stopped. A change to a
+// CMake config, or a source added or removed while the configs glob for
+// the sources, reconfigures the build; a header or source edited only
+// builds, CMake's dependency scan recompiling what includes it. A build
+// that fails shows its output as it did before and leaves the server
+// stopped until the next change. A server that fails is started again from
+// the same build, unless it is crash-looping.
+func watchServer(m *project.Manifest, server *devServer, built *serverBuild, build func(reconfigure bool) (*serverBuild, error), restart <-chan struct{}) {
+	run := func(reconfigure bool) bool {
+		var err error
+		built, err = build(reconfigure)
+		running.waitIfStopping()
+		if err == nil {
+			err = server.start(built.dir)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return false
+		}
+		return true
+	}
+
+	settings := newWatchSettings(m, devWatchDebounce)
+	snap, err := scanServer(m, settings, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not scan the server sources for changes: %v\n", err)
+	}
+	if built != nil {
+		if err := server.start(built.dir); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	}
+	dir := filepath.ToSlash(m.Backend)
+	fmt.Printf("Watching %s/src, %s/include and the CMake configs; the server restarts when they change.\n", dir, dir)
+
+	pending := map[string]bool{}
+	var lastChange time.Time
+	for {
+		requested := false
+		select {
+		case <-time.After(watchInterval):
+		case <-restart:
+			requested = true
+		case <-server.crashed:
+			if built != nil && server.down() {
+				if err := server.start(built.dir); err != nil {
+					fmt.Printf("Error: %v\n", err)
+				}
+			}
+			continue
+		}
+		if settings.refresh() {
+			// Files ignored until now show up as added, and files ignored
+			// from now on as removed, which is no reason to rebuild.
+			snap, _ = scanServer(m, settings, snap)
+		}
+		cur, err := scanServer(m, settings, snap)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not scan the server sources for changes: %v\n", err)
+			continue
+		}
+		if notePending(pending, snap, cur) {
+			lastChange = time.Now()
+		}
+		snap = cur
+		if !requested && (len(pending) == 0 || time.Since(lastChange) < settings.debounce) {
+			continue
+		}
+
+		logTriggers(pendingPaths(pending))
+		reconfigure, reason := rebuildPlan(pending, m.Backend)
+		pending = map[string]bool{}
+		started := time.Now()
+		server.stop()
+		server.resetCrashes()
+		ok := run(reconfigure)
+		stamp := started.Format("15:04:05")
+		took := time.Since(started).Seconds()
+		if ok {
+			fmt.Printf("[%s] Rebuilt and restarted the server in %.1fs (%s)\n", stamp, took, reason)
+		} else {
+			fmt.Printf("[%s] Server build failed after %.1fs (%s); waiting for the next change\n", stamp, took, reason)
+			notifyDesktop("Reavix: the server build failed", "After "+reason+"; dev waits for the next change.")
+		}
+	}
+}
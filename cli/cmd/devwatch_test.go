@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRebuildPlan(t *testing.T) {
+	root, m := scaffoldProject(t)
+	cmakeLists := filepath.Join(root, m.Backend, "CMakeLists.txt")
+	content, err := os.ReadFile(cmakeLists)
+	if err != nil {
+		t.Fatal(err)
+	}
+	globbing := string(content) + "file(GLOB EXTRA_SOURCES src/*.c)\n"
+	writeFiles(t, root, map[string]string{
+		"server/CMakeLists.txt":  globbing,
+		"server/src/extra.c":     "int extra(void) { return 1; }\n",
+		"server/include/extra.h": "int extra(void);\n",
+	})
+	settings := newWatchSettings(m, time.Second)
+	snap, err := scanServer(m, settings, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		change      func()
+		reconfigure bool
+		reason      string
+	}{
+		{"CMake config edited", func() {
+			writeFiles(t, root, map[string]string{"server/CMakeLists.txt": globbing + "# edited\n"})
+		}, true, "server/CMakeLists.txt changed"},
+		{"source added under a glob", func() {
+			writeFiles(t, root, map[string]string{"server/src/more.c": "int more(void) { return 2; }\n"})
+		}, true, "server/src/more.c changed; reconfigured, as the CMake configs glob for the sources"},
+		{"source removed under a glob", func() {
+			if err := os.Remove(filepath.Join(root, "server", "src", "extra.c")); err != nil {
+				t.Fatal(err)
+			}
+		}, true, "server/src/extra.c changed; reconfigured, as the CMake configs glob for the sources"},
+		{"header edited", func() {
+			writeFiles(t, root, map[string]string{"server/include/extra.h": "int extra(void); int more(void);\n"})
+		}, false, "server/include/extra.h changed"},
+		{"source edited", func() {
+			writeFiles(t, root, map[string]string{"server/src/more.c": "int more(void) { return 3; }\n"})
+		}, false, "server/src/more.c changed"},
+		{"glob dropped", func() {
+			writeFiles(t, root, map[string]string{"server/CMakeLists.txt": string(content)})
+		}, true, "server/CMakeLists.txt changed"},
+		{"source added without a glob", func() {
+			writeFiles(t, root, map[string]string{"server/src/last.c": "int last(void) { return 4; }\n"})
+		}, false, "server/src/last.c changed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.change()
+			cur, err := scanServer(m, settings, snap)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pending := map[string]bool{}
+			if !notePending(pending, snap, cur) {
+				t.Fatal("the change went unseen")
+			}
+			snap = cur
+			reconfigure, reason := rebuildPlan(pending, m.Backend)
+			if reconfigure != tt.reconfigure || filepath.ToSlash(reason) != tt.reason {
+				t.Errorf("rebuildPlan = %v, %q; want %v, %q", reconfigure, reason, tt.reconfigure, tt.reason)
+			}
+		})
+	}
+}
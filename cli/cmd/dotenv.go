@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// buildMode matches the --mode values build accepts, which also name the
+// server/.env.<mode> file.
+var buildMode = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// envKey matches the variable names an env file may set.
+var envKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// envVar is a variable of the project's env files and source the file it
+// came from, or "environment" when the environment reavix runs in sets it
+// already.
+type envVar struct {
+	key, value, source string
+}
+
+// projectEnvFiles are the env files at the project root dev, run and env
+// read for mode, lowest precedence first, in the order Vite reads those of
+// app/.
+func projectEnvFiles(mode string) []string {
+	return []string{".env", ".env.local", ".env." + mode, ".env." + mode + ".local"}
+}
+
+// resolveProjectEnv reads the env files at the project root for mode, the
+// ones of them that exist, or files, the --env-file flags, instead when
+// given. A file overrides the ones before it, and the environment reavix
+// runs in overrides them all, as with Vite and dotenv, so that
+// `KEY=value reavix dev` still wins.
+func resolveProjectEnv(mode string, files []string) ([]envVar, error) {
+	explicit := len(files) > 0
+	if !explicit {
+		files = projectEnvFiles(mode)
+	}
+	var vars []envVar
+	index := map[string]int{}
+	for _, file := range files {
+		env, err := loadEnvFile(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			if explicit {
+				return nil, fmt.Errorf("--env-file %s does not exist", displayPath(file))
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range env {
+			key, value, _ := strings.Cut(entry, "=")
+			v := envVar{key: key, value: value, source: displayPath(file)}
+			if i, ok := index[key]; ok {
+				vars[i] = v
+				continue
+			}
+			index[key] = len(vars)
+			vars = append(vars, v)
+		}
+	}
+	for i, v := range vars {
+		if value, ok := os.LookupEnv(v.key); ok {
+			vars[i] = envVar{key: v.key, value: value, source: "environment"}
+		}
+	}
+	return vars, nil
+}
+
+// childEnv returns the variables of vars the environment does not set
+// already as KEY=VALUE entries for the environment of a command, which
+// keeps them from reavix's own.
+func childEnv(vars []envVar) []string {
+	var env []string
+	for _, v := range vars {
+		if v.source != "environment" {
+			env = append(env, v.key+"="+v.value)
+		}
+	}
+	return env
+}
+
+// mergeEnv sets the variables of added in vars, in the place of those of
+// the same key, and returns vars. They override the env files and the
+// environment alike.
+func mergeEnv(vars []envVar, added ...envVar) []envVar {
+next:
+	for _, v := range added {
+		for i := range vars {
+			if vars[i].key == v.key {
+				vars[i] = v
+				continue next
+			}
+		}
+		vars = append(vars, v)
+	}
+	return vars
+}
+
+// parseEnvFlags parses values, those of --env flags, as KEY=VALUE; a key
+// given twice keeps its last value.
+func parseEnvFlags(values []string) ([]envVar, error) {
+	var vars []envVar
+	for _, value := range values {
+		key, v, ok := strings.Cut(value, "=")
+		if !ok || !envKey.MatchString(key) {
+			return nil, fmt.Errorf("invalid --env %q: want KEY=VALUE", value)
+		}
+		vars = mergeEnv(vars, envVar{key: key, value: v, source: "--env"})
+	}
+	return vars, nil
+}
+
+// loadEnvFile reads the env file at path as KEY=VALUE entries for a command
+// environment; see parseEnv.
+func loadEnvFile(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	env, err := parseEnv(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", displayPath(path), err)
+	}
+	return env, nil
+}
+
+// parseEnv parses the contents of an env file, in the dialect Vite and
+// dotenv read: one KEY=VALUE per line, optionally after "export ", with
+// blank lines and lines starting with # ignored. An unquoted value ends at
+// a # after whitespace and is trimmed. A value in single quotes is taken
+// as it is; one in double quotes understands \n, \r, \t, \\, \" and \$.
+// Quoted values may span lines. Variables are not expanded. A key set
+// twice keeps its last value, at the place of its first.
+func parseEnv(content string) ([]string, error) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	var keys []string
+	values := map[string]string{}
+	for pos, line := 0, 1; pos < len(content); {
+		end := lineEnd(content, pos)
+		entry := strings.TrimSpace(content[pos:end])
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			pos, line = end+1, line+1
+			continue
+		}
+		key, rest, ok := strings.Cut(strings.TrimPrefix(entry, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", line)
+		}
+		if !envKey.MatchString(key) {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", line, key)
+		}
+		unquoted := rest
+		rest = strings.TrimLeft(rest, " \t")
+
+		var value string
+		if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+			// A quoted value may run on into the following lines, so it is
+			// decoded from the contents rather than from its line. Keys
+			// have no quotes, so the first one on the line opens it.
+			at := pos + strings.IndexAny(content[pos:end], `"'`)
+			decoded, n, err := quotedValue(content[at:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			value = decoded
+			end = lineEnd(content, at+n)
+			if trailing := strings.TrimSpace(content[at+n : end]); trailing != "" && !strings.HasPrefix(trailing, "#") {
+				return nil, fmt.Errorf("line %d: unexpected %q after the closing quote", line, trailing)
+			}
+		} else {
+			// Before trimming, so a value of only a comment is empty.
+			value = unquoted
+			for _, comment := range []string{" #", "\t#"} {
+				if i := strings.Index(value, comment); i >= 0 {
+					value = value[:i]
+				}
+			}
+			value = strings.TrimSpace(value)
+		}
+		if _, seen := values[key]; !seen {
+			keys = append(keys, key)
+		}
+		values[key] = value
+		line += strings.Count(content[pos:end], "\n") + 1
+		pos = end + 1
+	}
+
+	env := make([]string, 0, len(keys))
+	for _, key := range keys {
+		env = append(env, key+"="+values[key])
+	}
+	return env, nil
+}
+
+// lineEnd returns the index of the newline ending the line at pos in s, or
+// the length of s for its last line.
+func lineEnd(s string, pos int) int {
+	if i := strings.IndexByte(s[pos:], '\n'); i >= 0 {
+		return pos + i
+	}
+	return len(s)
+}
+
+// quotedValue decodes the quoted value s starts with and returns it with
+// the length of s it took up, closing quote included.
+func quotedValue(s string) (string, int, error) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\\' && quote == '"' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '\\', '"', '$':
+				b.WriteByte(s[i])
+			default:
+				// Unknown escapes are kept, as dotenv does.
+				b.WriteByte('\\')
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("missing closing %c", quote)
+}
+
+// printLoadedEnv says how many variables dev or run pass on from which env
+// files, when any.
+func printLoadedEnv(vars []envVar) {
+	var sources []string
+	n := 0
+	for _, v := range vars {
+		if v.source == "environment" {
+			continue
+		}
+		n++
+		if !containsString(sources, v.source) {
+			sources = append(sources, v.source)
+		}
+	}
+	if n > 0 {
+		sort.Strings(sources)
+		fmt.Printf("Loaded %d variable(s) from %s.\n", n, strings.Join(sources, ", "))
+	}
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		err     string
+	}{
+		{"plain", "A=1\nB = two \n", []string{"A=1", "B=two"}, ""},
+		{"export prefix", "export A=1\nexport  B=2\n", []string{"A=1", "B=2"}, ""},
+		{"comments and blank lines", "# a comment\n\n  # indented\nA=1\n", []string{"A=1"}, ""},
+		{"inline comment", "A=1 # one\nB=2\t# two\nC=x#y\n", []string{"A=1", "B=2", "C=x#y"}, ""},
+		{"empty values", "A=\nB=''\nC=\"\"\nD= # nothing\n", []string{"A=", "B=", "C=", "D="}, ""},
+		{"single quotes", `A='a \n "b" # c $d'`, []string{`A=a \n "b" # c $d`}, ""},
+		{"double quotes", `A="a\nb \"c\" \\ \$d \q"`, []string{"A=a\nb \"c\" \\ $d \\q"}, ""},
+		{"comment after quotes", `A="a # b" # c`, []string{"A=a # b"}, ""},
+		{"multiline", "A=\"one\ntwo\"\nB=3\n", []string{"A=one\ntwo", "B=3"}, ""},
+		{"crlf", "A=1\r\nB='2'\r\n", []string{"A=1", "B=2"}, ""},
+		{"set twice", "A=1\nB=2\nA=3\n", []string{"A=3", "B=2"}, ""},
+		{"no equals", "A=1\n\nB\n", nil, "line 3: expected KEY=VALUE"},
+		{"bad name", "A=1\n1A=2\n", nil, `line 2: invalid variable name "1A"`},
+		{"unclosed quote", "A=1\nB=\"open\nC=2\n", nil, `line 2: missing closing "`},
+		{"after closing quote", "A='x' y\n", nil, `line 1: unexpected "y" after the closing quote`},
+		{"line after multiline", "A=\"one\ntwo\"\nB\n", nil, "line 3: expected KEY=VALUE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEnv(tt.content)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("parseEnv = %q, %v; want error %q", got, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseEnv: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseEnv = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// embedDir, in the server directory, holds the frontend that build
+// --embed-assets generates as C sources.
+const embedDir = "generated"
+
+// embedStampFile, in embedDir, holds the hash of the dist the sources there
+// were generated from.
+const embedStampFile = ".reavix-assets"
+
+// embedChunkSize caps each generated array. Some compilers slow to a crawl
+// or give up on initializers of many megabytes, so files are split up.
+const embedChunkSize = 64 * 1024
+
+// embedDefine is the -D build passes CMake with --embed-assets. Changing it
+// reconfigures the build directory, so the CMakeLists.txt of the templates
+// picks the generated sources up or drops them again.
+const embedDefine = "-DREAVIX_EMBED_ASSETS=ON"
+
+// contentTypes maps the extensions of frontend files to the Content-Type
+// they are served with; other files are application/octet-stream.
+var contentTypes = map[string]string{
+	".html":        "text/html; charset=utf-8",
+	".htm":         "text/html; charset=utf-8",
+	".css":         "text/css; charset=utf-8",
+	".js":          "text/javascript; charset=utf-8",
+	".mjs":         "text/javascript; charset=utf-8",
+	".json":        "application/json",
+	".map":         "application/json",
+	".webmanifest": "application/manifest+json",
+	".txt":         "text/plain; charset=utf-8",
+	".xml":         "application/xml",
+	".svg":         "image/svg+xml",
+	".png":         "image/png",
+	".jpg":         "image/jpeg",
+	".jpeg":        "image/jpeg",
+	".gif":         "image/gif",
+	".webp":        "image/webp",
+	".avif":        "image/avif",
+	".ico":         "image/x-icon",
+	".woff":        "font/woff",
+	".woff2":       "font/woff2",
+	".ttf":         "font/ttf",
+	".otf":         "font/otf",
+	".wasm":        "application/wasm",
+}
+
+// embeddedSources are the files generateAssets writes into embedDir for a
+// server in lang, "c" or "cpp"; a C++ project compiles no C.
+func embeddedSources(lang string) (source, header string) {
+	if lang == "cpp" {
+		return "assets.cpp", "assets.h"
+	}
+	return "assets.c", "assets.h"
+}
+
+// generateAssets writes the files in dist into the embedDir of backend as
+// C sources: one set of byte arrays per file and a table assets_get looks
+// request paths up in. Nothing is written when dist hashes the same as at
+// the last generation, so the compile after it has nothing to do. It
+// reports whether the sources were written.
+func generateAssets(dist, backend, lang string) (bool, error) {
+	hash, err := hashTree(dist, "", skipPaths(nil))
+	if err != nil {
+		return false, err
+	}
+	dir := filepath.Join(backend, embedDir)
+	source, header := embeddedSources(lang)
+	stamp := filepath.Join(dir, embedStampFile)
+	if last, err := os.ReadFile(stamp); err == nil && string(last) == hash+"\n" &&
+		fileExists(filepath.Join(dir, source)) && fileExists(filepath.Join(dir, header)) {
+		return false, nil
+	}
+
+	// files maps the request path of every file to serve to the file.
+	files := map[string]string{}
+	err = filepath.WalkDir(dist, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		// The server sends files as they are, so the precompressed
+		// siblings would only make the binary bigger.
+		if ext := filepath.Ext(p); ext == ".gz" || ext == ".br" {
+			return nil
+		}
+		rel, err := filepath.Rel(dist, p)
+		if err != nil {
+			return err
+		}
+		files["/"+filepath.ToSlash(rel)] = p
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	if len(files) == 0 {
+		return false, fmt.Errorf("%s is empty; there is nothing to embed", displayPath(dist))
+	}
+	// assets_get binary searches the table, which is in byte order.
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var src bytes.Buffer
+	src.WriteString("/* Generated by reavix build --embed-assets from the frontend build; do not edit. */\n\n")
+	src.WriteString("#include <string.h>\n\n#include \"assets.h\"\n")
+	var table bytes.Buffer
+	for i, path := range paths {
+		content, err := os.ReadFile(files[path])
+		if err != nil {
+			return false, err
+		}
+		var chunks, sizes []string
+		for off, n := 0, 0; off < len(content) || (off == 0 && n == 0); off += embedChunkSize {
+			end := off + embedChunkSize
+			if end > len(content) {
+				end = len(content)
+			}
+			name := fmt.Sprintf("asset_%d_%d", i, n)
+			writeByteArray(&src, name, content[off:end])
+			chunks = append(chunks, name)
+			sizes = append(sizes, fmt.Sprint(end-off))
+			n++
+		}
+		fmt.Fprintf(&src, "static const unsigned char* const asset_%d_chunks[] = {%s};\n", i, strings.Join(chunks, ", "))
+		fmt.Fprintf(&src, "static const size_t asset_%d_sizes[] = {%s};\n", i, strings.Join(sizes, ", "))
+
+		contentType := contentTypes[strings.ToLower(filepath.Ext(path))]
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		fmt.Fprintf(&table, "    {%s, \"%s\", %d, %d, asset_%d_chunks, asset_%d_sizes},\n",
+			cString(path), contentType, len(content), len(chunks), i, i)
+	}
+	src.WriteString("\n")
+	fmt.Fprintf(&src, "static const reavix_asset_t assets[] = {\n%s};\n", table.String())
+	src.WriteString(assetsLookup(len(paths)))
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return false, err
+	}
+	// A generation that fails halfway must not be taken for a finished one.
+	if err := os.Remove(stamp); err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, header), []byte(assetsHeader), 0644); err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, source), src.Bytes(), 0644); err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(stamp, []byte(hash+"\n"), 0644)
+}
+
+// writeByteArray writes content as a static array called name, twenty
+// bytes to a line. An empty file still gets an array of one byte, as C has
+// no empty arrays; its size says there is nothing to send.
+func writeByteArray(b *bytes.Buffer, name string, content []byte) {
+	fmt.Fprintf(b, "\nstatic const unsigned char %s[] = {", name)
+	if len(content) == 0 {
+		b.WriteString("0")
+	}
+	line := make([]byte, 0, 100)
+	for i, c := range content {
+		if i%20 == 0 {
+			b.Write(line)
+			line = append(line[:0], "\n    "...)
+		}
+		line = strconv.AppendUint(line, uint64(c), 10)
+		line = append(line, ',')
+	}
+	b.Write(line)
+	b.WriteString("\n};\n")
+}
+
+// cString quotes s as a C string literal, escaping everything but
+// printable ASCII in octal so no byte runs into the next.
+func cString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c >= 0x7f || c == '?':
+			// '?' too, so no trigraph comes out.
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// assetsHeader declares what the generated source defines.
+const assetsHeader = `/* Generated by reavix build --embed-assets; do not edit. */
+#ifndef REAVIX_ASSETS_H
+#define REAVIX_ASSETS_H
+
+#include <stddef.h>
+
+/* An embedded file of the frontend. Its contents are split into
+ * chunk_count chunks, which keeps every array small enough for any
+ * compiler; send them in order. */
+typedef struct {
+    const char* path;
+    const char* content_type;
+    size_t size;
+    size_t chunk_count;
+    const unsigned char* const* chunks;
+    const size_t* chunk_sizes;
+} reavix_asset_t;
+
+/* assets_get returns the embedded file for a request path, ignoring its
+ * query string, or NULL. "/" and other paths without a file extension get
+ * index.html, so client-side routes load the app. */
+const reavix_asset_t* assets_get(const char* path);
+
+#endif
+`
+
+// assetsLookup is the assets_get of a table of count assets.
+func assetsLookup(count int) string {
+	return fmt.Sprintf(`
+static const reavix_asset_t* find_asset(const char* path, size_t len) {
+    size_t lo = 0, hi = %d;
+    while (lo < hi) {
+        size_t mid = lo + (hi - lo) / 2;
+        int cmp = strncmp(assets[mid].path, path, len);
+        if (cmp == 0 && assets[mid].path[len] != '\0') {
+            cmp = 1;
+        }
+        if (cmp == 0) {
+            return &assets[mid];
+        }
+        if (cmp < 0) {
+            lo = mid + 1;
+        } else {
+            hi = mid;
+        }
+    }
+    return NULL;
+}
+
+const reavix_asset_t* assets_get(const char* path) {
+    size_t len = strcspn(path, "?#");
+    const reavix_asset_t* asset = find_asset(path, len);
+    if (asset) {
+        return asset;
+    }
+    const char* base = path + len;
+    while (base > path && base[-1] != '/') {
+        base--;
+    }
+    if (memchr(base, '.', (size_t)(path + len - base)) == NULL) {
+        return find_asset("/index.html", strlen("/index.html"));
+    }
+    return NULL;
+}
+`, count)
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var envOpts struct {
+	mode     string
+	envFiles []string
+}
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Show the variables dev and run pass on",
+	Long: "Print the variables `reavix dev` and `reavix run` add to the environment of the server and\n" +
+		"the Vite dev server, with the file each comes from. They are read from .env, .env.local,\n" +
+		".env.<mode> and .env.<mode>.local at the project root, in that order, a later file overriding\n" +
+		"an earlier one; a variable the environment sets already keeps its value. --env-file reads the\n" +
+		"files given instead. The mode is development, or --mode; run uses production.\n\n" +
+		"Values of variables named like secrets, such as API_KEY, DB_PASSWORD or AUTH_TOKEN, and the\n" +
+		"passwords in URLs are masked.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		files, err := absPathFlags(envOpts.envFiles)
+		if err != nil {
+			return err
+		}
+		if !buildMode.MatchString(envOpts.mode) {
+			return fmt.Errorf("invalid --mode %q: use letters, digits, '.', '_' and '-'", envOpts.mode)
+		}
+		cmd.SilenceUsage = true
+		if _, err := requireProject(); err != nil {
+			return err
+		}
+		vars, err := resolveProjectEnv(envOpts.mode, files)
+		if err != nil {
+			return err
+		}
+		if len(vars) == 0 {
+			if len(files) > 0 {
+				fmt.Println("No variables: the --env-file files set none.")
+			} else {
+				fmt.Printf("No variables: %s set none, or do not exist.\n", strings.Join(projectEnvFiles(envOpts.mode), ", "))
+			}
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tVALUE\tFROM")
+		for _, v := range vars {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", v.key, maskEnvValue(v.key, v.value), v.source)
+		}
+		return w.Flush()
+	},
+}
+
+// secretWords are the words of a variable name, split at underscores, that
+// make env mask its value.
+var secretWords = map[string]bool{
+	"SECRET": true, "SECRETS": true, "TOKEN": true, "PASSWORD": true, "PASSWD": true, "PASS": true, "PWD": true,
+	"KEY": true, "APIKEY": true, "PRIVATE": true, "CREDENTIAL": true, "CREDENTIALS": true, "AUTH": true, "SALT": true,
+}
+
+// maskEnvValue hides value when key names a secret, and otherwise the
+// password of a URL value, like the one in postgres://user:pw@host/db.
+func maskEnvValue(key, value string) string {
+	for _, word := range strings.Split(strings.ToUpper(key), "_") {
+		if secretWords[word] {
+			if value == "" {
+				return ""
+			}
+			return "****"
+		}
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		if password, ok := u.User.Password(); ok && password != "" {
+			return strings.Replace(value, ":"+password+"@", ":****@", 1)
+		}
+	}
+	return value
+}
+
+// absPathFlags is absPathFlag for each path of a repeatable flag.
+func absPathFlags(paths []string) ([]string, error) {
+	var abs []string
+	for _, path := range paths {
+		p, err := absPathFlag(path)
+		if err != nil {
+			return nil, err
+		}
+		abs = append(abs, p)
+	}
+	return abs, nil
+}
+
+func init() {
+	envCmd.Flags().StringVar(&envOpts.mode, "mode", "development", "Mode whose .env.<mode> files are read")
+	envCmd.Flags().StringArrayVar(&envOpts.envFiles, "env-file", nil, "Read this env file instead of those at the project root (repeatable, later files win)")
+	rootCmd.AddCommand(envCmd)
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// projectExamples are the --example values; none scaffolds no example.
+var projectExamples = []string{"none", "crud"}
+
+// resolveExample checks --example against the server language. The crud
+// example's /api/todos handlers are written against the C server.
+func resolveExample() error {
+	if createOpts.example == "" {
+		createOpts.example = "none"
+	}
+	if !containsString(projectExamples, createOpts.example) {
+		return fmt.Errorf("unknown example %q (supported: %s)", createOpts.example, strings.Join(projectExamples, ", "))
+	}
+	if createOpts.example != "none" && createOpts.backend != "c" {
+		return fmt.Errorf("--example %s needs --backend c", createOpts.example)
+	}
+	return nil
+}
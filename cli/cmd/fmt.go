@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var fmtOpts struct {
+	check bool
+}
+
+// serverSourceExts are the files clang-format formats in the server's src
+// and include directories.
+var serverSourceExts = map[string]bool{".c": true, ".h": true, ".cpp": true, ".hpp": true}
+
+var fmtCmd = &cobra.Command{
+	Use:   "fmt",
+	Short: "Format the server sources with clang-format and the frontend with Prettier",
+	Long: "Format the server sources with clang-format and the frontend sources with Prettier, skipping\n" +
+		"whichever tool is not installed. With --check nothing is written and the command exits\n" +
+		"non-zero if any file would change.",
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := requireProject()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		var changed []string
+		ran := false
+		if m.HasBackend() {
+			files, ok, err := formatServer(m.Backend, fmtOpts.check)
+			if err != nil {
+				fmt.Printf("clang-format failed: %v\n", err)
+				os.Exit(1)
+			}
+			changed, ran = append(changed, files...), ran || ok
+		}
+		if m.HasFrontend() {
+			files, ok, err := formatFrontend(m.Frontend, fmtOpts.check)
+			if err != nil {
+				fmt.Printf("Prettier failed: %v\n", err)
+				os.Exit(1)
+			}
+			changed, ran = append(changed, files...), ran || ok
+		}
+
+		if !ran {
+			fmt.Println("Neither clang-format nor Prettier is installed; nothing was formatted.")
+			if fmtOpts.check {
+				os.Exit(1)
+			}
+			return
+		}
+		switch {
+		case len(changed) == 0:
+			fmt.Println("All files are formatted.")
+		case fmtOpts.check:
+			fmt.Printf("%d file(s) need formatting:\n", len(changed))
+		default:
+			fmt.Printf("Formatted %d file(s):\n", len(changed))
+		}
+		for _, f := range changed {
+			fmt.Printf("  %s\n", f)
+		}
+		if fmtOpts.check && len(changed) > 0 {
+			fmt.Println("Run `reavix fmt` to fix them.")
+			os.Exit(1)
+		}
+	},
+}
+
+// formatServer runs clang-format over the C and C++ sources below
+// backend/src and backend/include and returns the files that changed, or
+// would change with check. ok is false when clang-format is not installed.
+func formatServer(backend string, check bool) (changed []string, ok bool, err error) {
+	clangFormat, err := exec.LookPath("clang-format")
+	if err != nil {
+		fmt.Printf("clang-format not found; skipping %s/\n", backend)
+		return nil, false, nil
+	}
+
+	var files []string
+	for _, dir := range []string{"src", "include"} {
+		err := filepath.WalkDir(filepath.Join(backend, dir), func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && serverSourceExts[filepath.Ext(p)] {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, true, err
+		}
+	}
+
+	// clang-format prints the formatted file, which tells which files it
+	// would touch; -i then rewrites just those.
+	for _, f := range files {
+		before, err := os.ReadFile(f)
+		if err != nil {
+			return nil, true, err
+		}
+		var stderr bytes.Buffer
+		c := exec.Command(clangFormat, f)
+		c.Stderr = &stderr
+		after, err := c.Output()
+		if err != nil {
+			return nil, true, fmt.Errorf("%s: %v %s", f, err, strings.TrimSpace(stderr.String()))
+		}
+		if !bytes.Equal(before, after) {
+			changed = append(changed, filepath.ToSlash(f))
+		}
+	}
+	if check || len(changed) == 0 {
+		return changed, true, nil
+	}
+
+	c := exec.Command(clangFormat, append([]string{"-i"}, changed...)...)
+	c.Stderr = os.Stderr
+	return changed, true, c.Run()
+}
+
+// formatFrontend runs Prettier over frontend/src, preferring the copy in the
+// frontend's node_modules, and returns the files that changed, or would
+// change with check. ok is false when Prettier is not installed.
+func formatFrontend(frontend string, check bool) (changed []string, ok bool, err error) {
+	bin := "prettier"
+	if runtime.GOOS == "windows" {
+		bin += ".cmd"
+	}
+	prettier, err := filepath.Abs(filepath.Join(frontend, "node_modules", ".bin", bin))
+	if err != nil || !fileExists(prettier) {
+		if prettier, err = exec.LookPath("prettier"); err != nil {
+			fmt.Printf("Prettier not found; skipping %s/ (`reavix create --lint` adds it)\n", frontend)
+			return nil, false, nil
+		}
+	}
+
+	args := []string{"--list-different"}
+	if !check {
+		args = append(args, "--write")
+	}
+	var stdout, stderr bytes.Buffer
+	c := exec.Command(prettier, append(args, "src")...)
+	c.Dir = frontend
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	// --list-different exits with 1 when it lists anything; 2 means Prettier
+	// itself failed, e.g. on a syntax error.
+	var exitErr *exec.ExitError
+	if err := c.Run(); err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return nil, true, fmt.Errorf("%v %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			changed = append(changed, filepath.ToSlash(filepath.Join(frontend, line)))
+		}
+	}
+	sort.Strings(changed)
+	return changed, true, nil
+}
+
+func init() {
+	fmtCmd.Flags().BoolVar(&fmtOpts.check, "check", false, "Only report the files that need formatting and exit non-zero if there are any")
+	rootCmd.AddCommand(fmtCmd)
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// frontendFramework describes a UI library create can scaffold the app
+// with. The pinned packages feed frontendDependencies and
+// frontendDevDependencies; vitePlugin is the import and call the Vite config
+// template needs and testingLibrary the package --tests renders with.
+type frontendFramework struct {
+	id      string
+	name    string
+	deps    map[string]string
+	devDeps map[string]string
+	// tsDevDeps are only added for TypeScript projects, testDevDeps only
+	// with --tests.
+	tsDevDeps   map[string]string
+	testDevDeps map[string]string
+
+	vitePluginImport string
+	vitePlugin       string
+	testingLibrary   string
+}
+
+var frontendFrameworks = []frontendFramework{
+	{
+		id:               "react",
+		name:             "React",
+		deps:             map[string]string{"react": "18.3.1", "react-dom": "18.3.1"},
+		devDeps:          map[string]string{"@vitejs/plugin-react": "4.3.3"},
+		tsDevDeps:        map[string]string{"@types/react": "18.3.12", "@types/react-dom": "18.3.1"},
+		vitePluginImport: `import react from "@vitejs/plugin-react";`,
+		vitePlugin:       "react()",
+		testDevDeps:      map[string]string{"@testing-library/react": "16.0.1", "@testing-library/dom": "10.4.0"},
+		testingLibrary:   "@testing-library/react",
+	},
+	{
+		id:               "preact",
+		name:             "Preact",
+		deps:             map[string]string{"preact": "10.24.3"},
+		devDeps:          map[string]string{"@preact/preset-vite": "2.9.1"},
+		vitePluginImport: `import preact from "@preact/preset-vite";`,
+		vitePlugin:       "preact()",
+		testDevDeps:      map[string]string{"@testing-library/preact": "3.2.4"},
+		testingLibrary:   "@testing-library/preact",
+	},
+	{
+		id:               "solid",
+		name:             "Solid",
+		deps:             map[string]string{"solid-js": "1.9.3"},
+		devDeps:          map[string]string{"vite-plugin-solid": "2.10.2"},
+		vitePluginImport: `import solid from "vite-plugin-solid";`,
+		vitePlugin:       "solid()",
+		testDevDeps:      map[string]string{"@solidjs/testing-library": "0.8.10"},
+		testingLibrary:   "@solidjs/testing-library",
+	},
+}
+
+// frontendID is the selected framework id with the default applied.
+func frontendID() string {
+	if createOpts.frontend == "" {
+		return "react"
+	}
+	return createOpts.frontend
+}
+
+// lookupFrontend resolves a --frontend value; "" selects React.
+func lookupFrontend(id string) (*frontendFramework, error) {
+	if id == "" {
+		id = "react"
+	}
+	var ids []string
+	for i := range frontendFrameworks {
+		if frontendFrameworks[i].id == id {
+			return &frontendFrameworks[i], nil
+		}
+		ids = append(ids, frontendFrameworks[i].id)
+	}
+	return nil, fmt.Errorf("unknown frontend %q (supported: %s)", id, strings.Join(ids, ", "))
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Reavix-framework/cli/internal/project"
+	utils "github.com/Reavix-framework/cli/internal/utils"
+)
+
+// packageConfigFiles are the files next to package.json that configure how
+// the package manager installs, like the registry to use.
+var packageConfigFiles = []string{".npmrc", ".yarnrc", ".yarnrc.yml", ".pnpmfile.cjs"}
+
+// frontendLockfile returns the path and the hash of the lockfile of the
+// frontend in m, as written by its package manager.
+func frontendLockfile(m *project.Manifest) (path, hash string, err error) {
+	for _, name := range lockfiles[m.PackageManager] {
+		path = filepath.Join(m.Frontend, name)
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", "", err
+		}
+		sum := sha256.Sum256(content)
+		return path, hex.EncodeToString(sum[:]), nil
+	}
+	return "", "", fmt.Errorf("%s has no %s lockfile; run `%s` with network access to write one",
+		displayPath(m.Frontend), m.PackageManager, strings.Join(installArgs(m.PackageManager), " "))
+}
+
+// checkFrozenLockfile makes sure the lockfile of the frontend in m is the
+// one the last online build or cache warm --prod recorded, so the packages
+// it lists are in the cache.
+func checkFrozenLockfile(m *project.Manifest) error {
+	path, hash, err := frontendLockfile(m)
+	if err != nil {
+		return err
+	}
+	switch m.LockfileHash {
+	case "":
+		return fmt.Errorf("--frozen needs the lockfile hash an online build records in %s; run `reavix build` or `reavix cache warm --prod` with network access first", project.FileName)
+	case hash:
+		return nil
+	}
+	return fmt.Errorf("%s changed since the last online build, so the cache may lack its packages; run `reavix cache warm --prod` with network access", displayPath(path))
+}
+
+// recordLockfileHash saves the hash of the frontend's lockfile in
+// reavix.json when it differs from the one there. A frontend without a
+// lockfile records nothing.
+func recordLockfileHash(m *project.Manifest) error {
+	_, hash, err := frontendLockfile(m)
+	if err != nil || hash == m.LockfileHash || !fileExists(project.FileName) {
+		return nil
+	}
+	m.LockfileHash = hash
+	return project.Update(".", func(saved *project.Manifest) { saved.LockfileHash = hash })
+}
+
+// frozenInstall installs the frontend dependencies of m from the local
+// cache alone, exactly as the lockfile lists them. When that fails for a
+// lack of packages, the error names them, unless --verbose shows all of the
+// install's output instead.
+func frozenInstall(out cmdOutput, m *project.Manifest) error {
+	args := frozenInstallArgs(m.PackageManager, true)
+	fmt.Fprintf(out.stdout, "Installing the frontend dependencies offline with %s...\n", strings.Join(args, " "))
+	// Without --verbose, the names of the missing packages take the place
+	// of the install's output.
+	explain := func(log []byte) error {
+		missing := missingPackages(string(log), directDependencies(m.Frontend))
+		if len(missing) == 0 {
+			return nil
+		}
+		return fmt.Errorf("packages missing from the %s cache: %s; run `reavix cache warm --prod` with network access and copy the cache over",
+			m.PackageManager, strings.Join(missing, ", "))
+	}
+	err := quietExplained(out, explain, func(out cmdOutput) error {
+		return runIn(out, m.Frontend, args[0], args[1:]...)
+	})
+	// An explained failure is not the install's exit status.
+	var exit *exec.ExitError
+	if err == nil || !errors.As(err, &exit) {
+		return err
+	}
+	return fmt.Errorf("offline install failed: %w", err)
+}
+
+// registryURL matches the package URLs of npm registries, hosts with
+// "registry" in their name, in the errors of an offline install, the
+// package name being the first path segment, or the first two for a scoped
+// package. Other registries are left to the direct dependencies.
+var registryURL = regexp.MustCompile(`https?://[^/\s"']*registry[^/\s"']*/((?:@|%40)[^/\s"']+(?:/|%2[fF])[^/\s"'?]+|[^@%/\s"'?][^/\s"'?]*)`)
+
+// missingPackages finds the packages an offline install could not get from
+// the cache in its output: those named by registry URLs and the direct
+// dependencies the output mentions.
+func missingPackages(output string, deps map[string]bool) []string {
+	found := map[string]bool{}
+	for _, m := range registryURL.FindAllStringSubmatch(output, -1) {
+		if name, err := url.PathUnescape(m[1]); err == nil {
+			found[name] = true
+		}
+	}
+	decoded := strings.NewReplacer("%2f", "/", "%2F", "/").Replace(output)
+	for _, token := range packageToken.FindAllString(decoded, -1) {
+		if deps[token] {
+			found[token] = true
+		}
+	}
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// directDependencies lists the dependencies and devDependencies of the
+// package.json in dir.
+func directDependencies(dir string) map[string]bool {
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	deps := map[string]bool{}
+	if raw, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil && json.Unmarshal(raw, &pkg) == nil {
+		for name := range pkg.Dependencies {
+			deps[name] = true
+		}
+		for name := range pkg.DevDependencies {
+			deps[name] = true
+		}
+	}
+	return deps
+}
+
+// warmProjectCache installs the frontend dependencies of m, exactly as its
+// lockfile lists them, into a throwaway copy of package.json and the
+// lockfile, which fills the package manager cache for build --frozen
+// without touching node_modules. The lockfile hash is then recorded.
+func warmProjectCache(m *project.Manifest) error {
+	lockfile, _, err := frontendLockfile(m)
+	if err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp("", "reavix-cache-warm-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range append([]string{"package.json", filepath.Base(lockfile)}, packageConfigFiles...) {
+		src := filepath.Join(m.Frontend, name)
+		if !fileExists(src) {
+			continue
+		}
+		if err := utils.CopyFile(src, filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+
+	args := append(frozenInstallArgs(m.PackageManager, false), "--ignore-scripts")
+	fmt.Printf("Running %s\n", strings.Join(args, " "))
+	if err := runIn(stdOutput, dir, args[0], args[1:]...); err != nil {
+		return err
+	}
+	return recordLockfileHash(m)
+}
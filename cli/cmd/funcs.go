@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"encoding/json"
+	"path"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// templateFuncs are available to every scaffolding template.
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		out, err := json.Marshal(v)
+		return string(out), err
+	},
+	"lower":  strings.ToLower,
+	"upper":  strings.ToUpper,
+	"camel":  camelCase,
+	"pascal": pascalCase,
+	"kebab":  func(s string) string { return strings.Join(identifierWords(s), "-") },
+	"snake":  func(s string) string { return strings.Join(identifierWords(s), "_") },
+	"year":   func() string { return strconv.Itoa(time.Now().Year()) },
+
+	"classes":    classAttr,
+	"classValue": classValue,
+}
+
+// frontendExts are rendered with [[ ]] delimiters, since JSX and JS object
+// literals legitimately contain {{ and }}.
+var frontendExts = map[string]bool{".ts": true, ".tsx": true, ".js": true, ".jsx": true}
+
+// templateDelims returns the action delimiters for the template rendered to
+// outPath.
+func templateDelims(outPath string) (left, right string) {
+	if frontendExts[path.Ext(outPath)] {
+		return "[[", "]]"
+	}
+	return "{{", "}}"
+}
+
+// identifierWords splits s into lower-case words at punctuation, spaces and
+// lower-to-upper case changes: "my-App name" gives my, app, name.
+func identifierWords(s string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	var prev rune
+	for _, r := range s {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+		prev = r
+	}
+	flush()
+	return words
+}
+
+func pascalCase(s string) string {
+	var b strings.Builder
+	for _, w := range identifierWords(s) {
+		r := []rune(w)
+		b.WriteString(strings.ToUpper(string(r[0])) + string(r[1:]))
+	}
+	return b.String()
+}
+
+func camelCase(s string) string {
+	p := []rune(pascalCase(s))
+	if len(p) == 0 {
+		return ""
+	}
+	return strings.ToLower(string(p[0])) + string(p[1:])
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/Reavix-framework/cli/templates"
+)
+
+// templateOutPaths maps each template the built-in variants and the
+// optional files of create render to the path it is rendered to, which
+// picks its delimiters.
+func templateOutPaths(t *testing.T) map[string]string {
+	t.Helper()
+	out := map[string]string{}
+	for _, name := range variantNames() {
+		variant, err := loadBuiltinVariant(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range variant.Files {
+			out[f.Template] = f.Path
+		}
+	}
+	saved := createOpts
+	defer func() { createOpts = saved }()
+	for _, lang := range []string{"ts", "js"} {
+		createOpts.lang, createOpts.license = lang, "mit"
+		createOpts.docker, createOpts.devcontainer, createOpts.lint, createOpts.tests = true, true, true, true
+		createOpts.storybook, createOpts.pwa, createOpts.vscode = true, true, true
+		for _, f := range projectFiles() {
+			out[f.template] = f.path
+		}
+	}
+	return out
+}
+
+// renderedDelims are what templates print that looks like their own
+// delimiters: the ${{ }} expressions of GitHub Actions.
+var renderedDelims = map[string]*regexp.Regexp{
+	"shared/ci-github.yml.tmpl": regexp.MustCompile(`\$\{\{[^}]*\}\}`),
+}
+
+// notScaffolded are the templates create does not render with ProjectData.
+var notScaffolded = map[string]bool{
+	"shared/systemd.service.tmpl": true,
+}
+
+func TestTemplatesRenderWithTheirDelimiters(t *testing.T) {
+	outPaths := templateOutPaths(t)
+	saved := createOpts
+	t.Cleanup(func() { createOpts = saved })
+
+	tests := []struct {
+		name string
+		set  func()
+	}{
+		{"defaults", func() {}},
+		{"js preact", func() {
+			createOpts.lang, createOpts.frontend, createOpts.css = "js", "preact", "modules"
+		}},
+		{"solid", func() { createOpts.frontend, createOpts.realtime = "solid", "ws" }},
+		{"everything", func() {
+			createOpts.db, createOpts.tls, createOpts.example = "sqlite", true, "crud"
+			createOpts.state, createOpts.router, createOpts.realtime = "zustand", "react-router", "none"
+			createOpts.docker, createOpts.lint, createOpts.tests, createOpts.storybook, createOpts.pwa = true, true, true, true, true
+			createOpts.strict, createOpts.cStd, createOpts.license = true, "gnu17", "mit"
+		}},
+		{"cpp", func() { createOpts.backend, createOpts.state, createOpts.router = "cpp", "redux", "tanstack" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			createOpts = saved
+			createOpts.noOverrides, createOpts.css = true, "tailwind"
+			tt.set()
+			data := newProjectData("my-app")
+			for _, dir := range []string{"full", "minimal", "shared"} {
+				err := fs.WalkDir(templates.FS, dir, func(name string, d fs.DirEntry, err error) error {
+					if err != nil || d.IsDir() || !strings.HasSuffix(name, ".tmpl") || notScaffolded[name] {
+						return err
+					}
+					out, ok := outPaths[name]
+					if !ok {
+						out = path.Base(strings.TrimSuffix(name, ".tmpl"))
+					}
+					content, err := renderTemplate(projectFile{path: out, template: name}, data)
+					if err != nil {
+						t.Errorf("%s (to %s): %v", name, out, err)
+						return nil
+					}
+					rendered := string(content)
+					if literal := renderedDelims[name]; literal != nil {
+						rendered = literal.ReplaceAllString(rendered, "")
+					}
+					left, right := templateDelims(out)
+					for _, delim := range []string{left, right} {
+						if i := strings.Index(rendered, delim); i >= 0 {
+							t.Errorf("%s (to %s) holds %q after rendering: %q", name, out, delim, excerpt(rendered, i))
+						}
+					}
+					return nil
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// excerpt is the line of s around offset i.
+func excerpt(s string, i int) string {
+	start := strings.LastIndexByte(s[:i], '\n') + 1
+	end := strings.IndexByte(s[i:], '\n')
+	if end < 0 {
+		return s[start:]
+	}
+	return s[start : i+end]
+}
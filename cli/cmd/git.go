@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const initialCommitMessage = "Initialize Reavix project"
+
+func gitAvailable() bool {
+	_, err := exec.LookPath("git")
+	return err == nil
+}
+
+// insideGitWorkTree reports whether dir already belongs to a git repository.
+func insideGitWorkTree(dir string) bool {
+	cmd := exec.Command("git", "rev-parse", "--is-inside-work-tree")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// gitSetupCommands lists the git invocations initGitRepo runs for a fresh
+// repository.
+func gitSetupCommands(dir string) [][]string {
+	cmds := [][]string{
+		{"add", "-A"},
+		{"commit", "-q", "-m", initialCommitMessage},
+	}
+	if !insideGitWorkTree(dir) {
+		cmds = append([][]string{{"init", "-q", "-b", "main"}}, cmds...)
+	}
+	return cmds
+}
+
+// initGitRepo initializes a repository in dir and commits the scaffold. When
+// dir is already inside a work tree the init is skipped and the user is asked
+// whether to commit the scaffold there instead.
+func initGitRepo(dir string) error {
+	if insideGitWorkTree(dir) {
+		if !isInteractive() {
+			fmt.Println("Already inside a git repository, leaving the scaffold uncommitted")
+			return nil
+		}
+		commit, err := newPrompter().askBool("Already inside a git repository. Commit the scaffold?", true)
+		if err != nil || !commit {
+			return err
+		}
+	} else if err := runGit(dir, "init", "-q", "-b", "main"); err != nil {
+		// git before 2.28 has no -b; fall back to renaming the branch.
+		if err := runGit(dir, "init", "-q"); err != nil {
+			return err
+		}
+		if err := runGit(dir, "symbolic-ref", "HEAD", "refs/heads/main"); err != nil {
+			return err
+		}
+	}
+
+	if err := runGit(dir, "add", "-A"); err != nil {
+		return err
+	}
+	return runGit(dir, "commit", "-q", "-m", initialCommitMessage)
+}
+
+func runGit(dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/Reavix-framework/cli/internal/project"
+)
+
+// hashesFile records, in the output directory, the input hashes of the
+// halves the last build there built.
+const hashesFile = ".reavix-hashes.json"
+
+// buildHashes are the contents of hashesFile. A half without a hash is
+// rebuilt. Sizes holds the size of each artifact in the output directory,
+// for the size report of the next build.
+type buildHashes struct {
+	Frontend string           `json:"frontend,omitempty"`
+	Backend  string           `json:"backend,omitempty"`
+	Sizes    map[string]int64 `json:"sizes,omitempty"`
+}
+
+// loadBuildHashes reads the hashes of the last build into outDir. A missing
+// or unreadable file means nothing is known to be up to date.
+func loadBuildHashes(outDir string) buildHashes {
+	var h buildHashes
+	if raw, err := os.ReadFile(filepath.Join(outDir, hashesFile)); err == nil {
+		if json.Unmarshal(raw, &h) != nil {
+			return buildHashes{}
+		}
+	}
+	return h
+}
+
+func saveBuildHashes(outDir string, h buildHashes) error {
+	raw, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outDir, hashesFile), append(raw, '\n'), 0644)
+}
+
+// frontendHash hashes every input of the Vite build: the sources, index.html,
+// the configs and the lockfile, which is all of the frontend but its
+// node_modules and dist/, together with stamp, the version, commit and mode
+// the app is built with.
+func frontendHash(m *project.Manifest, outDir, stamp string) (string, error) {
+	skip := append(frontendArtifacts(m), filepath.Join(m.Frontend, "node_modules"), outDir)
+	return hashTree(m.Frontend, stamp, skipPaths(skip))
+}
+
+// backendHash hashes the server sources, headers and CMake files, leaving out
+// the build directories, together with the preset and build type, so a
+// Debug binary is not reused for a Release build.
+func backendHash(m *project.Manifest, outDir, preset, buildType string) (string, error) {
+	skip := append(serverArtifacts(m), outDir)
+	return hashTree(m.Backend, preset+"\x00"+buildType, skipPaths(skip))
+}
+
+// skipPaths returns a hashTree filter that leaves out the given paths.
+func skipPaths(paths []string) func(string, fs.DirEntry) bool {
+	skipped := map[string]bool{}
+	for _, p := range paths {
+		if abs, err := filepath.Abs(p); err == nil {
+			skipped[abs] = true
+		}
+	}
+	return func(path string, _ fs.DirEntry) bool {
+		abs, err := filepath.Abs(path)
+		return err == nil && skipped[abs]
+	}
+}
+
+// hashTree hashes the paths and contents of the files below root, with salt
+// mixed in first. Files and directories for which skip returns true are
+// left out. Walking in lexical order keeps the hash stable.
+func hashTree(root, salt string, skip func(path string, d fs.DirEntry) bool) (string, error) {
+	sum := sha256.New()
+	io.WriteString(sum, salt+"\x00")
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != root && skip(path, d) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			io.WriteString(sum, "l\x00"+filepath.ToSlash(rel)+"\x00"+target+"\x00")
+		case d.Type().IsRegular():
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			// The size delimits the contents from the next entry.
+			fmt.Fprintf(sum, "f\x00%s\x00%d\x00", filepath.ToSlash(rel), info.Size())
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(sum, f)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Reavix-framework/cli/internal/project"
+)
+
+func TestBuildHashesInvalidateOneHalf(t *testing.T) {
+	root := t.TempDir()
+	chdir(t, root)
+	m := project.Default()
+	writeFiles(t, root, map[string]string{
+		"app/src/App.tsx":             "export default function App() {}\n",
+		"app/package.json":            "{}\n",
+		"app/node_modules/x/index.js": "1\n",
+		"server/src/main.c":           "int main(void) { return 0; }\n",
+		"server/CMakeLists.txt":       "project(app C)\n",
+		"server/build/CMakeCache.txt": "cache\n",
+	})
+	outDir := m.OutDir
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	hashes := func() (string, string) {
+		t.Helper()
+		app, err := frontendHash(&m, outDir, "1.0.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		server, err := backendHash(&m, outDir, "dev", "Release")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return app, server
+	}
+	app, server := hashes()
+	if err := saveBuildHashes(outDir, buildHashes{Frontend: app, Backend: server}); err != nil {
+		t.Fatal(err)
+	}
+	saved := loadBuildHashes(outDir)
+
+	// What the builds leave behind changes neither half.
+	writeFiles(t, root, map[string]string{
+		"app/node_modules/x/index.js": "2\n",
+		"app/dist/index.html":         "<html></html>\n",
+		"server/build/CMakeCache.txt": "cache 2\n",
+	})
+	if app, server := hashes(); app != saved.Frontend || server != saved.Backend {
+		t.Fatal("build artifacts made a half stale")
+	}
+
+	writeFiles(t, root, map[string]string{"app/src/App.tsx": "export default function App() { return null }\n"})
+	app, server = hashes()
+	if app == saved.Frontend {
+		t.Error("changing app/src/App.tsx left the frontend up to date")
+	}
+	if server != saved.Backend {
+		t.Error("changing app/src/App.tsx made the server stale")
+	}
+	saved.Frontend = app
+
+	writeFiles(t, root, map[string]string{"server/src/main.c": "int main(void) { return 1; }\n"})
+	app, server = hashes()
+	if server == saved.Backend {
+		t.Error("changing server/src/main.c left the server up to date")
+	}
+	if app != saved.Frontend {
+		t.Error("changing server/src/main.c made the frontend stale")
+	}
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// commentStyle is how a license header is commented out in one kind of
+// file: open and close wrap the header, prefix starts each of its lines.
+type commentStyle struct {
+	open, prefix, close string
+}
+
+var (
+	slashComment = commentStyle{prefix: "// "}
+	hashComment  = commentStyle{prefix: "# "}
+	blockComment = commentStyle{open: "/*\n", prefix: " * ", close: " */\n"}
+)
+
+// headerComments maps the extensions of the files that get an SPDX header,
+// and the names of such files without one, to their comment syntax. Data
+// files like JSON and Markdown are left alone.
+var headerComments = map[string]commentStyle{
+	".c":             slashComment,
+	".h":             slashComment,
+	".cc":            slashComment,
+	".cpp":           slashComment,
+	".hpp":           slashComment,
+	".ts":            slashComment,
+	".tsx":           slashComment,
+	".js":            slashComment,
+	".jsx":           slashComment,
+	".mjs":           slashComment,
+	".cjs":           slashComment,
+	".css":           blockComment,
+	".sh":            hashComment,
+	".yml":           hashComment,
+	".yaml":          hashComment,
+	".cmake":         hashComment,
+	"CMakeLists.txt": hashComment,
+	"Dockerfile":     hashComment,
+}
+
+// addLicenseHeader prepends the SPDX license header to a rendered file when
+// data asks for headers and the file's type has a comment syntax. Shebangs
+// and Dockerfile parser directives stay on the first line, and files that
+// already carry an SPDX identifier, as remote templates may, are unchanged.
+func addLicenseHeader(filePath string, content []byte, data ProjectData) []byte {
+	if !data.LicenseHeaders || data.License == "" {
+		return content
+	}
+	base := path.Base(filePath)
+	style, ok := headerComments[base]
+	if !ok {
+		style, ok = headerComments[path.Ext(base)]
+	}
+	if !ok || bytes.Contains(content, []byte("SPDX-License-Identifier:")) {
+		return content
+	}
+
+	var header strings.Builder
+	header.WriteString(style.open)
+	fmt.Fprintf(&header, "%sSPDX-FileCopyrightText: %s %s\n", style.prefix, data.Year, data.Author)
+	fmt.Fprintf(&header, "%sSPDX-License-Identifier: %s\n", style.prefix, data.License)
+	header.WriteString(style.close)
+	header.WriteString("\n")
+
+	var first []byte
+	if bytes.HasPrefix(content, []byte("#!")) || bytes.HasPrefix(content, []byte("# syntax=")) {
+		end := bytes.IndexByte(content, '\n') + 1
+		if end == 0 {
+			end = len(content)
+		}
+		first, content = content[:end], content[end:]
+		if !bytes.HasSuffix(first, []byte("\n")) {
+			first = append(first, '\n')
+		}
+	}
+	// The header is followed by one blank line, whatever the template
+	// started with.
+	content = bytes.TrimLeft(content, "\n")
+
+	out := make([]byte, 0, len(first)+header.Len()+len(content))
+	out = append(out, first...)
+	out = append(out, header.String()...)
+	return append(out, content...)
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	"github.com/Reavix-framework/cli/internal/project"
+)
+
+// noHooks is the --no-hooks of create, build, dev and package.
+var noHooks bool
+
+// projectHooks returns the hooks of m, none when it has no hooks section.
+func projectHooks(m *project.Manifest) project.Hooks {
+	if m.Hooks == nil {
+		return project.Hooks{}
+	}
+	return *m.Hooks
+}
+
+// hookEnv is the environment of the hooks of the project at root, as
+// REAVIX_* variables: the project root and the CLI version, and the values
+// of vars that are set, like "VERSION" or "OUT_DIR".
+func hookEnv(root string, vars map[string]string) []string {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	env := []string{"REAVIX_PROJECT_ROOT=" + root, "REAVIX_CLI_VERSION=" + version}
+	for name, value := range vars {
+		if value != "" {
+			env = append(env, "REAVIX_"+name+"="+value)
+		}
+	}
+	sort.Strings(env[2:])
+	return env
+}
+
+// runHooks runs the commands of the hook name, like "preBuild", one after
+// the other through the shell in root, with env and REAVIX_HOOK set. The
+// first one to fail stops the rest, unless --no-hooks skips them all.
+func runHooks(name string, commands []string, root string, env []string) error {
+	if noHooks || len(commands) == 0 {
+		return nil
+	}
+	env = append(env, "REAVIX_HOOK="+name)
+	for _, command := range commands {
+		if verbose {
+			fmt.Printf("Running the %s hook: %s\n", name, command)
+		}
+		shell := []string{"sh", "-c", command}
+		if runtime.GOOS == "windows" {
+			shell = []string{"cmd", "/C", command}
+		}
+		err := buildPhases.track(phaseHooks, func() error {
+			return runInEnv(stdOutput, root, env, shell[0], shell[1:]...)
+		})
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			return fmt.Errorf("the %s hook %q failed with exit code %d", name, command, exit.ExitCode())
+		}
+		if err != nil {
+			return fmt.Errorf("the %s hook %q failed: %w", name, command, err)
+		}
+	}
+	return nil
+}
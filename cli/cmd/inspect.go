@@ -0,0 +1,556 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Reavix-framework/cli/internal/project"
+	"github.com/spf13/cobra"
+)
+
+// inspectFormat is --inspect of dev, build and run: "text" or "json" to
+// print their resolved configuration instead of doing anything, or "".
+var inspectFormat string
+
+// addInspectFlag gives cmd the --inspect flag, whose value may be left out
+// for text.
+func addInspectFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&inspectFormat, "inspect", "", "Print the resolved configuration and where each value comes from, without starting anything: text, or json for tools")
+	cmd.Flags().Lookup("inspect").NoOptDefVal = "text"
+}
+
+// checkInspect validates --inspect.
+func checkInspect() error {
+	switch inspectFormat {
+	case "", "text", "json":
+		return nil
+	}
+	return fmt.Errorf("invalid --inspect %q: use text or json", inspectFormat)
+}
+
+// configValue is one setting of a command, as resolved, and where it comes
+// from: a flag, like --jobs, a key of reavix.json, like reavix.json jobs, an
+// env file, the environment, or the default.
+type configValue struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// configHook is a hook of reavix.json the command runs.
+type configHook struct {
+	Name     string   `json:"name"`
+	Commands []string `json:"commands"`
+	Skipped  bool     `json:"skipped,omitempty"`
+}
+
+// resolvedConfig is what dev, build or run resolved from their flags,
+// reavix.json, the env files and the defaults, which --inspect prints:
+// the settings, the variables passed to the dev servers or the server,
+// masked, and the hooks.
+type resolvedConfig struct {
+	Command  string        `json:"command"`
+	Settings []configValue `json:"settings"`
+	Env      []configValue `json:"env"`
+	Hooks    []configHook  `json:"hooks"`
+
+	cmd *cobra.Command
+	raw map[string]interface{}
+}
+
+// newResolvedConfig starts the configuration of cmd, at the root of the
+// project of m, with its layout.
+func newResolvedConfig(cmd *cobra.Command, m *project.Manifest) *resolvedConfig {
+	c := &resolvedConfig{Command: cmd.Name(), Settings: []configValue{}, Env: []configValue{}, Hooks: []configHook{}, cmd: cmd}
+	if data, err := os.ReadFile(project.FileName); err == nil {
+		json.Unmarshal(data, &c.raw)
+	}
+	c.setProject(m)
+	return c
+}
+
+// set records the setting name, replacing an earlier value of it.
+func (c *resolvedConfig) set(name, value, source string) {
+	for i := range c.Settings {
+		if c.Settings[i].Name == name {
+			c.Settings[i] = configValue{name, value, source}
+			return
+		}
+	}
+	c.Settings = append(c.Settings, configValue{name, value, source})
+}
+
+// source says where a setting comes from: the flag, when given, else key,
+// like "ports.app", of reavix.json when it sets it, else the default.
+// Either may be "" when the setting has no such flag or key.
+func (c *resolvedConfig) source(flag, key string) string {
+	if flag != "" {
+		if f := c.cmd.Flags().Lookup(flag); f != nil && f.Changed {
+			return "--" + flag
+		}
+	}
+	if key != "" && c.inManifest(key) {
+		return project.FileName + " " + key
+	}
+	return "default"
+}
+
+// inManifest reports whether reavix.json sets key, a path like
+// "cache.ccache".
+func (c *resolvedConfig) inManifest(key string) bool {
+	var v interface{} = c.raw
+	for _, part := range strings.Split(key, ".") {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if v, ok = obj[part]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// setEnv records the variables of the env files vars, masked as env masks
+// them, and those the command adds itself.
+func (c *resolvedConfig) setEnv(vars []envVar, added ...configValue) {
+	for _, v := range vars {
+		c.Env = append(c.Env, configValue{v.key, maskEnvValue(v.key, v.value), v.source})
+	}
+	for _, v := range added {
+		v.Value = maskEnvValue(v.Name, v.Value)
+		c.Env = append(c.Env, v)
+	}
+}
+
+// setHook records the hook name of reavix.json, if it has commands.
+func (c *resolvedConfig) setHook(name string, commands []string) {
+	if len(commands) > 0 {
+		c.Hooks = append(c.Hooks, configHook{name, commands, noHooks})
+	}
+}
+
+// print writes c in inspectFormat to stdout.
+func (c *resolvedConfig) print() error {
+	if inspectFormat == "json" {
+		out, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	fmt.Printf("reavix %s would run with:\n\n", c.Command)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SETTING\tVALUE\tFROM")
+	for _, v := range c.Settings {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", v.Name, v.Value, v.Source)
+	}
+	w.Flush()
+	fmt.Println()
+	if len(c.Env) == 0 {
+		fmt.Println("No variables are added to the environment.")
+	} else {
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VARIABLE\tVALUE\tFROM")
+		for _, v := range c.Env {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", v.Name, v.Value, v.Source)
+		}
+		w.Flush()
+	}
+	fmt.Println()
+	if len(c.Hooks) == 0 {
+		fmt.Printf("No hooks of %s run.\n", project.FileName)
+	}
+	for _, h := range c.Hooks {
+		skipped := ""
+		if h.Skipped {
+			skipped = ", skipped as --no-hooks is given"
+		}
+		fmt.Printf("Hook %s%s:\n", h.Name, skipped)
+		for _, command := range h.Commands {
+			fmt.Println("  " + command)
+		}
+	}
+	return nil
+}
+
+// setProject records the layout of the project of m.
+func (c *resolvedConfig) setProject(m *project.Manifest) {
+	if root, err := os.Getwd(); err == nil {
+		c.set("project root", root, "the directory of "+project.FileName)
+	}
+	if m.HasFrontend() {
+		c.set("frontend", m.Frontend, c.source("", "frontend"))
+		c.set("package manager", m.PackageManager, c.source("", "packageManager"))
+	}
+	if m.HasBackend() {
+		c.set("backend", m.Backend, c.source("", "backend"))
+		c.set("backend language", m.BackendLang, c.source("", "backendLang"))
+	}
+}
+
+// serverSettings are the settings of the server build of dev and build,
+// resolved alike from their flags --debug, --release, --preset, --jobs,
+// --generator and --ccache, reavix.json and the defaults.
+type serverSettings struct {
+	buildType string
+	preset    string
+	jobs      int
+	generator string
+	ccache    bool
+}
+
+// resolveServerSettings resolves the server settings of the command of c
+// for m, whose build type is def unless a flag says otherwise, recording
+// them in c.
+func resolveServerSettings(c *resolvedConfig, m *project.Manifest, def string) (serverSettings, error) {
+	flags := c.cmd.Flags()
+	debug, _ := flags.GetBool("debug")
+	release, _ := flags.GetBool("release")
+	preset := ""
+	if flags.Lookup("preset") != nil {
+		preset, _ = flags.GetString("preset")
+	}
+	var s serverSettings
+	var err error
+	if s.buildType, s.preset, err = resolveBuildType(debug, release, preset, def); err != nil {
+		return s, err
+	}
+	jobs, _ := flags.GetInt("jobs")
+	if s.jobs, err = resolveJobs(jobs, flags.Changed("jobs"), m); err != nil {
+		return s, err
+	}
+	generator, _ := flags.GetString("generator")
+	if s.generator, err = resolveGenerator(generator, m); err != nil {
+		return s, err
+	}
+	ccache, _ := flags.GetBool("ccache")
+	if s.ccache, err = resolveCCache(ccache, m); err != nil {
+		return s, err
+	}
+	if !m.HasBackend() {
+		return s, nil
+	}
+
+	source := "default"
+	switch {
+	case debug:
+		source = "--debug"
+	case release:
+		source = "--release"
+	case preset != "":
+		source = "--preset"
+	}
+	// With CMakePresets.json, the preset sets the build type.
+	if hasCMakePresets(m.Backend) {
+		c.set("preset", s.preset, source)
+	} else {
+		c.set("build type", s.buildType, source)
+	}
+	jobsValue := strconv.Itoa(s.jobs)
+	if s.jobs == 0 {
+		jobsValue = "one per CPU"
+	}
+	c.set("jobs", jobsValue, c.source("jobs", "jobs"))
+	switch {
+	case s.generator == "":
+		c.set("generator", "that of the presets", filepath.Join(m.Backend, cmakePresetsFile))
+	case flags.Changed("generator") && generator != "auto":
+		c.set("generator", s.generator, "--generator")
+	case generator == "" && m.Generator != "" && m.Generator != "auto":
+		c.set("generator", s.generator, project.FileName+" generator")
+	default:
+		c.set("generator", s.generator, "default, as ninja is "+toolState("ninja"))
+	}
+	ccacheSource := c.source("ccache", "cache.ccache")
+	if ccacheSource == "default" {
+		ccacheSource = "default, as ccache is " + toolState("ccache")
+	}
+	c.set("ccache", onOff(s.ccache), ccacheSource)
+	return s, nil
+}
+
+// toolState says whether the command name is installed.
+func toolState(name string) string {
+	if _, err := exec.LookPath(name); err != nil {
+		return "not installed"
+	}
+	return "installed"
+}
+
+// onOff shows a switch.
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// portState is port, with who holds it when it is in use.
+func portState(port int) string {
+	value := strconv.Itoa(port)
+	if portFree(port) {
+		return value
+	}
+	if owner := portOwner(port); owner != "" {
+		return value + ", in use by " + owner
+	}
+	return value + ", in use"
+}
+
+// setFlags records the switches of the command of c that are on, by their
+// flag names.
+func (c *resolvedConfig) setFlags(names ...string) {
+	for _, name := range names {
+		if on, err := c.cmd.Flags().GetBool(name); err == nil && on {
+			c.set(name, "on", "--"+name)
+		}
+	}
+}
+
+// setEnvFiles records the env files the command reads for mode: files, of
+// --env-file, or those at the project root that exist.
+func (c *resolvedConfig) setEnvFiles(mode string, files []string) {
+	if len(files) > 0 {
+		var shown []string
+		for _, file := range files {
+			shown = append(shown, displayPath(file))
+		}
+		c.set("env files", strings.Join(shown, ", "), "--env-file")
+		return
+	}
+	var found []string
+	for _, file := range projectEnvFiles(mode) {
+		if fileExists(file) {
+			found = append(found, file)
+		}
+	}
+	if len(found) == 0 {
+		found = []string{"none of " + strings.Join(projectEnvFiles(mode), ", ")}
+	}
+	c.set("env files", strings.Join(found, ", "), "default")
+}
+
+// setLogs records the session logs of dev or run in logDir, "" for none.
+func (c *resolvedConfig) setLogs(m *project.Manifest, logDir string) {
+	if logDir == "" {
+		c.set("log dir", "off", "--log-dir")
+		return
+	}
+	c.set("log dir", displayPath(logDir), c.source("log-dir", ""))
+	c.set("log file size", strconv.Itoa(m.LogMaxSize())+" MB", c.source("", "logs.maxSizeMb"))
+	c.set("log age", strconv.Itoa(m.LogMaxAge())+" days", c.source("", "logs.maxAgeDays"))
+}
+
+// inspectDev records the rest of the configuration of dev, for m, with the
+// other values dev resolved.
+func inspectDev(c *resolvedConfig, m *project.Manifest, mode string, envFiles []string, api, mockFile, logDir string, sanitize []string) error {
+	c.set("starts", devHalves(m), c.source("only", "only"))
+	if len(sanitize) > 0 {
+		c.set("build type", "Debug", "--sanitize")
+		c.set("sanitizers", strings.Join(sanitize, ", "), "--sanitize")
+	}
+	ports, err := configuredDevPorts(m, devOpts.appPort, devOpts.serverPort)
+	if err != nil {
+		return err
+	}
+	if m.HasFrontend() {
+		c.set("app port", portState(ports.app), c.source("app-port", "ports.app"))
+	}
+	c.set("server port", portState(ports.server), c.source("server-port", "ports.server"))
+	c.setFlags("port-auto")
+	if api != "" {
+		c.set("API", api, "--api")
+	}
+	if mockFile != "" {
+		value, source := displayPath(mockFile), c.source("mock-api", "")
+		if m.HasBackend() {
+			value += ", should the server fail to build"
+		}
+		if source == "default" {
+			source = "default, as " + defaultMockFile + " exists"
+		}
+		c.set("mock API", value, source)
+	}
+	c.set("mode", mode, c.source("mode", ""))
+	c.setEnvFiles(mode, envFiles)
+	if m.HasBackend() {
+		c.set("health path", m.HealthPath(), c.source("", "dev.healthPath"))
+	}
+	open := m.Dev != nil && m.Dev.Open
+	if c.cmd.Flags().Changed("open") {
+		open = devOpts.open
+	}
+	c.set("open browser", onOff(open), c.source("open", "dev.open"))
+	events := m.HasFrontend() && m.HasBackend() && !devOpts.noWatch
+	if events {
+		restart := "the app checks the API again"
+		if m.Dev != nil && m.Dev.ReloadOnRestart {
+			restart = "the app reloads"
+		}
+		c.set("on server restart", restart, c.source("", "dev.reloadOnRestart"))
+	}
+	c.set("https", onOff(devOpts.https), c.source("https", ""))
+	if m.HasBackend() {
+		c.set("watch", onOff(!devOpts.noWatch), c.source("no-watch", ""))
+		if !devOpts.noWatch {
+			settings := newWatchSettings(m, devWatchDebounce)
+			c.set("watch debounce", settings.debounce.String(), c.source("", "watch.debounceMs"))
+			for i, pattern := range settings.ignore {
+				source := "default"
+				if i > len(defaultWatchIgnore) {
+					source = project.FileName + " watch.ignore"
+				}
+				c.set("watch ignore "+strconv.Itoa(i+1), pattern, source)
+			}
+		}
+	}
+	c.setLogs(m, logDir)
+	if devOpts.filter != "" {
+		c.set("filter", devOpts.filter, "--filter")
+	}
+	c.setFlags("ignore-backend-failure", "debug-server", "wait-debugger", "notify")
+
+	vars, err := resolveProjectEnv(mode, envFiles)
+	if err != nil {
+		return err
+	}
+	var added []configValue
+	if m.HasBackend() {
+		added = append(added, configValue{"REAVIX_PORT", strconv.Itoa(ports.server), "dev, to the server"})
+	}
+	if m.HasFrontend() {
+		added = append(added, configValue{"REAVIX_SERVER_PORT", strconv.Itoa(ports.server), "dev, to Vite"})
+		if api != "" {
+			added = append(added, configValue{"REAVIX_API_URL", api, "dev, to Vite"})
+		}
+		if events {
+			scheme := "ws"
+			if devOpts.https {
+				scheme = "wss"
+			}
+			added = append(added, configValue{"VITE_REAVIX_DEV_EVENTS", scheme + "://localhost:<a free port>", "dev, to Vite"})
+		}
+	}
+	if devOpts.https {
+		cert := filepath.ToSlash(filepath.Join(devCertDir, "localhost.pem"))
+		key := filepath.ToSlash(filepath.Join(devCertDir, "localhost-key.pem"))
+		if m.HasFrontend() {
+			added = append(added, configValue{"REAVIX_HTTPS_CERT", cert, "dev, to Vite"}, configValue{"REAVIX_HTTPS_KEY", key, "dev, to Vite"})
+		}
+		if m.HasBackend() && m.TLS {
+			added = append(added, configValue{"REAVIX_TLS_CERT", cert, "dev, to the server"}, configValue{"REAVIX_TLS_KEY", key, "dev, to the server"})
+		}
+	}
+	c.setEnv(vars, added...)
+	c.setHook("preDev", projectHooks(m).PreDev)
+	return c.print()
+}
+
+// inspectBuild records the rest of the configuration of build, for m, with
+// the other values build resolved.
+func inspectBuild(c *resolvedConfig, m *project.Manifest, outDir string, info buildInfo, sanitize []string) error {
+	switch {
+	case len(sanitize) > 0:
+		c.set("build type", "Debug", "--sanitize")
+		c.set("sanitizers", strings.Join(sanitize, ", "), "--sanitize")
+	case len(buildOpts.triples) > 0:
+		c.set("target triples", strings.Join(buildOpts.triples, ", "), "--target-triple")
+		if buildOpts.toolchain != "" {
+			c.set("toolchain", buildOpts.toolchain, "--toolchain")
+		}
+	}
+	switch {
+	case buildOpts.skipFrontend:
+		c.set("builds", "the server only", "--skip-frontend")
+	case buildOpts.skipBackend:
+		c.set("builds", "the frontend only", "--skip-backend")
+	default:
+		c.set("builds", devHalves(m), c.source("", "only"))
+	}
+	c.set("out dir", displayPath(outDir), c.source("out", "outDir"))
+	mode := buildOpts.mode
+	if mode == "" {
+		mode = "production"
+	}
+	c.set("mode", mode, c.source("mode", ""))
+	versionSource := c.source("version", "appVersion")
+	if versionSource == "default" {
+		versionSource = "git describe"
+	}
+	c.set("version", info.Version, versionSource)
+	c.set("commit", info.Commit, "git")
+	c.setFlags("lto", "static", "strip", "embed-assets", "frozen", "serial", "force", "clean", "reconfigure", "no-compress", "watch", "and-run")
+	if buildOpts.report != "" {
+		c.set("report", buildOpts.report, "--report")
+	}
+
+	var added []configValue
+	if m.HasFrontend() && !buildOpts.skipFrontend {
+		for _, kv := range info.viteEnv() {
+			name, value, _ := strings.Cut(kv, "=")
+			added = append(added, configValue{name, value, "build, to Vite"})
+		}
+	}
+	var vars []envVar
+	if m.HasBackend() && !buildOpts.skipBackend && buildOpts.mode != "" {
+		envFile := filepath.Join(m.Backend, ".env."+buildOpts.mode)
+		lines, err := loadEnvFile(envFile)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for _, kv := range lines {
+			name, value, _ := strings.Cut(kv, "=")
+			vars = append(vars, envVar{key: name, value: value, source: filepath.ToSlash(envFile) + ", to the server build"})
+		}
+	}
+	c.setEnv(vars, added...)
+	hooks := projectHooks(m)
+	c.setHook("preBuild", hooks.PreBuild)
+	c.setHook("postBuild", hooks.PostBuild)
+	return c.print()
+}
+
+// inspectRun records the rest of the configuration of run, for m, with the
+// values run resolved: the output directory, the env files, vars, the
+// variables resolveRunEnv passes on, with port, and the directory of the
+// session log.
+func inspectRun(c *resolvedConfig, m *project.Manifest, outDir string, envFiles []string, vars []envVar, port int, logDir string) error {
+	c.set("binary", displayPath(filepath.Join(outDir, m.Binary)), c.source("out", "outDir"))
+	c.set("mode", runOpts.mode, c.source("mode", ""))
+	c.setEnvFiles(runOpts.mode, envFiles)
+	for _, v := range vars {
+		if v.key == "REAVIX_PORT" {
+			c.set("port", strconv.Itoa(port), v.source)
+		}
+	}
+	c.setFlags("verify", "detach", "supervise", "always-restart")
+	if runOpts.supervise {
+		c.set("max restarts", strconv.Itoa(runOpts.maxRestarts)+" in 10 minutes", c.source("max-restarts", ""))
+	}
+	if runOpts.noHealth {
+		c.set("health check", "skipped", "--no-health")
+	} else {
+		path, source := runOpts.health, c.source("health", "")
+		if path == "" {
+			path, source = m.HealthPath(), c.source("", "dev.healthPath")
+		}
+		c.set("health check", path+", within "+runOpts.healthTimeout.String(), source)
+	}
+	c.set("shutdown timeout", runOpts.shutdownTimeout.String(), c.source("shutdown-timeout", ""))
+	hup := "passed on to the server"
+	if m.Run != nil && m.Run.RestartOnHup {
+		hup = "restarts the server"
+	}
+	c.set("SIGHUP", hup, c.source("", "run.restartOnHup"))
+	c.setLogs(m, logDir)
+	c.setEnv(vars)
+	return c.print()
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// projectLicense describes a license create can generate.
+type projectLicense struct {
+	id       string
+	spdx     string
+	name     string
+	template string
+}
+
+var projectLicenses = []projectLicense{
+	{id: "mit", spdx: "MIT", name: "MIT License", template: "shared/license-mit.tmpl"},
+	{id: "apache-2.0", spdx: "Apache-2.0", name: "Apache License 2.0", template: "shared/license-apache-2.0.tmpl"},
+	{id: "bsd-3", spdx: "BSD-3-Clause", name: "BSD 3-Clause License", template: "shared/license-bsd-3.tmpl"},
+}
+
+// lookupLicense resolves a --license value. It returns nil for "none".
+func lookupLicense(id string) (*projectLicense, error) {
+	if id == "none" || id == "" {
+		return nil, nil
+	}
+	ids := []string{}
+	for i := range projectLicenses {
+		if strings.EqualFold(projectLicenses[i].id, id) {
+			return &projectLicenses[i], nil
+		}
+		ids = append(ids, projectLicenses[i].id)
+	}
+	return nil, fmt.Errorf("unknown license %q (supported: %s, none)", id, strings.Join(ids, ", "))
+}
+
+// resolveAuthor returns --author when given, then the git user name, then a
+// generic fallback derived from the app name.
+func resolveAuthor(appName string) string {
+	if createOpts.author != "" {
+		return createOpts.author
+	}
+	if out, err := exec.Command("git", "config", "user.name").Output(); err == nil {
+		if name := strings.TrimSpace(string(out)); name != "" {
+			return name
+		}
+	}
+	return "The " + appName + " authors"
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+// templateListing is one row of --list-templates and its --json form.
+type templateListing struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Stack       string   `json:"stack"`
+	Tags        []string `json:"tags"`
+	Flags       []string `json:"flags"`
+	MinVersion  string   `json:"minVersion,omitempty"`
+	Source      string   `json:"source"`
+	Default     bool     `json:"default"`
+}
+
+func newTemplateListing(name, source string, meta *templateMeta) templateListing {
+	flags := meta.Flags
+	if flags == nil {
+		flags = optionalCreateFlags
+	}
+	return templateListing{
+		Name:        name,
+		Description: meta.Description,
+		Stack:       meta.Stack,
+		Tags:        meta.Tags,
+		Flags:       flags,
+		MinVersion:  meta.MinVersion,
+		Source:      source,
+		Default:     name == defaultVariant,
+	}
+}
+
+// cachedRemoteTemplates lists the remote templates already in the cache,
+// named by the URL they were cloned from. Nothing is fetched.
+func cachedRemoteTemplates() []templateListing {
+	cacheDir, err := templateCacheDir()
+	if err != nil {
+		return nil
+	}
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		return nil
+	}
+
+	var listings []templateListing
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		dir := filepath.Join(cacheDir, e.Name())
+		name := e.Name()
+		if out, err := exec.Command("git", "-C", dir, "config", "--get", "remote.origin.url").Output(); err == nil {
+			name = strings.TrimSpace(string(out))
+		}
+
+		meta := &templateMeta{}
+		if raw, err := os.ReadFile(filepath.Join(dir, remoteManifest)); err == nil {
+			if err := json.Unmarshal(raw, meta); err != nil {
+				meta.Description = fmt.Sprintf("(invalid %s: %v)", remoteManifest, err)
+			}
+		}
+		if meta.Description == "" {
+			meta.Description = "Remote template " + name
+		}
+		listings = append(listings, newTemplateListing(name, "remote", meta))
+	}
+	return listings
+}
+
+// listTemplates prints the built-in and cached remote templates as a table,
+// or as JSON for tooling.
+func listTemplates(asJSON bool) error {
+	var listings []templateListing
+	for _, name := range variantNames() {
+		meta, err := builtinMeta(name)
+		if err != nil {
+			return err
+		}
+		listings = append(listings, newTemplateListing(name, "builtin", meta))
+	}
+	listings = append(listings, cachedRemoteTemplates()...)
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(listings)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSOURCE\tSTACK\tFLAGS\tDESCRIPTION")
+	for _, l := range listings {
+		name := l.Name
+		if l.Default {
+			name += " (default)"
+		}
+		stack := l.Stack
+		if stack == "" {
+			stack = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", name, l.Source, stack, strings.Join(l.Flags, ","), l.Description)
+	}
+	return w.Flush()
+}
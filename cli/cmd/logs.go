@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Reavix-framework/cli/internal/logs"
+	"github.com/spf13/cobra"
+)
+
+var logsOpts struct {
+	follow bool
+	lines  int
+	logDir string
+}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [app|server]",
+	Short: "Show the logs of the last dev or run",
+	Long: "Print the last lines of the session logs `reavix dev` and `reavix run` write to .reavix/logs,\n" +
+		"those of the most recent session: of the Vite dev server, app, and of the server, server, or\n" +
+		"both, interleaved by time and prefixed like dev prefixes them. Each line starts with the time\n" +
+		"it was written at. -n sets how many lines of each are printed, all of them with -n 0, and -f\n" +
+		"keeps printing new lines as the session writes them, until Ctrl+C.\n\n" +
+		"A session's log moves on to a new file, like dev-server-<time>.2.log, past logs.maxSizeMb of\n" +
+		"reavix.json, 10 by default, and dev and run remove the logs older than logs.maxAgeDays, 7 by\n" +
+		"default, as they start.",
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"app", "server"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := defaultLogDir
+		if cmd.Flags().Changed("log-dir") {
+			var err error
+			if dir, err = absPathFlag(logsOpts.logDir); err != nil {
+				return err
+			}
+		}
+		if len(args) == 1 && args[0] != "app" && args[0] != "server" {
+			return fmt.Errorf("invalid log %q: use app or server", args[0])
+		}
+		if logsOpts.lines < 0 {
+			return fmt.Errorf("invalid -n %d: use a number of lines, or 0 for all", logsOpts.lines)
+		}
+		cmd.SilenceUsage = true
+		if _, err := requireProject(); err != nil {
+			return err
+		}
+
+		s, err := latestSession(dir)
+		if err != nil {
+			return err
+		}
+		names := s.names()
+		if len(args) == 1 {
+			if len(s.parts[args[0]]) == 0 {
+				return fmt.Errorf("the last session, %s of %s, has no %s log", s.command, s.started(), args[0])
+			}
+			names = args
+		}
+		fmt.Fprintf(os.Stderr, "Session of reavix %s started %s:\n", s.command, s.started())
+
+		// One log prints as it is; two are prefixed as in dev.
+		out := map[string]io.Writer{names[0]: os.Stdout}
+		if len(names) > 1 {
+			mux := outputMux("")
+			out = map[string]io.Writer{
+				"app":    mux.Stream("app", logs.Cyan).Stdout,
+				"server": mux.Stream("server", logs.Magenta).Stdout,
+			}
+		}
+
+		type logLine struct{ name, text string }
+		var tail []logLine
+		followers := map[string]*logFollower{}
+		for _, name := range names {
+			lines, f, err := s.tail(name, logsOpts.lines)
+			if err != nil {
+				return err
+			}
+			followers[name] = f
+			for _, line := range lines {
+				tail = append(tail, logLine{name, line})
+			}
+		}
+		// The lines of each log are in order already; their times, at the
+		// start of each line, interleave the two.
+		sort.SliceStable(tail, func(i, j int) bool {
+			return lineTime(tail[i].text) < lineTime(tail[j].text)
+		})
+		for _, line := range tail {
+			fmt.Fprintln(out[line.name], line.text)
+		}
+		if !logsOpts.follow {
+			return nil
+		}
+		for {
+			time.Sleep(watchInterval)
+			for _, name := range names {
+				if err := followers[name].follow(out[name]); err != nil {
+					return err
+				}
+			}
+		}
+	},
+}
+
+// logSession is a session of dev or run: the command, the start of the
+// session, as in the names of its files, and the files of the log of each
+// dev server or server, in order.
+type logSession struct {
+	command, stamp string
+	parts          map[string][]string
+}
+
+// latestSession returns the most recent session with logs in dir.
+func latestSession(dir string) (*logSession, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	type part struct {
+		name, file string
+		n          int
+	}
+	var latest *logSession
+	var parts []part
+	for _, entry := range entries {
+		match := sessionLogFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		command, name, stamp := match[1], match[2], match[3]
+		if latest == nil || stamp > latest.stamp || stamp == latest.stamp && command > latest.command {
+			latest, parts = &logSession{command: command, stamp: stamp, parts: map[string][]string{}}, nil
+		}
+		if stamp == latest.stamp && command == latest.command {
+			n := 1
+			if match[4] != "" {
+				n, _ = strconv.Atoi(match[4])
+			}
+			parts = append(parts, part{name, entry.Name(), n})
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no session logs in %s; `reavix dev` and `reavix run` write them", displayPath(dir))
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].n < parts[j].n })
+	for _, p := range parts {
+		latest.parts[p.name] = append(latest.parts[p.name], filepath.Join(dir, p.file))
+	}
+	return latest, nil
+}
+
+// names returns the dev servers or servers s has logs of, app first.
+func (s *logSession) names() []string {
+	var names []string
+	for _, name := range []string{"app", "server"} {
+		if len(s.parts[name]) > 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// started is the start of s, as a person writes it.
+func (s *logSession) started() string {
+	t, err := time.ParseInLocation(sessionTime, s.stamp, time.Local)
+	if err != nil {
+		return s.stamp
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// tail returns the last n lines of the log of name, or all of them when n
+// is 0, reading only the files they are in, and a follower of the log from
+// its end.
+func (s *logSession) tail(name string, n int) ([]string, *logFollower, error) {
+	files := s.parts[name]
+	f := &logFollower{files: files, part: len(files) - 1}
+	var lines []string
+	for i := len(files) - 1; i >= 0; i-- {
+		data, err := os.ReadFile(files[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		if i == len(files)-1 {
+			// A partial last line is printed by follow, once complete.
+			end := bytes.LastIndexByte(data, '\n') + 1
+			f.offset = int64(end)
+			data = data[:end]
+		}
+		if len(data) > 0 {
+			lines = append(strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"), lines...)
+		}
+		if n > 0 && len(lines) >= n {
+			return lines[len(lines)-n:], f, nil
+		}
+	}
+	return lines, f, nil
+}
+
+// lineTime is the time at the start of a line of a session log.
+func lineTime(line string) string {
+	if i := strings.IndexByte(line, ' '); i > 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// logFollower reads what is added to a session log after offset in its
+// file of index part, moving on to the next file once the session does.
+type logFollower struct {
+	files  []string
+	part   int
+	offset int64
+}
+
+// follow writes the complete lines added to the log since the last call to
+// w.
+func (f *logFollower) follow(w io.Writer) error {
+	for {
+		// The session writes no more to a file once it moved on to the
+		// next one, so what is read after that is seen is all of it.
+		next := nextLogPart(f.files[f.part])
+		done := fileExists(next)
+		file, err := os.Open(f.files[f.part])
+		if err != nil {
+			return err
+		}
+		file.Seek(f.offset, io.SeekStart)
+		data, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+		end := bytes.LastIndexByte(data, '\n') + 1
+		w.Write(data[:end])
+		f.offset += int64(end)
+		if !done {
+			return nil
+		}
+		if f.part == len(f.files)-1 {
+			f.files = append(f.files, next)
+		}
+		f.part, f.offset = f.part+1, 0
+	}
+}
+
+// nextLogPart is the name of the file a session log moves on to after the
+// one at path.
+func nextLogPart(path string) string {
+	match := sessionLogFile.FindStringSubmatch(filepath.Base(path))
+	n := 1
+	if match[4] != "" {
+		n, _ = strconv.Atoi(match[4])
+	}
+	return filepath.Join(filepath.Dir(path), match[1]+"-"+match[2]+"-"+match[3]+"."+strconv.Itoa(n+1)+".log")
+}
+
+func init() {
+	logsCmd.Flags().BoolVarP(&logsOpts.follow, "follow", "f", false, "Keep printing the lines the session writes")
+	logsCmd.Flags().IntVarP(&logsOpts.lines, "lines", "n", 200, "Number of lines of each log to print, 0 for all")
+	logsCmd.Flags().StringVar(&logsOpts.logDir, "log-dir", defaultLogDir, "Directory dev and run wrote their session logs to")
+	rootCmd.AddCommand(logsCmd)
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// desktopNotifier returns the command that shows a desktop notification
+// with title and message: osascript on macOS and notify-send elsewhere, or
+// "" on Windows, which has no such command.
+func desktopNotifier(title, message string) (string, []string) {
+	switch runtime.GOOS {
+	case "darwin":
+		return "osascript", []string{"-e", "display notification " + appleScriptString(message) + " with title " + appleScriptString(title)}
+	case "windows":
+		return "", nil
+	}
+	return "notify-send", []string{"--app-name=reavix", title, message}
+}
+
+// appleScriptString quotes s as an AppleScript string, for osascript.
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// checkNotify warns, with dev --notify, when there is nothing to show
+// desktop notifications with.
+func checkNotify() {
+	if !devOpts.notify {
+		return
+	}
+	name, _ := desktopNotifier("", "")
+	if name == "" {
+		fmt.Fprintln(os.Stderr, "Warning: --notify is not supported on Windows, so dev shows no notifications.")
+		return
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: --notify needs %s, which is not installed, so dev shows no notifications.\n", name)
+	}
+}
+
+// notifyDesktop shows, with dev --notify, a desktop notification with
+// title and message, so that a failure is noticed while another window is
+// in front. It does not wait for it to show.
+func notifyDesktop(title, message string) {
+	if !devOpts.notify {
+		return
+	}
+	name, args := desktopNotifier(title, message)
+	if name == "" {
+		return
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return
+	}
+	go exec.Command(name, args...).Run()
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Reavix-framework/cli/internal/project"
+)
+
+// onlyHalves are the --only values; without --only both are scaffolded.
+var onlyHalves = []string{project.OnlyFrontend, project.OnlyBackend}
+
+// frontendFlags only change what goes into app/ and backendFlags only what
+// goes into server/, so they are rejected when --only leaves that half out.
+var (
+	frontendFlags = []string{"lang", "frontend", "no-tailwind", "css", "state", "router", "lint", "tests", "storybook", "pwa"}
+	backendFlags  = []string{"backend", "c-std", "strict", "tls", "db", "vscode"}
+)
+
+func hasFrontend() bool { return createOpts.only != project.OnlyBackend }
+func hasBackend() bool  { return createOpts.only != project.OnlyFrontend }
+
+// checkOnly validates --only against the other create flags.
+func checkOnly(cmd *cobra.Command) error {
+	if createOpts.only == "" {
+		return nil
+	}
+	if !containsString(onlyHalves, createOpts.only) {
+		return fmt.Errorf("unknown --only %q (supported: %s)", createOpts.only, strings.Join(onlyHalves, ", "))
+	}
+	if createOpts.docker {
+		return fmt.Errorf("--docker builds both the app and the server and cannot be combined with --only")
+	}
+
+	skipped, half := frontendFlags, "frontend"
+	if !hasBackend() {
+		skipped, half = backendFlags, "backend"
+	}
+	for _, flag := range skipped {
+		if cmd.Flags().Changed(flag) {
+			return fmt.Errorf("--%s only affects the %s, which --only %s leaves out", flag, half, createOpts.only)
+		}
+	}
+	return nil
+}
+
+// inSkippedHalf reports whether the project path p belongs to the half
+// --only leaves out.
+func inSkippedHalf(p string) bool {
+	under := func(dir string) bool { return p == dir || strings.HasPrefix(p, dir+"/") }
+	return (!hasFrontend() && under(project.DefaultFrontend)) || (!hasBackend() && under(project.DefaultBackend))
+}
+
+// devFrontendFlags and devBackendFlags are the dev flags of the Vite dev
+// server and of the server, rejected when dev --only leaves that half out.
+var (
+	devFrontendFlags = []string{"app-port"}
+	devBackendFlags  = []string{"server-port", "ccache", "sanitize", "generator", "reconfigure", "jobs", "debug", "release", "no-watch", "ignore-backend-failure", "debug-server", "wait-debugger"}
+)
+
+// applyDevOnly validates dev --only and narrows m to the half it names, so
+// that dev checks, builds and starts just that one. m is not saved.
+func applyDevOnly(cmd *cobra.Command, m *project.Manifest, only string) error {
+	if only == "" {
+		return nil
+	}
+	if !containsString(onlyHalves, only) {
+		return fmt.Errorf("unknown --only %q (supported: %s)", only, strings.Join(onlyHalves, ", "))
+	}
+	if only == project.OnlyFrontend && !m.HasFrontend() || only == project.OnlyBackend && !m.HasBackend() {
+		return fmt.Errorf("--only %s runs nothing, as this project was created without a %s", only, only)
+	}
+	if cmd.Flags().Changed("filter") {
+		return fmt.Errorf("--filter picks one of the two dev servers, and --only %s runs just one", only)
+	}
+	skipped, half := devBackendFlags, "server"
+	if only == project.OnlyBackend {
+		skipped, half = devFrontendFlags, "frontend"
+	}
+	for _, flag := range skipped {
+		if cmd.Flags().Changed(flag) {
+			return fmt.Errorf("--%s only affects the %s, which --only %s leaves out", flag, half, only)
+		}
+	}
+	m.Only = only
+	return nil
+}
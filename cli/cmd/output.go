@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Reavix-framework/cli/internal/logs"
+)
+
+// cmdOutput is where a child process's output goes.
+type cmdOutput struct {
+	stdout, stderr io.Writer
+}
+
+// stdOutput streams a child's output straight to the terminal.
+var stdOutput = cmdOutput{stdout: os.Stdout, stderr: os.Stderr}
+
+// runIn runs name with args in dir, streaming its output to out.
+func runIn(out cmdOutput, dir, name string, args ...string) error {
+	return runInEnv(out, dir, nil, name, args...)
+}
+
+// runInEnv is runIn with env added to the environment of the command.
+func runInEnv(out cmdOutput, dir string, env []string, name string, args ...string) error {
+	return runCommand(out, dir, env, false, name, args...)
+}
+
+// runInGroup is runInEnv for a command that leads a process group of its
+// own, see ownProcessGroup. The Ctrl+C of the terminal no longer reaches
+// it, so it is up to the caller to stop it, with running.stopAll.
+func runInGroup(out cmdOutput, dir string, env []string, name string, args ...string) error {
+	return runCommand(out, dir, env, true, name, args...)
+}
+
+func runCommand(out cmdOutput, dir string, env []string, group bool, name string, args ...string) error {
+	c, err := startCommand(out, dir, env, group, name, args...)
+	if err != nil {
+		return err
+	}
+	return waitCommand(c)
+}
+
+// startCommand starts what runCommand runs without waiting for it; see
+// waitCommand.
+func startCommand(out cmdOutput, dir string, env []string, group bool, name string, args ...string) (*exec.Cmd, error) {
+	c := exec.Command(name, args...)
+	c.Dir = dir
+	if len(env) > 0 {
+		c.Env = append(os.Environ(), env...)
+	}
+	if group {
+		ownProcessGroup(c)
+	}
+	c.Stdout = out.stdout
+	c.Stderr = out.stderr
+	if err := c.Start(); err != nil {
+		return nil, err
+	}
+	running.add(c.Process, group)
+	return c, nil
+}
+
+// waitCommand waits for c, started by startCommand, to exit.
+func waitCommand(c *exec.Cmd) error {
+	defer running.remove(c.Process)
+	return c.Wait()
+}
+
+// running holds the processes runCommand has started and not yet waited
+// for, so an interrupted build --watch or dev can stop them.
+var running = &processSet{procs: map[*os.Process]bool{}}
+
+// processSet maps each process to whether it leads a process group of its
+// own.
+type processSet struct {
+	mu      sync.Mutex
+	procs   map[*os.Process]bool
+	stopped bool
+}
+
+// add records p, killing it right away once killAll or stopAll was called.
+func (s *processSet) add(p *os.Process, group bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		signalProcess(p, group, syscall.SIGKILL)
+	}
+	s.procs[p] = group
+}
+
+func (s *processSet) remove(p *os.Process) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.procs, p)
+}
+
+func (s *processSet) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.procs)
+}
+
+// killAll kills every running process and every one started after.
+func (s *processSet) killAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	for p, group := range s.procs {
+		signalProcess(p, group, syscall.SIGKILL)
+	}
+}
+
+// stopAll asks every running process to stop with SIGTERM, their process
+// groups included, and waits up to timeout for them to exit. Then it kills
+// what is left, like the rest of a group whose leader has exited, and
+// every process started after. It reports whether they all stopped in
+// time.
+func (s *processSet) stopAll(timeout time.Duration) bool {
+	s.mu.Lock()
+	s.stopped = true
+	stopping := map[*os.Process]bool{}
+	for p, group := range s.procs {
+		stopping[p] = group
+		signalProcess(p, group, syscall.SIGTERM)
+	}
+	s.mu.Unlock()
+
+	deadline := time.Now().Add(timeout)
+	for s.count() > 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	stopped := s.count() == 0
+	for p, group := range stopping {
+		signalProcess(p, group, syscall.SIGKILL)
+	}
+	s.killAll()
+	return stopped
+}
+
+// waitIfStopping blocks for good once stopAll was called, leaving the exit
+// to the signal handler that called it, so the processes it stopped are
+// not reported as failures.
+func (s *processSet) waitIfStopping() {
+	s.mu.Lock()
+	stopping := s.stopped
+	s.mu.Unlock()
+	if stopping {
+		select {}
+	}
+}
+
+// printBox prints lines to w framed in a box, for the errors that must not
+// scroll by unnoticed among the output of the dev servers.
+func printBox(w io.Writer, lines ...string) {
+	width := 0
+	for _, line := range lines {
+		if n := utf8.RuneCountInString(line); n > width {
+			width = n
+		}
+	}
+	border := "+" + strings.Repeat("-", width+2) + "+"
+	fmt.Fprintln(w, border)
+	for _, line := range lines {
+		fmt.Fprintf(w, "| %s%s |\n", line, strings.Repeat(" ", width-utf8.RuneCountInString(line)))
+	}
+	fmt.Fprintln(w, border)
+}
+
+// quietUnlessVerbose runs step with its output held back, unless --verbose
+// was given, and replays that output to out only when step fails, so a
+// successful build stays quiet.
+func quietUnlessVerbose(out cmdOutput, step func(cmdOutput) error) error {
+	return quietExplained(out, nil, step)
+}
+
+// quietExplained is quietUnlessVerbose for a step whose failures explain
+// can sum up: when explain recognizes the held-back output of a failed
+// step, its error takes the place of the replayed output.
+func quietExplained(out cmdOutput, explain func(log []byte) error, step func(cmdOutput) error) error {
+	if verbose {
+		return step(out)
+	}
+	// One buffer for both streams keeps them in order; exec.Cmd copies into
+	// it from a single goroutine when stdout and stderr are the same writer.
+	var log bytes.Buffer
+	err := step(cmdOutput{stdout: &log, stderr: &log})
+	if err == nil {
+		return nil
+	}
+	if explain != nil {
+		if explained := explain(log.Bytes()); explained != nil {
+			return explained
+		}
+	}
+	out.stderr.Write(log.Bytes())
+	return err
+}
+
+// outputMux returns a logs.Mux to the terminal for commands running side by
+// side, colored unless --no-color, NO_COLOR or a redirect rule it out,
+// showing only the stream named only, or all of them for "".
+func outputMux(only string) *logs.Mux {
+	return logs.NewMux(os.Stdout, os.Stderr, logs.ColorEnabled(os.Stdout, noColor), only)
+}
+
+// streamOutput is the output of a command whose lines go to s.
+func streamOutput(s *logs.Stream) cmdOutput {
+	return cmdOutput{stdout: s.Stdout, stderr: s.Stderr}
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// templateOverrideDir holds user replacements for the embedded templates,
+// ~/.config/reavix/templates on Linux. An override uses the same relative
+// path as the template it replaces, e.g. full/connection_status.tsx.tmpl.
+func templateOverrideDir() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "reavix", "templates"), nil
+}
+
+// templateOverride returns the user's replacement for the embedded template
+// name, or "" when there is none or --no-overrides was given.
+func templateOverride(name string) string {
+	if createOpts.noOverrides {
+		return ""
+	}
+	dir, err := templateOverrideDir()
+	if err != nil {
+		return ""
+	}
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	return path
+}
+
+// templateSource picks the filesystem file is rendered from, preferring an
+// override for embedded templates. source describes the choice for verbose
+// output.
+func templateSource(file projectFile, embedded fs.FS) (fsys fs.FS, name, source string) {
+	if file.fsys != nil {
+		return file.fsys, file.template, "template repository"
+	}
+	if override := templateOverride(file.template); override != "" {
+		return os.DirFS(filepath.Dir(override)), filepath.Base(override), fmt.Sprintf("override %s", override)
+	}
+	return embedded, file.template, "embedded"
+}
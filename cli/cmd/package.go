@@ -0,0 +1,400 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Reavix-framework/cli/internal/project"
+	"github.com/spf13/cobra"
+)
+
+// checksumsFile, in the package output directory, lists the SHA-256 of
+// every archive there in the format sha256sum -c reads.
+const checksumsFile = "SHA256SUMS"
+
+// packageFormats are the archive formats --format accepts.
+var packageFormats = []string{"tar.gz", "zip"}
+
+var packageOpts struct {
+	formats   []string
+	out       string
+	include   []string
+	skipBuild bool
+}
+
+var packageCmd = &cobra.Command{
+	Use:   "package",
+	Short: "Build and package the app into distributable archives",
+	Long: "Run `reavix build`, which reuses whatever is up to date, and pack its output into\n" +
+		"dist/<binary>-<version>-<os>-<arch>.tar.gz: the server binary, static/, a start.sh that runs\n" +
+		"the server from the unpacked directory, " + buildInfoFile + " and the " + artifactManifestFile + " that\n" +
+		"`reavix verify` checks the unpacked files against, under one top-level directory of the same\n" +
+		"name. --format zip, or --format tar.gz,zip, writes a zip instead or as well;\n" +
+		"--include adds files or directories like LICENSE or migrations. The SHA-256 of every archive\n" +
+		"in the output directory is listed in its " + checksumsFile + ".",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formats, err := resolvePackageFormats(packageOpts.formats)
+		if err != nil {
+			return err
+		}
+		// Paths given on the command line are relative to where it was
+		// started, so resolve them before requireProject changes into the
+		// project root.
+		out, err := absPathFlag(packageOpts.out)
+		if err != nil {
+			return err
+		}
+		var includes []string
+		for _, p := range packageOpts.include {
+			abs, err := filepath.Abs(p)
+			if err != nil {
+				return err
+			}
+			if !fileExists(abs) {
+				return fmt.Errorf("--include %s does not exist", p)
+			}
+			includes = append(includes, abs)
+		}
+		m, err := requireProject()
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		if out == "" {
+			out = "dist"
+		}
+		cmd.SilenceUsage = true
+
+		if !packageOpts.skipBuild {
+			if err := buildCmd.RunE(buildCmd, nil); err != nil {
+				return err
+			}
+		}
+		info, err := loadBuildInfo(m.OutDir)
+		if err != nil {
+			// A build from before build-info.json still packages, as what
+			// it would be built as now.
+			if info, err = resolveBuildInfo(".", "", m.AppVersion); err != nil {
+				return err
+			}
+		}
+		base := fmt.Sprintf("%s-%s-%s-%s", m.Binary, strings.ReplaceAll(info.Version, "/", "-"), runtime.GOOS, runtime.GOARCH)
+		entries, err := packageEntries(m, base, includes)
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(out, 0755); err != nil {
+			return err
+		}
+		var archives []string
+		for _, format := range formats {
+			archive := filepath.Join(out, base+"."+format)
+			write := writeTarGz
+			if format == "zip" {
+				write = writeZip
+			}
+			if err := writeArchive(archive, entries, write); err != nil {
+				return fmt.Errorf("writing %s: %w", displayPath(archive), err)
+			}
+			fmt.Printf("Packaged %s (%s)\n", displayPath(archive), formatSize(pathSize(archive)))
+			archives = append(archives, archive)
+		}
+		if err := writeChecksums(out, archives); err != nil {
+			return fmt.Errorf("writing %s: %w", checksumsFile, err)
+		}
+		fmt.Printf("Checksums are in %s\n", displayPath(filepath.Join(out, checksumsFile)))
+		return nil
+	},
+}
+
+// resolvePackageFormats checks the --format values and drops repeats.
+func resolvePackageFormats(values []string) ([]string, error) {
+	var formats []string
+	seen := map[string]bool{}
+	for _, v := range values {
+		v = strings.TrimPrefix(strings.ToLower(v), ".")
+		if v == "tgz" {
+			v = "tar.gz"
+		}
+		known := false
+		for _, f := range packageFormats {
+			known = known || f == v
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown archive format %q (supported: %s)", v, strings.Join(packageFormats, ", "))
+		}
+		if !seen[v] {
+			seen[v] = true
+			formats = append(formats, v)
+		}
+	}
+	if len(formats) == 0 {
+		return nil, fmt.Errorf("--format needs at least one of %s", strings.Join(packageFormats, ", "))
+	}
+	return formats, nil
+}
+
+// packageEntry is a file in an archive, below its top-level directory.
+// Its contents come from src on disk, or from content when src is "".
+type packageEntry struct {
+	name    string
+	src     string
+	content []byte
+	mode    fs.FileMode
+	modTime time.Time
+	dir     bool
+}
+
+// packageEntries lists what goes into the archives of m below base: the
+// binary and the start script of a project with a server, static/ of one
+// with a frontend, buildInfoFile, artifactManifestFile and the includes.
+func packageEntries(m *project.Manifest, base string, includes []string) ([]packageEntry, error) {
+	entries := []packageEntry{{name: base, mode: fs.ModeDir | 0755, modTime: time.Now(), dir: true}}
+	names := map[string]string{}
+	add := func(src, name string) error {
+		if other, ok := names[name]; ok {
+			return fmt.Errorf("%s and %s would both be packaged as %s", displayPath(other), displayPath(src), name)
+		}
+		names[name] = src
+		return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(src, p)
+			if err != nil {
+				return err
+			}
+			info, err := os.Stat(p)
+			if err != nil {
+				return err
+			}
+			entry := packageEntry{name: path.Join(base, name, filepath.ToSlash(rel)), src: p, mode: info.Mode(), modTime: info.ModTime()}
+			switch {
+			case info.IsDir():
+				entry.src, entry.dir = "", true
+			case !info.Mode().IsRegular():
+				return nil
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	}
+
+	if m.HasBackend() {
+		binary := filepath.Join(m.OutDir, m.Binary)
+		if !fileExists(binary) {
+			return nil, fmt.Errorf("%s does not exist; run `reavix build` first", displayPath(binary))
+		}
+		if err := add(binary, m.Binary); err != nil {
+			return nil, err
+		}
+		names["start.sh"] = "start.sh"
+		entries = append(entries, packageEntry{name: path.Join(base, "start.sh"), content: startScript(m), mode: 0755, modTime: time.Now()})
+	}
+	if static := filepath.Join(m.OutDir, "static"); m.HasFrontend() {
+		if !fileExists(static) {
+			return nil, fmt.Errorf("%s does not exist; run `reavix build` first", displayPath(static))
+		}
+		if err := add(static, "static"); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range []string{buildInfoFile, artifactManifestFile} {
+		if file := filepath.Join(m.OutDir, name); fileExists(file) {
+			if err := add(file, name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, include := range includes {
+		if err := add(include, filepath.Base(include)); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// startScript runs the server of m from the directory the archive was
+// unpacked into, as `reavix run` does from the output directory.
+func startScript(m *project.Manifest) []byte {
+	var b bytes.Buffer
+	b.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&b, "# Starts %s from this directory, where it finds static/.\n", m.Binary)
+	if m.TLS {
+		b.WriteString("# Set REAVIX_TLS_CERT and REAVIX_TLS_KEY to serve HTTPS.\n")
+	}
+	b.WriteString("cd \"$(dirname \"$0\")\" || exit 1\n")
+	fmt.Fprintf(&b, "exec ./%s \"$@\"\n", m.Binary)
+	return b.Bytes()
+}
+
+// writeArchive writes entries to path with write, which gets the file to
+// write to. A failed archive is removed rather than left half written.
+func writeArchive(path string, entries []packageEntry, write func(io.Writer, []packageEntry) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = write(f, entries)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+// open returns the contents of e.
+func (e packageEntry) open() (io.ReadCloser, error) {
+	if e.src == "" {
+		return io.NopCloser(bytes.NewReader(e.content)), nil
+	}
+	return os.Open(e.src)
+}
+
+func (e packageEntry) size() (int64, error) {
+	if e.src == "" {
+		return int64(len(e.content)), nil
+	}
+	info, err := os.Stat(e.src)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func writeTarGz(w io.Writer, entries []packageEntry) error {
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Mode: int64(e.mode.Perm()), ModTime: e.modTime, Typeflag: tar.TypeReg}
+		if e.dir {
+			h.Name += "/"
+			h.Typeflag = tar.TypeDir
+			if err := tw.WriteHeader(h); err != nil {
+				return err
+			}
+			continue
+		}
+		size, err := e.size()
+		if err != nil {
+			return err
+		}
+		h.Size = size
+		if err := tw.WriteHeader(h); err != nil {
+			return err
+		}
+		if err := copyEntry(tw, e); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// writeZip writes entries as a zip. zip records permissions only in the
+// external attributes of entries made by Unix, which SetMode sets up, so
+// the binary and start.sh stay executable when unzipped.
+func writeZip(w io.Writer, entries []packageEntry) error {
+	zw := zip.NewWriter(w)
+	for _, e := range entries {
+		h := &zip.FileHeader{Name: e.name, Method: zip.Deflate, Modified: e.modTime}
+		h.SetMode(e.mode)
+		if e.dir {
+			h.Name += "/"
+			h.Method = zip.Store
+		}
+		fw, err := zw.CreateHeader(h)
+		if err != nil {
+			return err
+		}
+		if !e.dir {
+			if err := copyEntry(fw, e); err != nil {
+				return err
+			}
+		}
+	}
+	return zw.Close()
+}
+
+func copyEntry(w io.Writer, e packageEntry) error {
+	r, err := e.open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// writeChecksums records the SHA-256 of archives in the checksumsFile of
+// dir, keeping the lines of other archives there that still exist.
+func writeChecksums(dir string, archives []string) error {
+	sums := map[string]string{}
+	if raw, err := os.ReadFile(filepath.Join(dir, checksumsFile)); err == nil {
+		for _, line := range strings.Split(string(raw), "\n") {
+			if sum, name, ok := strings.Cut(line, "  "); ok && fileExists(filepath.Join(dir, name)) {
+				sums[name] = sum
+			}
+		}
+	}
+	for _, archive := range archives {
+		sum, err := fileSHA256(archive)
+		if err != nil {
+			return err
+		}
+		sums[filepath.Base(archive)] = sum
+	}
+	names := make([]string, 0, len(sums))
+	for name := range sums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s  %s\n", sums[name], name)
+	}
+	return os.WriteFile(filepath.Join(dir, checksumsFile), []byte(b.String()), 0644)
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	sum := sha256.New()
+	if _, err := io.Copy(sum, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+func init() {
+	packageCmd.Flags().StringSliceVar(&packageOpts.formats, "format", []string{"tar.gz"}, "Archive formats to write: tar.gz, zip or both (comma-separated)")
+	packageCmd.Flags().StringVarP(&packageOpts.out, "out", "o", "", "Directory to write the archives and "+checksumsFile+" to (default dist)")
+	packageCmd.Flags().StringArrayVar(&packageOpts.include, "include", nil, "Extra file or directory to put in the archives, like LICENSE or migrations (repeatable)")
+	packageCmd.Flags().BoolVar(&packageOpts.skipBuild, "skip-build", false, "Package the output of the last build as it is instead of running reavix build first")
+	packageCmd.Flags().BoolVar(&noHooks, "no-hooks", false, "Don't run the preBuild and postBuild hooks of reavix.json")
+	packageCmd.Flags().BoolVar(&skipChecks, "skip-checks", false, "Don't check that the build tools are installed before building")
+	rootCmd.AddCommand(packageCmd)
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var packageManagers = []string{"npm", "pnpm", "yarn", "bun"}
+
+// lockfiles maps each package manager to the lockfiles it writes.
+var lockfiles = map[string][]string{
+	"pnpm": {"pnpm-lock.yaml"},
+	"yarn": {"yarn.lock"},
+	"bun":  {"bun.lockb", "bun.lock"},
+	"npm":  {"package-lock.json"},
+}
+
+// validatePackageManager accepts a known manager or "" for auto-detection.
+func validatePackageManager(pm string) error {
+	if pm == "" {
+		return nil
+	}
+	for _, known := range packageManagers {
+		if pm == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown package manager %q (supported: %s)", pm, strings.Join(packageManagers, ", "))
+}
+
+// detectPackageManager picks a manager for a new project in dir: a lockfile
+// in dir or one of its parents wins, then the manager that invoked the CLI,
+// then the first one found on PATH.
+func detectPackageManager(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		for d := abs; ; d = filepath.Dir(d) {
+			if pm := lockfileManager(d); pm != "" {
+				return pm
+			}
+			if filepath.Dir(d) == d {
+				break
+			}
+		}
+	}
+
+	if agent := os.Getenv("npm_config_user_agent"); agent != "" {
+		name := strings.SplitN(agent, "/", 2)[0]
+		if validatePackageManager(name) == nil {
+			return name
+		}
+	}
+
+	for _, pm := range packageManagers {
+		if _, err := exec.LookPath(pm); err == nil {
+			return pm
+		}
+	}
+	return "npm"
+}
+
+func lockfileManager(dir string) string {
+	for _, pm := range packageManagers {
+		for _, lock := range lockfiles[pm] {
+			if fileExists(filepath.Join(dir, lock)) {
+				return pm
+			}
+		}
+	}
+	return ""
+}
+
+// projectPackageManager returns the manager a scaffolded frontend in appDir
+// uses, as recorded in the packageManager field of its package.json or
+// implied by its lockfile.
+func projectPackageManager(appDir string) string {
+	data, err := os.ReadFile(filepath.Join(appDir, "package.json"))
+	if err == nil {
+		var pkg struct {
+			PackageManager string `json:"packageManager"`
+		}
+		if json.Unmarshal(data, &pkg) == nil && pkg.PackageManager != "" {
+			name := strings.SplitN(pkg.PackageManager, "@", 2)[0]
+			if validatePackageManager(name) == nil {
+				return name
+			}
+		}
+	}
+	if pm := lockfileManager(appDir); pm != "" {
+		return pm
+	}
+	return "npm"
+}
+
+// packageManagerSpec returns the "name@version" value for the packageManager
+// field of package.json, or "" when the version cannot be determined.
+func packageManagerSpec(pm string) string {
+	out, err := exec.Command(pm, "--version").Output()
+	if err != nil {
+		return ""
+	}
+	version := strings.TrimPrefix(strings.TrimSpace(string(out)), "v")
+	if version == "" || strings.ContainsAny(version, " \n") {
+		return ""
+	}
+	return pm + "@" + version
+}
+
+// installArgs returns the command line that installs everything listed in
+// package.json.
+func installArgs(pm string) []string {
+	return []string{pm, "install"}
+}
+
+// offlineInstallArgs installs from the local package manager cache only.
+// bun has no offline switch; it already prefers its cache.
+func offlineInstallArgs(pm string) []string {
+	if pm == "bun" {
+		return installArgs(pm)
+	}
+	return append(installArgs(pm), "--offline")
+}
+
+// frozenInstallArgs installs exactly what the lockfile lists, failing when
+// package.json and the lockfile disagree instead of updating it. With
+// offline, only the local cache is used; bun has no switch for that either.
+func frozenInstallArgs(pm string, offline bool) []string {
+	var args []string
+	switch pm {
+	case "npm":
+		args = []string{"npm", "ci"}
+	default:
+		args = append(installArgs(pm), "--frozen-lockfile")
+	}
+	if offline && pm != "bun" {
+		args = append(args, "--offline")
+	}
+	return args
+}
+
+// runScriptArgs returns the command line that runs a package.json script,
+// passing args on to it; npm is the one manager that needs them after "--".
+func runScriptArgs(pm, script string, args ...string) []string {
+	line := []string{pm, "run", script}
+	if pm == "npm" && len(args) > 0 {
+		line = append(line, "--")
+	}
+	return append(line, args...)
+}
+
+// execArgs returns the command line that runs a package binary, the
+// equivalent of npx for each manager.
+func execArgs(pm string, bin ...string) []string {
+	switch pm {
+	case "pnpm":
+		return append([]string{"pnpm", "dlx"}, bin...)
+	case "yarn":
+		return append([]string{"yarn"}, bin...)
+	case "bun":
+		return append([]string{"bunx"}, bin...)
+	default:
+		return append([]string{"npx"}, bin...)
+	}
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/Reavix-framework/cli/internal/project"
+)
+
+// portFree reports whether nothing listens on port, by listening on it.
+func portFree(port int) bool {
+	ln, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err != nil {
+		return false
+	}
+	ln.Close()
+	return true
+}
+
+// portOwner names the process listening on port, like "node (pid 4242)",
+// or returns "" when that cannot be found out. Linux has it in /proc;
+// elsewhere lsof is asked, when installed.
+func portOwner(port int) string {
+	if runtime.GOOS == "linux" {
+		if owner := procPortOwner(port); owner != "" {
+			return owner
+		}
+	}
+	out, err := exec.Command("lsof", "-nP", "-iTCP:"+strconv.Itoa(port), "-sTCP:LISTEN", "-Fpc").Output()
+	if err != nil {
+		return ""
+	}
+	// -F prints one field per line: p<pid>, then c<command>.
+	var pid, name string
+	for _, line := range strings.Split(string(out), "\n") {
+		switch {
+		case strings.HasPrefix(line, "p") && pid == "":
+			pid = line[1:]
+		case strings.HasPrefix(line, "c") && name == "":
+			name = line[1:]
+		}
+	}
+	if pid == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s (pid %s)", name, pid)
+}
+
+// procPortOwner finds the socket listening on port in /proc/net, then the
+// process holding it among the open files in /proc. Processes of other
+// users cannot be looked into, so theirs are not found.
+func procPortOwner(port int) string {
+	inodes := map[string]bool{}
+	for _, table := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		f, err := os.Open(table)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			// sl local_address rem_address st ... inode, the state 0A
+			// being LISTEN and the port the hex after the colon.
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 10 || fields[3] != "0A" {
+				continue
+			}
+			_, hexPort, ok := strings.Cut(fields[1], ":")
+			if p, err := strconv.ParseInt(hexPort, 16, 32); ok && err == nil && int(p) == port {
+				inodes["socket:["+fields[9]+"]"] = true
+			}
+		}
+		f.Close()
+	}
+	if len(inodes) == 0 {
+		return ""
+	}
+	fds, _ := filepath.Glob("/proc/[0-9]*/fd/*")
+	for _, fd := range fds {
+		if target, err := os.Readlink(fd); err == nil && inodes[target] {
+			pid := strings.Split(fd, "/")[2]
+			name, _ := os.ReadFile(filepath.Join("/proc", pid, "comm"))
+			return fmt.Sprintf("%s (pid %s)", strings.TrimSpace(string(name)), pid)
+		}
+	}
+	return ""
+}
+
+// devPorts are the ports dev runs the frontend and the server on, and
+// whether they speak HTTPS there, under --https.
+type devPorts struct {
+	app, server       int
+	appTLS, serverTLS bool
+}
+
+// appURL is the URL of the Vite dev server.
+func (p devPorts) appURL() string { return localURL(p.appTLS, p.app) }
+
+// serverURL is the URL of the server.
+func (p devPorts) serverURL() string { return localURL(p.serverTLS, p.server) }
+
+func localURL(tls bool, port int) string {
+	scheme := "http"
+	if tls {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://localhost:%d", scheme, port)
+}
+
+// configuredDevPorts returns the ports dev is configured to use, the flags
+// else the ports of reavix.json, without checking that they are free.
+func configuredDevPorts(m *project.Manifest, appFlag, serverFlag int) (devPorts, error) {
+	ports := devPorts{app: m.Ports.App, server: m.Ports.Server}
+	if appFlag != 0 {
+		ports.app = appFlag
+	}
+	if serverFlag != 0 {
+		ports.server = serverFlag
+	}
+	for _, p := range []struct {
+		flag string
+		port int
+	}{{"--app-port", ports.app}, {"--server-port", ports.server}} {
+		if p.port < 1 || p.port > 65535 {
+			return devPorts{}, fmt.Errorf("invalid %s %d: want a port from 1 to 65535", p.flag, p.port)
+		}
+	}
+	if m.HasFrontend() && m.HasBackend() && ports.app == ports.server {
+		return devPorts{}, fmt.Errorf("the app and the server cannot both use port %d; pass --app-port or --server-port", ports.app)
+	}
+	return ports, nil
+}
+
+// resolveDevPorts picks the ports of dev, those of configuredDevPorts. A
+// port in use fails with the process holding it named, unless auto,
+// --port-auto, moves on to the next free one, saying so. Only the ports of
+// the halves m has are checked.
+func resolveDevPorts(m *project.Manifest, appFlag, serverFlag int, auto bool) (devPorts, error) {
+	ports, err := configuredDevPorts(m, appFlag, serverFlag)
+	if err != nil {
+		return devPorts{}, err
+	}
+	pick := func(half, flag string, port, taken int) (int, error) {
+		if portFree(port) {
+			return port, nil
+		}
+		holder := "another process"
+		if owner := portOwner(port); owner != "" {
+			holder = owner
+		}
+		if !auto {
+			return 0, fmt.Errorf("port %d for the %s is in use by %s; stop it, pass %s, or pass --port-auto to pick a free port", port, half, holder, flag)
+		}
+		for next := port + 1; next <= 65535; next++ {
+			if next != taken && portFree(next) {
+				fmt.Printf("Port %d is in use by %s, so the %s runs on port %d.\n", port, holder, half, next)
+				return next, nil
+			}
+		}
+		return 0, fmt.Errorf("no free port above %d for the %s", port, half)
+	}
+	if m.HasBackend() {
+		taken := 0
+		if m.HasFrontend() {
+			taken = ports.app
+		}
+		if ports.server, err = pick("server", "--server-port", ports.server, taken); err != nil {
+			return devPorts{}, err
+		}
+	}
+	if m.HasFrontend() {
+		if ports.app, err = pick("app", "--app-port", ports.app, ports.server); err != nil {
+			return devPorts{}, err
+		}
+	}
+	return ports, nil
+}
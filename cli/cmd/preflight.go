@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/Reavix-framework/cli/internal/preflight"
+	"github.com/Reavix-framework/cli/internal/project"
+)
+
+// skipChecks is the --skip-checks of create, build and dev.
+var skipChecks bool
+
+// runPreflight checks tools before a command runs any of them and returns
+// one error listing everything that is missing, unless --skip-checks was
+// given.
+func runPreflight(tools []preflight.Tool) error {
+	if skipChecks || len(tools) == 0 {
+		return nil
+	}
+	if problems := preflight.Check(tools); len(problems) > 0 {
+		return errors.New(preflight.Report(problems, runtime.GOOS))
+	}
+	return nil
+}
+
+// frontendTools are what installing and building the frontend runs.
+func frontendTools(pm string) []preflight.Tool {
+	return []preflight.Tool{preflight.Node(), preflight.PackageManager(pm)}
+}
+
+// serverTools are what building the server with preset and generator
+// needs: cmake as recent as the project asks for, the compiler and the build
+// tool of the generator, or of the preset's when generator is "".
+func serverTools(m *project.Manifest, preset, generator string) []preflight.Tool {
+	if generator == "" {
+		generator = "make"
+		if p, err := resolvePreset(m.Backend, preset); err == nil && strings.Contains(p.generator, "Ninja") {
+			generator = "ninja"
+		}
+	}
+	buildTool := preflight.Make()
+	if generator == "ninja" {
+		buildTool = preflight.Ninja()
+	}
+	return []preflight.Tool{
+		preflight.CMake(requiredCMake(m.Backend)),
+		preflight.Compiler(m.BackendLang),
+		buildTool,
+	}
+}
+
+// projectTools are the tools building the frontend, the server or both
+// needs.
+func projectTools(m *project.Manifest, preset, generator string, app, server bool) []preflight.Tool {
+	var tools []preflight.Tool
+	if app {
+		tools = append(tools, frontendTools(m.PackageManager)...)
+	}
+	if server {
+		tools = append(tools, serverTools(m, preset, generator)...)
+	}
+	return tools
+}
+
+var cmakeMinimumRequired = regexp.MustCompile(`(?i)cmake_minimum_required\s*\(\s*VERSION\s+([0-9.]+)`)
+
+// requiredCMake is the cmake version the server in backend asks for: its
+// cmake_minimum_required, raised to what CMakePresets.json needs when there
+// is one.
+func requiredCMake(backend string) string {
+	min := cmakeMinimum
+	if content, err := os.ReadFile(filepath.Join(backend, "CMakeLists.txt")); err == nil {
+		if m := cmakeMinimumRequired.FindSubmatch(content); m != nil {
+			min = string(m[1])
+		}
+	}
+	if hasCMakePresets(backend) && preflight.CompareVersions(min, preflight.MinCMakePresets) < 0 {
+		return preflight.MinCMakePresets
+	}
+	return min
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// presetFlags are the create flags a preset records.
+var presetFlags = []string{
+	"template", "only", "pm", "lang", "backend", "c-std", "strict", "tls", "db", "frontend", "no-tailwind", "css", "state", "router", "realtime", "example", "no-install", "git", "license",
+	"no-headers", "author", "ci", "docker", "devcontainer", "lint", "tests", "storybook", "pwa", "vscode", "no-overrides",
+}
+
+// flagSemanticsChanged maps a preset flag to the CLI version in which its
+// meaning changed, so replaying an older preset can warn about it. Add an
+// entry whenever a release changes what a recorded flag value does.
+var flagSemanticsChanged = map[string]string{}
+
+// createPreset is a saved set of create flags.
+type createPreset struct {
+	Version string            `json:"version"`
+	Flags   map[string]string `json:"flags"`
+}
+
+type presetFile struct {
+	Presets map[string]createPreset `json:"presets"`
+}
+
+func presetsPath() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "reavix", "presets.json"), nil
+}
+
+func loadPresets() (*presetFile, error) {
+	path, err := presetsPath()
+	if err != nil {
+		return nil, err
+	}
+	presets := &presetFile{Presets: map[string]createPreset{}}
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return presets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, presets); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	if presets.Presets == nil {
+		presets.Presets = map[string]createPreset{}
+	}
+	return presets, nil
+}
+
+func (p *presetFile) save() error {
+	path, err := presetsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(raw, '\n'), 0644)
+}
+
+// applyPreset sets every flag recorded in the named preset that was not
+// given explicitly on the command line.
+func applyPreset(cmd *cobra.Command, name string) error {
+	presets, err := loadPresets()
+	if err != nil {
+		return err
+	}
+	preset, ok := presets.Presets[name]
+	if !ok {
+		return fmt.Errorf("unknown preset %q (see `reavix preset list`)", name)
+	}
+
+	for flag, value := range preset.Flags {
+		if cmd.Flags().Lookup(flag) == nil {
+			fmt.Fprintf(os.Stderr, "Warning: preset %s sets --%s, which this version no longer has\n", name, flag)
+			continue
+		}
+		if changedIn, ok := flagSemanticsChanged[flag]; ok && !versionAtLeast(preset.Version, changedIn) {
+			fmt.Fprintf(os.Stderr, "Warning: preset %s was saved with reavix %s; --%s changed meaning in %s\n", name, preset.Version, flag, changedIn)
+		}
+		if cmd.Flags().Changed(flag) {
+			continue
+		}
+		if err := cmd.Flags().Set(flag, value); err != nil {
+			return fmt.Errorf("preset %s: invalid --%s value %q: %w", name, flag, value, err)
+		}
+	}
+	return nil
+}
+
+// savePreset records the preset flags given on the command line, including
+// any replayed from --preset, under name.
+func savePreset(cmd *cobra.Command, name string) error {
+	presets, err := loadPresets()
+	if err != nil {
+		return err
+	}
+	flags := map[string]string{}
+	for _, flag := range presetFlags {
+		if f := cmd.Flags().Lookup(flag); f != nil && f.Changed {
+			flags[flag] = f.Value.String()
+		}
+	}
+	presets.Presets[name] = createPreset{Version: version, Flags: flags}
+	return presets.save()
+}
+
+var presetCmd = &cobra.Command{
+	Use:   "preset",
+	Short: "Manage saved create presets",
+	Long:  "Manage the flag presets saved with `reavix create --save-preset` and replayed with --preset.",
+}
+
+var presetListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved presets",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		presets, err := loadPresets()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if len(presets.Presets) == 0 {
+			fmt.Println("No presets saved yet. Use `reavix create <app> --save-preset <name>`.")
+			return
+		}
+
+		var names []string
+		for name := range presets.Presets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tVERSION\tFLAGS")
+		for _, name := range names {
+			preset := presets.Presets[name]
+			var flags []string
+			for flag, value := range preset.Flags {
+				flags = append(flags, fmt.Sprintf("--%s=%s", flag, value))
+			}
+			sort.Strings(flags)
+			fmt.Fprintf(w, "%s\t%s\t%s\n", name, preset.Version, strings.Join(flags, " "))
+		}
+		w.Flush()
+	},
+}
+
+var presetDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a saved preset",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		presets, err := loadPresets()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if _, ok := presets.Presets[args[0]]; !ok {
+			fmt.Printf("unknown preset %q\n", args[0])
+			os.Exit(1)
+		}
+		delete(presets.Presets, args[0])
+		if err := presets.save(); err != nil {
+			fmt.Printf("Error saving presets: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Deleted preset %s\n", args[0])
+	},
+}
+
+func init() {
+	presetCmd.AddCommand(presetListCmd, presetDeleteCmd)
+	rootCmd.AddCommand(presetCmd)
+}
@@ -0,0 +1,66 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ownProcessGroup makes c the leader of a process group of its own, so the
+// processes it starts, like the esbuild of Vite, can be signalled with it.
+func ownProcessGroup(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signalProcess sends sig to p and, when p leads a process group of its
+// own, to the rest of its group, which may outlive p.
+func signalProcess(p *os.Process, group bool, sig syscall.Signal) error {
+	if group {
+		return syscall.Kill(-p.Pid, sig)
+	}
+	return p.Signal(sig)
+}
+
+// forwardSignal passes sig, which run got, on to p and, with group, the
+// rest of the process group p leads.
+func forwardSignal(p *os.Process, group bool, sig os.Signal) {
+	if s, ok := sig.(syscall.Signal); ok {
+		signalProcess(p, group, s)
+	}
+}
+
+// inProcessGroup reports whether the process pid is in the process group
+// of leader.
+func inProcessGroup(pid, leader int) bool {
+	pgid, err := syscall.Getpgid(pid)
+	return err == nil && pgid == leader
+}
+
+// detachProcess starts c in a session of its own, which also makes it the
+// leader of its process group, so it outlives the terminal it was started
+// from.
+func detachProcess(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+// processAlive reports whether the process pid exists, the signal 0 asking
+// that without signalling it. One of another user, which refuses it, exists.
+// A zombie, which no one reaped, as in a container whose init does not, is
+// gone: Linux says so in /proc.
+func processAlive(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil && err != syscall.EPERM {
+		return false
+	}
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return true
+	}
+	// pid (comm) state ..., comm being free to hold spaces and parentheses.
+	fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+	return len(fields) == 0 || fields[0] != "Z"
+}
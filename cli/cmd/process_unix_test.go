@@ -0,0 +1,90 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// groupAlive reports whether a process of the process group pgid is still
+// running. Zombies, which a container's init may never reap, do not count;
+// without /proc, they do.
+func groupAlive(pgid int) bool {
+	stats, _ := filepath.Glob("/proc/[0-9]*/stat")
+	if len(stats) == 0 {
+		return syscall.Kill(-pgid, 0) == nil
+	}
+	for _, path := range stats {
+		stat, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		// pid (comm) state ppid pgrp ...
+		fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+		if len(fields) > 2 && fields[0] != "Z" && fields[2] == strconv.Itoa(pgid) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestStopAllStopsProcessGroups(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		// inTime is whether SIGTERM stops the group, rather than the
+		// SIGKILL after the timeout.
+		inTime bool
+	}{
+		{"stops on SIGTERM", "sleep 60 & sleep 60", true},
+		{"ignores SIGTERM", `trap "" TERM; sleep 60 & sleep 60`, false},
+	}
+	const timeout = 500 * time.Millisecond
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A set of the test's own, as waitCommand would remove the
+			// process from running while other tests use it.
+			set := &processSet{procs: map[*os.Process]bool{}}
+			c := exec.Command("sh", "-c", tt.script)
+			ownProcessGroup(c)
+			if err := c.Start(); err != nil {
+				t.Fatal(err)
+			}
+			set.add(c.Process, true)
+			exited := make(chan struct{})
+			go func() {
+				c.Wait()
+				set.remove(c.Process)
+				close(exited)
+			}()
+			defer func() { <-exited }()
+			pgid := c.Process.Pid
+			defer syscall.Kill(-pgid, syscall.SIGKILL)
+			// Let sh start both sleeps, and its trap take effect.
+			time.Sleep(200 * time.Millisecond)
+
+			started := time.Now()
+			if stopped := set.stopAll(timeout); stopped != tt.inTime {
+				t.Errorf("stopAll = %v, want %v", stopped, tt.inTime)
+			}
+			elapsed := time.Since(started)
+			if !tt.inTime && elapsed < timeout {
+				t.Errorf("stopAll killed the group after %s, before the %s grace period", elapsed, timeout)
+			}
+			for deadline := time.Now().Add(2 * time.Second); groupAlive(pgid); {
+				if time.Now().After(deadline) {
+					t.Fatalf("process group %d still runs after stopAll", pgid)
+				}
+				time.Sleep(20 * time.Millisecond)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+//go:build windows
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// detachedProcess is the creation flag of a process without a console.
+const detachedProcess = 0x00000008
+
+// ownProcessGroup does nothing on Windows, which has no process groups to
+// signal.
+func ownProcessGroup(c *exec.Cmd) {}
+
+// signalProcess kills p whatever sig is, as Windows cannot ask a process to
+// stop.
+func signalProcess(p *os.Process, group bool, sig syscall.Signal) error {
+	return p.Kill()
+}
+
+// forwardSignal does nothing on Windows, which cannot pass signals on; its
+// Ctrl+C reaches every process of the console by itself.
+func forwardSignal(p *os.Process, group bool, sig os.Signal) {}
+
+// inProcessGroup reports false, as Windows has no process groups to be in.
+func inProcessGroup(pid, leader int) bool { return false }
+
+// detachProcess starts c without a console, and so without the Ctrl+C of
+// the one it was started from.
+func detachProcess(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | detachedProcess}
+}
+
+// processAlive reports whether the process pid is still running.
+func processAlive(pid int) bool {
+	h, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(h)
+	var code uint32
+	// STILL_ACTIVE, 259, is the exit code of a process that has not exited.
+	return syscall.GetExitCodeProcess(h, &code) == nil && code == 259
+}
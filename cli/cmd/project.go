@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Reavix-framework/cli/internal/project"
+)
+
+// requireProject finds the enclosing Reavix project, changes into its root
+// and returns its manifest. Projects created before reavix.json existed are
+// recognised by their app/ and server/ directories. Anywhere else it fails
+// with a hint before the command gets halfway.
+func requireProject() (*project.Manifest, error) {
+	root, m, err := project.Find(".")
+	if errors.Is(err, project.ErrNotFound) && isLegacyProject(".") {
+		def := project.Default()
+		root, m, err = ".", &def, nil
+	}
+	if errors.Is(err, project.ErrNotFound) {
+		return nil, fmt.Errorf("%w (no %s in this directory or any parent); run this command from a project created with `reavix create`", err, project.FileName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading project: %w", err)
+	}
+
+	if err := os.Chdir(root); err != nil {
+		return nil, fmt.Errorf("entering project root %s: %w", root, err)
+	}
+	if m.PackageManager == "" {
+		m.PackageManager = projectPackageManager(m.Frontend)
+	}
+	return m, nil
+}
+
+func isLegacyProject(dir string) bool {
+	return fileExists(filepath.Join(dir, project.DefaultFrontend, "package.json")) &&
+		fileExists(filepath.Join(dir, project.DefaultBackend, "CMakeLists.txt"))
+}
+
+// projectManifest renders the reavix.json written by create, with the hooks
+// the template declares.
+func projectManifest(data ProjectData) ([]byte, error) {
+	template := createOpts.template
+	if template == "" {
+		template = defaultVariant
+	}
+	var hooks *project.Hooks
+	if variant, err := loadVariant(createOpts.template); err == nil {
+		hooks = variant.Hooks
+	}
+	return project.Marshal(project.Manifest{
+		Name:           data.Name,
+		Version:        version,
+		Template:       template,
+		PackageManager: data.PM,
+		Frontend:       project.DefaultFrontend,
+		Backend:        project.DefaultBackend,
+		Binary:         data.BinaryName,
+		OutDir:         project.DefaultOutDir,
+		BackendLang:    data.BackendLang,
+		CStd:           createOpts.cStd,
+		Strict:         data.Strict,
+		TLS:            data.TLS,
+		Router:         data.Router,
+		Only:           createOpts.only,
+		Ports:          project.Ports{App: data.AppPort, Server: data.ServerPort},
+		Hooks:          hooks,
+	})
+}
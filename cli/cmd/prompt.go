@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// prompter asks simple line-based questions on the terminal.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func newPrompter() *prompter {
+	return &prompter{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+}
+
+// isInteractive reports whether stdin is attached to a terminal. /dev/null
+// is a character device too, so it is ruled out explicitly.
+func isInteractive() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	if null, err := os.Stat(os.DevNull); err == nil && os.SameFile(info, null) {
+		return false
+	}
+	return true
+}
+
+func (p *prompter) readLine() (string, error) {
+	line, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// askString asks until validate accepts the answer. An empty answer selects
+// def when one is given.
+func (p *prompter) askString(label, def string, validate func(string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Fprintf(p.out, "? %s (%s): ", label, def)
+		} else {
+			fmt.Fprintf(p.out, "? %s: ", label)
+		}
+		answer, err := p.readLine()
+		if err != nil {
+			return "", err
+		}
+		if answer == "" {
+			answer = def
+		}
+		if validate == nil {
+			return answer, nil
+		}
+		if err := validate(answer); err != nil {
+			fmt.Fprintf(p.out, "  %v\n", err)
+			continue
+		}
+		return answer, nil
+	}
+}
+
+func (p *prompter) askBool(label string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	for {
+		fmt.Fprintf(p.out, "? %s (%s): ", label, hint)
+		answer, err := p.readLine()
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Fprintln(p.out, "  please answer y or n")
+	}
+}
+
+func (p *prompter) askChoice(label string, choices []string, def string) (string, error) {
+	return p.askString(fmt.Sprintf("%s [%s]", label, strings.Join(choices, "/")), def, func(answer string) error {
+		for _, c := range choices {
+			if answer == c {
+				return nil
+			}
+		}
+		return fmt.Errorf("choose one of: %s", strings.Join(choices, ", "))
+	})
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// pwaThemeColor is the theme color of the web app manifest, the
+// theme-color meta tag and the placeholder icons.
+const pwaThemeColor = "#1f2937"
+
+// pwaIconSizes are the square icons a PWA needs to be installable.
+var pwaIconSizes = []int{192, 512}
+
+// pwaFiles are the files --pwa adds: the web app manifest and placeholder
+// icons in app/public.
+func pwaFiles() []projectFile {
+	files := []projectFile{{path: "app/public/manifest.webmanifest", template: "shared/manifest.webmanifest.tmpl"}}
+	for _, size := range pwaIconSizes {
+		files = append(files, projectFile{
+			path:     fmt.Sprintf("app/public/pwa-%dx%d.png", size, size),
+			template: "(generated)",
+			generate: pwaIcon(size),
+		})
+	}
+	return files
+}
+
+// pwaIcon returns a generator for a size×size placeholder icon: a light
+// square centered on the theme color.
+func pwaIcon(size int) func(ProjectData) ([]byte, error) {
+	return func(ProjectData) ([]byte, error) {
+		var r, g, b uint8
+		if _, err := fmt.Sscanf(pwaThemeColor, "#%02x%02x%02x", &r, &g, &b); err != nil {
+			return nil, err
+		}
+		bg := color.RGBA{R: r, G: g, B: b, A: 0xff}
+		fg := color.RGBA{R: 0xf9, G: 0xfa, B: 0xfb, A: 0xff}
+
+		img := image.NewRGBA(image.Rect(0, 0, size, size))
+		inset := size / 3
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				c := bg
+				if x >= inset && x < size-inset && y >= inset && y < size-inset {
+					c = fg
+				}
+				img.SetRGBA(x, y, c)
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// realtimeMechanism is a --realtime choice. path is the server endpoint
+// ConnectionStatus connects to through the Vite proxy; it is empty for
+// none, which keeps the one-off /api/health check.
+type realtimeMechanism struct {
+	id   string
+	path string
+}
+
+var realtimeMechanisms = []realtimeMechanism{
+	{id: "sse", path: "/api/events"},
+	{id: "ws", path: "/api/ws"},
+	{id: "none"},
+}
+
+// lookupRealtime resolves a --realtime value; "" selects none.
+func lookupRealtime(id string) (*realtimeMechanism, error) {
+	if id == "" {
+		id = "none"
+	}
+	var ids []string
+	for i := range realtimeMechanisms {
+		if realtimeMechanisms[i].id == id {
+			return &realtimeMechanisms[i], nil
+		}
+		ids = append(ids, realtimeMechanisms[i].id)
+	}
+	return nil, fmt.Errorf("unknown realtime mechanism %q (supported: %s)", id, strings.Join(ids, ", "))
+}
+
+// resolveRealtime checks --realtime against the template and server
+// language. The endpoints are part of the full C server, so templates
+// without them and the C++ server fall back to none unless --realtime was
+// given explicitly.
+func resolveRealtime(cmd *cobra.Command, variant *projectVariant) error {
+	mechanism, err := lookupRealtime(createOpts.realtime)
+	if err != nil {
+		return err
+	}
+	createOpts.realtime = mechanism.id
+	if mechanism.id == "none" || (variant.supports("realtime") && createOpts.backend == "c") {
+		return nil
+	}
+	if cmd.Flags().Changed("realtime") {
+		return fmt.Errorf("--realtime %s needs --backend c", mechanism.id)
+	}
+	createOpts.realtime = "none"
+	return nil
+}
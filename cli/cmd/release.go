@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// missingStaticLib finds the libraries a static link could not find, in the
+// wording of GNU ld, lld and the macOS linker.
+var missingStaticLib = regexp.MustCompile(`(?:cannot find|unable to find library|library not found for) -l([\w+.-]+)`)
+
+// explainStaticLink sums up a server build that failed because --static
+// asked for static libraries the toolchain does not have, and returns nil
+// for any other failure.
+func explainStaticLink(log []byte) error {
+	var libs []string
+	seen := map[string]bool{}
+	for _, m := range missingStaticLib.FindAllSubmatch(log, -1) {
+		if lib := string(m[1]); !seen[lib] {
+			seen[lib] = true
+			libs = append(libs, lib)
+		}
+	}
+	if len(libs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("static linking failed: no static library for %s; install the static versions (lib%s.a) or build without --static, and pass --verbose to see the linker output",
+		strings.Join(libs, ", "), strings.Join(libs, ".a, lib"))
+}
+
+// stripBinary removes the symbols from the binary at path, with the strip of
+// the cross toolchain for triple when it is on PATH.
+func stripBinary(path, triple string) error {
+	tool := "strip"
+	if triple != "" {
+		if _, err := exec.LookPath(triple + "-strip"); err == nil {
+			tool = triple + "-strip"
+		}
+	}
+	return quietUnlessVerbose(stdOutput, func(out cmdOutput) error {
+		return runIn(out, ".", tool, path)
+	})
+}
+
+// printSizeReport lists the size of each artifact, in order, next to how it
+// changed since the build that recorded previous.
+func printSizeReport(order []string, sizes, previous map[string]int64) {
+	width := 0
+	for _, name := range order {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	fmt.Println("Sizes:")
+	for _, name := range order {
+		size := sizes[name]
+		change := "new"
+		if before, ok := previous[name]; ok {
+			switch diff := size - before; {
+			case diff == 0:
+				change = "unchanged"
+			case diff > 0:
+				change = "+" + formatSize(diff)
+			default:
+				change = "-" + formatSize(-diff)
+			}
+		}
+		fmt.Printf("  %-*s  %10s  (%s)\n", width, name, formatSize(size), change)
+	}
+}
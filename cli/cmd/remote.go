@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// remoteManifest is the optional file at the root of a remote template
+// repository. It is read into projectVariant and never copied into the
+// project.
+const remoteManifest = "reavix-template.json"
+
+// templateVariable is a value a remote template needs beyond ProjectData.
+// Templates read it as {{.Vars.name}}.
+type templateVariable struct {
+	Name     string `json:"name"`
+	Prompt   string `json:"prompt"`
+	Default  string `json:"default"`
+	Required bool   `json:"required"`
+}
+
+// isRemoteTemplate reports whether a --template value names a git repository
+// rather than a built-in variant.
+func isRemoteTemplate(name string) bool {
+	for _, prefix := range []string{"https://", "http://", "ssh://", "git@", "file://"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return strings.HasSuffix(name, ".git")
+}
+
+// templateCacheDir is where remote templates are cloned,
+// ~/.cache/reavix/templates on Linux.
+func templateCacheDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "reavix", "templates"), nil
+}
+
+var unsafeCacheChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// fetchRemoteTemplate returns the local clone of url, cloning it shallowly
+// when it is not cached yet or --refresh was given.
+func fetchRemoteTemplate(url string) (string, error) {
+	cacheDir, err := templateCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate the template cache: %w", err)
+	}
+	key := strings.TrimSuffix(url, ".git")
+	if i := strings.Index(key, "://"); i >= 0 {
+		key = key[i+3:]
+	}
+	dir := filepath.Join(cacheDir, strings.Trim(unsafeCacheChars.ReplaceAllString(key, "_"), "_."))
+
+	if fileExists(dir) && !createOpts.refresh {
+		return dir, nil
+	}
+	if !gitAvailable() {
+		return "", fmt.Errorf("git is required to fetch template %s", url)
+	}
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", err
+	}
+
+	// Clone next to the cache entry and swap it in, so a failed refresh
+	// keeps the previous copy usable.
+	tmp, err := os.MkdirTemp(cacheDir, ".clone-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	fmt.Printf("Fetching template %s\n", url)
+	clone := exec.Command("git", "clone", "--depth", "1", "--quiet", url, tmp)
+	clone.Stdout = os.Stdout
+	clone.Stderr = os.Stderr
+	if err := clone.Run(); err != nil {
+		return "", fmt.Errorf("failed to clone template %s: %w", url, err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// loadRemoteVariant clones url and turns its contents into a variant: every
+// file except the git metadata and the manifest is scaffolded, with .tmpl
+// files rendered and the suffix dropped.
+func loadRemoteVariant(url string) (*projectVariant, error) {
+	dir, err := fetchRemoteTemplate(url)
+	if err != nil {
+		return nil, err
+	}
+	return loadTemplateDir(url, dir)
+}
+
+// loadTemplateDir reads a template laid out like a remote repository from
+// dir. id names it in messages.
+func loadTemplateDir(id, dir string) (*projectVariant, error) {
+	variant := &projectVariant{id: id, fsys: os.DirFS(dir)}
+	if raw, err := os.ReadFile(filepath.Join(dir, remoteManifest)); err == nil {
+		if err := json.Unmarshal(raw, variant); err != nil {
+			return nil, fmt.Errorf("template %s: invalid %s: %w", id, remoteManifest, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if variant.Description == "" {
+		variant.Description = "Template " + id
+	}
+	if err := variant.validate(id); err != nil {
+		return nil, err
+	}
+	for _, v := range variant.Variables {
+		if v.Name == "" {
+			return nil, fmt.Errorf("template %s: %s declares a variable without a name", id, remoteManifest)
+		}
+	}
+
+	err := fs.WalkDir(variant.fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return fs.SkipDir
+			}
+			if p != "." {
+				variant.Dirs = append(variant.Dirs, p)
+			}
+			return nil
+		}
+		if p == remoteManifest || !d.Type().IsRegular() {
+			return nil
+		}
+		target := strings.TrimSuffix(p, ".tmpl")
+		variant.Files = append(variant.Files, variantFile{
+			Path:     target,
+			Template: p,
+			raw:      target == p,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", id, err)
+	}
+	return variant, nil
+}
+
+// resolveTemplateVars fills in the variables variant declares, starting from
+// the --var values. Missing values are asked for in a terminal and otherwise
+// fall back to their defaults; a required variable without any value is an
+// error.
+func resolveTemplateVars(variant *projectVariant, given map[string]string) (map[string]string, error) {
+	vars := map[string]string{}
+	for k, v := range given {
+		vars[k] = v
+	}
+
+	var p *prompter
+	for _, v := range variant.Variables {
+		if _, ok := vars[v.Name]; ok {
+			continue
+		}
+		if isInteractive() {
+			if p == nil {
+				p = newPrompter()
+			}
+			label := v.Prompt
+			if label == "" {
+				label = v.Name
+			}
+			var validate func(string) error
+			if v.Required {
+				validate = func(answer string) error {
+					if answer == "" {
+						return fmt.Errorf("%s is required", v.Name)
+					}
+					return nil
+				}
+			}
+			answer, err := p.askString(label, v.Default, validate)
+			if err != nil {
+				return nil, err
+			}
+			vars[v.Name] = answer
+			continue
+		}
+		if v.Default == "" && v.Required {
+			return nil, fmt.Errorf("template variable %s is required (pass --var %s=VALUE)", v.Name, v.Name)
+		}
+		vars[v.Name] = v.Default
+	}
+	return vars, nil
+}
+
+// postCreateCommands returns the remote template's post-create steps to run
+// in projectDir. Each entry is split on whitespace and run directly, not
+// through a shell.
+func postCreateCommands(variant *projectVariant, projectDir string) []*exec.Cmd {
+	var cmds []*exec.Cmd
+	for _, line := range variant.PostCreate {
+		args := strings.Fields(line)
+		if len(args) == 0 {
+			continue
+		}
+		c := exec.Command(args[0], args[1:]...)
+		c.Dir = projectDir
+		cmds = append(cmds, c)
+	}
+	return cmds
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Reavix-framework/cli/internal/preflight"
+)
+
+// buildReportSchema is the schemaVersion of the --report document. It goes
+// up when a field is removed or changes meaning; adding one keeps it.
+const buildReportSchema = 1
+
+// The phases of a build, as timed by buildPhases.
+const (
+	phaseHooks     = "hooks"
+	phaseFrontend  = "frontend build"
+	phaseEmbed     = "embed assets"
+	phaseConfigure = "cmake configure"
+	phaseCompile   = "compile"
+	phaseCopy      = "copy"
+	phaseStrip     = "strip"
+	phaseCompress  = "compress"
+)
+
+// buildReport is the document --report writes. Status is "success" or
+// "failure", with the exit status and error of the build; Warnings counts
+// the compiler warnings of the server build and Toolchain maps each tool the
+// project builds with to its version, "" when it is not installed.
+type buildReport struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	Status        string            `json:"status"`
+	ExitCode      int               `json:"exitCode"`
+	Error         string            `json:"error,omitempty"`
+	CLIVersion    string            `json:"cliVersion"`
+	Build         *buildInfo        `json:"build,omitempty"`
+	StartedAt     string            `json:"startedAt"`
+	Seconds       float64           `json:"seconds"`
+	Phases        []phaseTime       `json:"phases"`
+	Artifacts     []reportArtifact  `json:"artifacts"`
+	Toolchain     map[string]string `json:"toolchain,omitempty"`
+	Warnings      int               `json:"warnings"`
+}
+
+// phaseTime is how long one phase of a build took, in total.
+type phaseTime struct {
+	Name    string  `json:"name"`
+	Seconds float64 `json:"seconds"`
+}
+
+// reportArtifact is a file or directory build put in the output directory.
+type reportArtifact struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// finish records the outcome of a build that started at started.
+func (r *buildReport) finish(err error, started time.Time) {
+	r.Seconds = time.Since(started).Seconds()
+	r.Phases = buildPhases.phases()
+	if err == nil {
+		r.Status = "success"
+		return
+	}
+	r.Status, r.Error, r.ExitCode = "failure", err.Error(), exitFailure
+	var exit *exitError
+	if errors.As(err, &exit) {
+		r.ExitCode = exit.code
+	}
+}
+
+func (r *buildReport) save(path string) error {
+	raw, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(raw, '\n'), 0644)
+}
+
+// toolchainVersions looks up the version of each of tools.
+func toolchainVersions(tools []preflight.Tool) map[string]string {
+	versions := map[string]string{}
+	for _, t := range tools {
+		if _, ok := versions[t.Name]; !ok {
+			versions[t.Name] = preflight.Version(t)
+		}
+	}
+	return versions
+}
+
+// phaseTimer adds up the time spent in each phase of a build. Phases run
+// more than once, like the compile of every target triple, add up under
+// one name; the frontend and the server build may time phases at once.
+type phaseTimer struct {
+	mu    sync.Mutex
+	names []string
+	spent map[string]time.Duration
+}
+
+// buildPhases times the phases of the running build.
+var buildPhases phaseTimer
+
+func (t *phaseTimer) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.names, t.spent = nil, nil
+}
+
+// track runs step and adds its duration to the phase name.
+func (t *phaseTimer) track(name string, step func() error) error {
+	start := time.Now()
+	err := step()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.spent == nil {
+		t.spent = map[string]time.Duration{}
+	}
+	if _, ok := t.spent[name]; !ok {
+		t.names = append(t.names, name)
+	}
+	t.spent[name] += time.Since(start)
+	return err
+}
+
+// phases lists the timed phases in the order they first ran.
+func (t *phaseTimer) phases() []phaseTime {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	phases := []phaseTime{}
+	for _, name := range t.names {
+		phases = append(phases, phaseTime{Name: name, Seconds: t.spent[name].Seconds()})
+	}
+	return phases
+}
+
+// printTimings prints the timing summary of a build that took total.
+// Phases the two halves ran side by side overlap, so they can add up to
+// more than the total.
+func printTimings(phases []phaseTime, total time.Duration) {
+	width := len("total")
+	for _, p := range phases {
+		if len(p.Name) > width {
+			width = len(p.Name)
+		}
+	}
+	fmt.Println("Timings:")
+	for _, p := range phases {
+		fmt.Printf("  %-*s  %7.1fs\n", width, p.Name, p.Seconds)
+	}
+	fmt.Printf("  %-*s  %7.1fs\n", width, "total", total.Seconds())
+}
+
+// warningCounter counts the compiler warnings, lines with ": warning:" as
+// GCC and Clang print them, in the output written through it.
+type warningCounter struct {
+	mu    sync.Mutex
+	count int
+}
+
+// wrap returns out with both streams counted. Streams sharing a writer
+// keep sharing one, which exec.Cmd relies on to copy them in order.
+func (c *warningCounter) wrap(out cmdOutput) cmdOutput {
+	stdout := &countingWriter{w: out.stdout, counter: c}
+	if out.stderr == out.stdout {
+		return cmdOutput{stdout: stdout, stderr: stdout}
+	}
+	return cmdOutput{stdout: stdout, stderr: &countingWriter{w: out.stderr, counter: c}}
+}
+
+func (c *warningCounter) total() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.count
+}
+
+// countingWriter passes its writes on to w, counting the warning lines in
+// them; a partial line is held until its newline arrives.
+type countingWriter struct {
+	w       io.Writer
+	counter *warningCounter
+	partial []byte
+}
+
+func (cw *countingWriter) Write(b []byte) (int, error) {
+	cw.partial = append(cw.partial, b...)
+	for {
+		i := bytes.IndexByte(cw.partial, '\n')
+		if i < 0 {
+			break
+		}
+		if bytes.Contains(cw.partial[:i], []byte(": warning:")) {
+			cw.counter.mu.Lock()
+			cw.counter.count++
+			cw.counter.mu.Unlock()
+		}
+		cw.partial = cw.partial[i+1:]
+	}
+	return cw.w.Write(b)
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	utils "github.com/Reavix-framework/cli/internal/utils"
+)
+
+// createdPaths records every file and directory createProject makes so a
+// failed run can be undone without touching anything that existed before.
+type createdPaths struct {
+	paths   []string
+	backups []backupCopy
+}
+
+// backupCopy pairs an overwritten file with the copy of its original content.
+type backupCopy struct {
+	path string
+	copy string
+}
+
+// mkdirAll behaves like os.MkdirAll but remembers each directory level that
+// did not exist beforehand.
+func (c *createdPaths) mkdirAll(dir string) error {
+	var missing []string
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		if _, err := os.Lstat(d); err == nil {
+			break
+		}
+		missing = append(missing, d)
+		if parent := filepath.Dir(d); parent == d {
+			break
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		c.paths = append(c.paths, missing[i])
+	}
+	return nil
+}
+
+// create opens path for writing, recording it when it is a new file.
+func (c *createdPaths) create(path string) (*os.File, error) {
+	if err := c.mkdirAll(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	_, statErr := os.Lstat(path)
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if os.IsNotExist(statErr) {
+		c.paths = append(c.paths, path)
+	}
+	return file, nil
+}
+
+// backup copies path to dst before it gets overwritten so undo can put the
+// original back.
+func (c *createdPaths) backup(path, dst string) error {
+	if err := c.mkdirAll(filepath.Dir(dst)); err != nil {
+		return err
+	}
+	if err := utils.CopyFile(path, dst); err != nil {
+		return err
+	}
+	c.paths = append(c.paths, dst)
+	c.backups = append(c.backups, backupCopy{path: path, copy: dst})
+	return nil
+}
+
+// undo restores overwritten files, then removes the recorded paths in
+// reverse creation order. Directories created by us are removed with their
+// contents, which also cleans up files written by child processes such as npm.
+func (c *createdPaths) undo() error {
+	var firstErr error
+	for i := len(c.backups) - 1; i >= 0; i-- {
+		b := c.backups[i]
+		if err := utils.CopyFile(b.copy, b.path); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	c.backups = nil
+
+	for i := len(c.paths) - 1; i >= 0; i-- {
+		if err := os.RemoveAll(c.paths[i]); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	c.paths = nil
+	return firstErr
+}
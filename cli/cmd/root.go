@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"github.com/spf13/cobra"
@@ -9,13 +10,48 @@ import (
 var (
 	version = "0.1.0"
 	verbose bool
+	noColor bool
 )
 
+// Exit codes of build, dev and run, so scripts can tell which step failed.
+// Every other error exits with exitFailure. run exits like a shell does: with
+// exitNotBuilt when there is no server binary to run, exitNotRunnable when
+// it cannot be started, and the server's own exit status otherwise.
+const (
+	exitFailure       = 1
+	exitFrontendBuild = 2
+	exitBackendBuild  = 3
+	exitArtifactCopy  = 4
+	exitNotRunnable   = 126
+	exitNotBuilt      = 127
+)
+
+// exitError is an error that ends the CLI with a specific exit code.
+// Execute does not print one that was reported already, in a box of its
+// own for example.
+type exitError struct {
+	code     int
+	err      error
+	reported bool
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode wraps err in context, formatted like fmt.Errorf with err as
+// the last argument, and tags it with code.
+func withExitCode(code int, err error, format string, args ...interface{}) error {
+	return &exitError{code: code, err: fmt.Errorf(format, append(args, err)...)}
+}
+
 var rootCmd = &cobra.Command{
 	Use: "reavix",
 	Version: version,
 	Short: "Reavix CLI tool",
 	Long: "A CLI tool for managing Reavix applications\nComplete documentation at: github.com/Reavix-framework/cli",
+	// Execute prints the error once; usage is still shown for bad flags
+	// and arguments.
+	SilenceErrors: true,
 	PersistentPreRun: func(cmd *cobra.Command, args []string){
 		if verbose {
 			fmt.Println("Debug mode enabled")
@@ -25,11 +61,19 @@ var rootCmd = &cobra.Command{
 
 func Execute(){
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		var exit *exitError
+		isExit := errors.As(err, &exit)
+		if !isExit || !exit.reported {
+			fmt.Printf("Error: %v\n", err)
+		}
+		if isExit {
+			os.Exit(exit.code)
+		}
+		os.Exit(exitFailure)
 	}
 }
 
 func init(){
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Don't color the output, as when NO_COLOR is set")
 }
\ No newline at end of file
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// clientRouter is a --router choice. module is the package the scaffold
+// imports RouterProvider, Link and Outlet from; it is empty for none.
+type clientRouter struct {
+	id     string
+	module string
+	deps   map[string]string
+}
+
+var clientRouters = []clientRouter{
+	{id: "none"},
+	{id: "react-router", module: "react-router-dom", deps: map[string]string{"react-router-dom": "6.28.0"}},
+	{id: "tanstack", module: "@tanstack/react-router", deps: map[string]string{"@tanstack/react-router": "1.81.5"}},
+}
+
+// lookupRouter resolves a --router value; "" selects none.
+func lookupRouter(id string) (*clientRouter, error) {
+	if id == "" {
+		id = "none"
+	}
+	var ids []string
+	for i := range clientRouters {
+		if clientRouters[i].id == id {
+			return &clientRouters[i], nil
+		}
+		ids = append(ids, clientRouters[i].id)
+	}
+	return nil, fmt.Errorf("unknown router %q (supported: %s)", id, strings.Join(ids, ", "))
+}
+
+// resolveRouter checks --router against the chosen frontend. Both routers
+// are scaffolded with their React bindings, so they need --frontend react.
+func resolveRouter() error {
+	router, err := lookupRouter(createOpts.router)
+	if err != nil {
+		return err
+	}
+	createOpts.router = router.id
+	if router.id != "none" && frontendID() != "react" {
+		return fmt.Errorf("--router %s needs --frontend react", router.id)
+	}
+	return nil
+}
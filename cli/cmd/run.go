@@ -1,32 +1,583 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
 	
+	"github.com/Reavix-framework/cli/internal/project"
 	"github.com/spf13/cobra"
 )
 
+var runOpts struct {
+	tlsCert  string
+	tlsKey   string
+	out      string
+	verify   bool
+	mode     string
+	envFiles []string
+	logDir   string
+
+	port      int
+	env       []string
+	staticDir string
+	detach    bool
+
+	supervise     bool
+	alwaysRestart bool
+	maxRestarts   int
+
+	health        string
+	healthTimeout time.Duration
+	noHealth      bool
+
+	shutdownTimeout time.Duration
+}
+
 var runCmd = &cobra.Command{
 	Use: "run",
 	Short: "Run Reavix application",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("Starting production server...")
+	Long: "Run the server binary `reavix build` put in the output directory, with the variables of the\n" +
+		".env files of --mode and of --env, listening on REAVIX_PORT. SIGINT, SIGTERM and SIGHUP are\n" +
+		"passed on to the process group of the server; with run.restartOnHup of reavix.json, SIGHUP\n" +
+		"restarts it instead.\n\n" +
+		"Exit status: the server's own, or 128+N when signal N killed it, as a shell reports it, so\n" +
+		"systemd and container runtimes see how it ended; 127 when the server binary does not exist,\n" +
+		"126 when it cannot be started, 1 for any other error.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkInspect(); err != nil {
+			return err
+		}
+		// The supervisor of --supervise --detach is run started again, in the
+		// background, where it supervises the server rather than detach.
+		detached := os.Getenv(detachedRunEnv) != ""
+		os.Unsetenv(detachedRunEnv)
+		detach := runOpts.detach && !detached
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		// Resolve the certificate paths before requireProject changes into
+		// the project root.
+		env, err := tlsEnv()
+		if err != nil {
+			return err
+		}
+		flagOut, err := absPathFlag(runOpts.out)
+		if err != nil {
+			return err
+		}
+		envFiles, err := absPathFlags(runOpts.envFiles)
+		if err != nil {
+			return err
+		}
+		staticDir, err := absPathFlag(runOpts.staticDir)
+		if err != nil {
+			return err
+		}
+		flagEnv, err := parseEnvFlags(runOpts.env)
+		if err != nil {
+			return err
+		}
+		if cmd.Flags().Changed("port") && (runOpts.port < 1 || runOpts.port > 65535) {
+			return fmt.Errorf("invalid --port %d: want 1-65535", runOpts.port)
+		}
+		logDir := defaultLogDir
+		if cmd.Flags().Changed("log-dir") {
+			if logDir, err = absPathFlag(runOpts.logDir); err != nil {
+				return err
+			}
+		}
+		if runOpts.detach && logDir == "" {
+			return fmt.Errorf("--detach needs a --log-dir for the output of the server")
+		}
+		if (runOpts.alwaysRestart || cmd.Flags().Changed("max-restarts")) && !runOpts.supervise {
+			return fmt.Errorf("--always-restart and --max-restarts need --supervise")
+		}
+		if runOpts.maxRestarts < 0 {
+			return fmt.Errorf("invalid --max-restarts %d: use a number of restarts, or 0 for no limit", runOpts.maxRestarts)
+		}
+		if runOpts.noHealth && (cmd.Flags().Changed("health") || cmd.Flags().Changed("health-timeout")) {
+			return fmt.Errorf("--no-health cannot be combined with --health or --health-timeout")
+		}
+		if runOpts.healthTimeout <= 0 {
+			return fmt.Errorf("invalid --health-timeout %s: want a positive duration", runOpts.healthTimeout)
+		}
+		if detached {
+			// Its output is the log already, and the run that started it
+			// polls the health path.
+			logDir = ""
+		}
+		if !buildMode.MatchString(runOpts.mode) {
+			return fmt.Errorf("invalid --mode %q: use letters, digits, '.', '_' and '-'", runOpts.mode)
+		}
+		m, err := requireProject()
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		outDir := m.OutDir
+		if flagOut != "" {
+			outDir = flagOut
+		}
+		if !m.HasBackend() {
+			fmt.Printf("This project has no server; serve %s with any static file server.\n", displayPath(filepath.Join(outDir, "static")))
+			return nil
+		}
+		if env != nil && !m.TLS {
+			return fmt.Errorf("--tls-cert and --tls-key need a server created with `reavix create --tls`")
+		}
+		vars, port, err := resolveRunEnv(m, envFiles, flagEnv, env, staticDir)
+		if err != nil {
+			return err
+		}
+		if inspectFormat != "" {
+			return inspectRun(newResolvedConfig(cmd, m), m, outDir, envFiles, vars, port, logDir)
+		}
+		cmd.SilenceUsage = true
+		binary := filepath.Join(outDir, m.Binary)
+		if !fileExists(binary) {
+			return &exitError{code: exitNotBuilt, err: fmt.Errorf("%s does not exist; did you run `reavix build`?", displayPath(binary))}
+		}
+		if runOpts.verify {
+			if err := checkArtifacts(outDir); err != nil {
+				return err
+			}
+		}
+		if detach {
+			d, err := runningDaemon()
+			if err != nil {
+				return err
+			}
+			if d != nil {
+				return fmt.Errorf("the server runs already, as pid %d; `reavix stop` stops it", d.pid)
+			}
+		}
+		if !portFree(port) {
+			owner := portOwner(port)
+			if owner == "" {
+				owner = "another process"
+			}
+			return fmt.Errorf("port %d is in use by %s; stop it or pass --port", port, owner)
+		}
+		fmt.Printf("Starting production server on port %d...\n", port)
+		printRunEnv(vars)
 
-		cmdRun := exec.Command(filepath.Join(".","reavix-app"))
-		cmdRun.Dir = "build"
-		cmdRun.Stdout = os.Stdout
-		cmdRun.Stderr = os.Stderr
+		healthPath := runOpts.health
+		if healthPath == "" {
+			healthPath = m.HealthPath()
+		}
+		healthURL := runHealthURL(healthPath, port, env != nil)
 
-		if err := cmdRun.Run(); err != nil {
-			fmt.Println("Error running application: %v\n", err)
+		serverEnv := append(os.Environ(), childEnv(vars)...)
+		if detach {
+			name := "server"
+			c := exec.Command("." + string(filepath.Separator) + m.Binary)
+			c.Dir, c.Env = outDir, serverEnv
+			if runOpts.supervise {
+				self, err := os.Executable()
+				if err != nil {
+					return err
+				}
+				name = "supervisor"
+				c = exec.Command(self, os.Args[1:]...)
+				c.Dir, c.Env = wd, append(os.Environ(), detachedRunEnv+"=1")
+			}
+			d, err := detachServer(c, name, m, port, logDir)
+			if err != nil || runOpts.noHealth {
+				return err
+			}
+			return awaitDetachedHealthy(d, healthURL, runOpts.healthTimeout, runOpts.shutdownTimeout)
 		}
-	
+		stdout, stderr := io.Writer(os.Stdout), io.Writer(os.Stderr)
+		tail := &outputTail{}
+		if !runOpts.noHealth && !detached {
+			stdout, stderr = io.MultiWriter(stdout, tail), io.MultiWriter(stderr, tail)
+		}
+		if logDir != "" {
+			if l := startSessionLogs(logDir, "run", []string{"server"}, time.Now(), m)["server"]; l != nil {
+				logOut, logErr := l.writer(), l.writer()
+				stdout, stderr = io.MultiWriter(stdout, logOut), io.MultiWriter(stderr, logErr)
+				defer func() {
+					logOut.Flush()
+					logErr.Flush()
+					l.close()
+				}()
+			}
+		}
+		newServer := func() *exec.Cmd {
+			// filepath.Join would drop the "./" exec needs to run the
+			// binary from the output directory rather than look it up in
+			// PATH.
+			c := exec.Command("." + string(filepath.Separator) + m.Binary)
+			c.Dir, c.Env, c.Stdout, c.Stderr = outDir, serverEnv, stdout, stderr
+			// In a process group of its own, the server gets a Ctrl+C
+			// once, from run, rather than from the terminal too. A
+			// detached supervisor has no terminal, and keeps the server in
+			// its group for `reavix stop` to kill them together.
+			if !detached {
+				ownProcessGroup(c)
+			}
+			return c
+		}
+		var probe func(done <-chan struct{}) error
+		healthy := make(chan struct{})
+		if !runOpts.noHealth && !detached {
+			timeout := runOpts.healthTimeout
+			probe = func(done <-chan struct{}) error {
+				err := awaitHealthy(healthURL, timeout, done)
+				if err == nil {
+					close(healthy)
+				}
+				return err
+			}
+		}
+		err = superviseServer(newServer, supervision{
+			binary:       displayPath(binary),
+			timeout:      runOpts.shutdownTimeout,
+			restartOnHup: m.Run != nil && m.Run.RestartOnHup,
+			ownGroup:     !detached,
+			supervise:    runOpts.supervise,
+			always:       runOpts.alwaysRestart,
+			maxRestarts:  runOpts.maxRestarts,
+			log:          stderr,
+			probe:        probe,
+		})
+		// A server that never got healthy, as it exited or did not answer,
+		// is shown with its last lines; one stopped on request is not.
+		var exit *exitError
+		if probe != nil && err != nil && !(errors.As(err, &exit) && exit.reported) {
+			select {
+			case <-healthy:
+			default:
+				printTail("its output", tail.last())
+			}
+		}
+		return err
 	},
 }
 
+// restartWindow is the span --max-restarts counts the restarts of
+// --supervise in.
+const restartWindow = 10 * time.Minute
+
+// maxRestartDelay caps the wait before a restart of --supervise, which
+// starts at a second and doubles each time the server crashes again.
+const maxRestartDelay = 30 * time.Second
+
+// restartReset is how long a server has to run for the wait before its
+// next restart to start over at a second.
+const restartReset = time.Minute
+
+// supervision is how superviseServer runs a server: binary names it in
+// errors; timeout, restartOnHup and ownGroup, whether it leads a process
+// group of its own, are about signals; supervise, always and maxRestarts
+// about restarts, which are logged to log. probe, when set, waits for the
+// server first started to answer its health path, giving up once done is
+// closed.
+type supervision struct {
+	binary       string
+	timeout      time.Duration
+	restartOnHup bool
+	ownGroup     bool
+	supervise    bool
+	always       bool
+	maxRestarts  int
+	log          io.Writer
+	probe        func(done <-chan struct{}) error
+}
+
+// resolveRunEnv returns the variables run passes on to the server of m and
+// the port it listens on. Those of the env files, as resolveProjectEnv
+// reads them, are overridden by flagEnv, of --env, and tls, the variables
+// of tlsEnv. staticDir, of --static-dir, is exported as REAVIX_STATIC_DIR
+// when set. The port, exported as REAVIX_PORT, is --port, else the
+// REAVIX_PORT those or the environment set, else ports.server of
+// reavix.json.
+func resolveRunEnv(m *project.Manifest, envFiles []string, flagEnv []envVar, tls []string, staticDir string) ([]envVar, int, error) {
+	vars, err := resolveProjectEnv(runOpts.mode, envFiles)
+	if err != nil {
+		return nil, 0, err
+	}
+	vars = mergeEnv(vars, flagEnv...)
+	for _, kv := range tls {
+		key, value, _ := strings.Cut(kv, "=")
+		vars = mergeEnv(vars, envVar{key: key, value: value, source: "--tls-cert and --tls-key"})
+	}
+	if staticDir != "" {
+		if info, err := os.Stat(staticDir); err != nil || !info.IsDir() {
+			return nil, 0, fmt.Errorf("--static-dir %s is not a directory", displayPath(staticDir))
+		}
+		vars = mergeEnv(vars, envVar{key: "REAVIX_STATIC_DIR", value: staticDir, source: "--static-dir"})
+	}
+
+	port := envVar{key: "REAVIX_PORT", value: strconv.Itoa(m.Ports.Server), source: project.FileName + " ports.server"}
+	if value, ok := os.LookupEnv("REAVIX_PORT"); ok {
+		port = envVar{key: "REAVIX_PORT", value: value, source: "environment"}
+	}
+	for _, v := range vars {
+		if v.key == "REAVIX_PORT" {
+			port = v
+		}
+	}
+	if runOpts.port != 0 {
+		port = envVar{key: "REAVIX_PORT", value: strconv.Itoa(runOpts.port), source: "--port"}
+	}
+	n, err := strconv.Atoi(port.value)
+	if err != nil || n < 1 || n > 65535 {
+		return nil, 0, fmt.Errorf("invalid REAVIX_PORT %q from %s: want 1-65535", port.value, port.source)
+	}
+	return mergeEnv(vars, port), n, nil
+}
+
+// printRunEnv prints vars, the variables run passes on to the server, with
+// where each comes from and the values of secrets masked.
+func printRunEnv(vars []envVar) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, v := range vars {
+		fmt.Fprintf(w, "  %s=%s\t%s\n", v.key, maskEnvValue(v.key, v.value), v.source)
+	}
+	w.Flush()
+}
+
+// superviseServer runs the server newServer makes until it exits, passing
+// the SIGINT, SIGTERM and SIGHUP run gets on to it, and returns how it
+// ended, as serverExit says. A server still running s.timeout after SIGINT
+// or SIGTERM is killed, unless the timeout is 0. With s.restartOnHup, SIGHUP
+// stops the server the same way instead and starts it again. With
+// s.supervise, a server that exits on its own is started again, as
+// restartDelay says, until it went s.maxRestarts times within
+// restartWindow. A server s.probe gives up on is stopped like on SIGTERM,
+// and run ends with the error of the probe, which is stopped and waited for
+// however superviseServer returns.
+func superviseServer(newServer func() *exec.Cmd, s supervision) error {
+	// run outlives the server to exit as it did, so the signals that would
+	// stop run go to the server instead.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+	var restarts []time.Time
+	delay := time.Duration(0)
+	var unhealthy chan error
+	var probeErr error
+	done := make(chan struct{})
+	var probing sync.WaitGroup
+	defer func() {
+		close(done)
+		probing.Wait()
+	}()
+	for {
+		c := newServer()
+		if err := c.Start(); err != nil {
+			return withExitCode(exitNotRunnable, err, "starting %s: %w", s.binary)
+		}
+		if s.probe != nil && unhealthy == nil {
+			unhealthy = make(chan error, 1)
+			probing.Add(1)
+			go func() {
+				defer probing.Done()
+				if err := s.probe(done); err != nil {
+					unhealthy <- err
+				}
+			}()
+		}
+		started := time.Now()
+		exited := make(chan error, 1)
+		go func() { exited <- c.Wait() }()
+
+		var deadline <-chan time.Time
+		stopped, restarting := false, false
+	wait:
+		for {
+			select {
+			case sig := <-signals:
+				switch {
+				case sig == syscall.SIGHUP && s.restartOnHup:
+					if !restarting {
+						fmt.Println("Restarting the server, as run got SIGHUP...")
+					}
+					restarting, sig = true, syscall.SIGTERM
+				case sig != syscall.SIGHUP:
+					stopped = true
+				}
+				forwardSignal(c.Process, s.ownGroup, sig)
+				// A SIGHUP passed on may only make the server reload, so
+				// it is not waited on to stop.
+				if deadline == nil && s.timeout > 0 && sig != syscall.SIGHUP {
+					deadline = time.After(s.timeout)
+				}
+			case probeErr = <-unhealthy:
+				fmt.Fprintln(os.Stderr, "Stopping the server, as it is not healthy...")
+				stopped = true
+				signalProcess(c.Process, s.ownGroup, syscall.SIGTERM)
+				if deadline == nil && s.timeout > 0 {
+					deadline = time.After(s.timeout)
+				}
+			case <-deadline:
+				fmt.Fprintf(os.Stderr, "Warning: the server did not stop within %s, so it was killed.\n", s.timeout)
+				signalProcess(c.Process, s.ownGroup, syscall.SIGKILL)
+			case err := <-exited:
+				if probeErr != nil {
+					return probeErr
+				}
+				if restarting && !stopped {
+					break wait
+				}
+				if stopped || !s.supervise || err == nil && !s.always {
+					return serverExit(err, stopped)
+				}
+				restarts = append(restarts, time.Now())
+				for len(restarts) > 0 && time.Since(restarts[0]) > restartWindow {
+					restarts = restarts[1:]
+				}
+				if s.maxRestarts > 0 && len(restarts) > s.maxRestarts {
+					logSupervisor(s.log, "error", "the server keeps exiting; giving up", append(exitFields(err),
+						"restarts", s.maxRestarts, "window", restartWindow)...)
+					return serverExit(err, false)
+				}
+				delay = restartDelay(delay, time.Since(started))
+				logSupervisor(s.log, "warn", "the server exited; restarting it", append(exitFields(err),
+					"restart", len(restarts), "backoff", delay)...)
+				select {
+				case <-time.After(delay):
+				case sig := <-signals:
+					// The server is down already; SIGHUP only restarts it
+					// sooner.
+					if sig != syscall.SIGHUP {
+						return serverExit(err, true)
+					}
+				case err := <-unhealthy:
+					return err
+				}
+				break wait
+			}
+		}
+	}
+}
+
+// restartDelay is how long --supervise waits before it restarts a server
+// that ran for ran, having waited last before the previous restart: a
+// second at first and after a server ran for restartReset, else twice the
+// last, up to maxRestartDelay.
+func restartDelay(last, ran time.Duration) time.Duration {
+	if last == 0 || ran >= restartReset {
+		return time.Second
+	}
+	if last *= 2; last > maxRestartDelay {
+		return maxRestartDelay
+	}
+	return last
+}
+
+// exitFields are the logfmt fields of how the server ended, err from
+// waiting for it: its exit status, or the signal that killed it.
+func exitFields(err error) []interface{} {
+	var exit *exec.ExitError
+	if err == nil || !errors.As(err, &exit) {
+		return []interface{}{"exit_code", 0}
+	}
+	if status, ok := exit.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return []interface{}{"signal", int(status.Signal()), "signal_name", status.Signal().String()}
+	}
+	return []interface{}{"exit_code", exit.ExitCode()}
+}
+
+// logSupervisor writes a line of --supervise to w in logfmt, for log
+// collectors to take apart: the time, level and msg, then kv, keys each
+// followed by their value.
+func logSupervisor(w io.Writer, level, msg string, kv ...interface{}) {
+	line := fmt.Sprintf("time=%s level=%s msg=%s", time.Now().Format(time.RFC3339), level, logfmtValue(msg))
+	for i := 0; i+1 < len(kv); i += 2 {
+		line += fmt.Sprintf(" %v=%s", kv[i], logfmtValue(fmt.Sprint(kv[i+1])))
+	}
+	fmt.Fprintln(w, line)
+}
+
+// logfmtValue quotes v when logfmt needs it to.
+func logfmtValue(v string) string {
+	if v == "" || strings.ContainsAny(v, " \t\"=\\") {
+		return strconv.Quote(v)
+	}
+	return v
+}
+
+// serverExit turns how the server of run ended, err from waiting for it,
+// into the error run exits with: nil when it exited with status 0, else
+// one with its exit status, or 128+N when signal N killed it. A server
+// stopped on request, by stopped, is not reported as an error, but still
+// exits run as it did.
+func serverExit(err error, stopped bool) error {
+	var exit *exec.ExitError
+	if !errors.As(err, &exit) {
+		return err
+	}
+	if status, ok := exit.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return &exitError{
+			code:     128 + int(status.Signal()),
+			err:      fmt.Errorf("the server was killed by signal %d (%v)", int(status.Signal()), status.Signal()),
+			reported: stopped,
+		}
+	}
+	return &exitError{code: exit.ExitCode(), err: fmt.Errorf("the server exited with status %d", exit.ExitCode()), reported: stopped}
+}
+
+// tlsEnv turns --tls-cert and --tls-key into the environment variables a
+// --tls server reads its certificate from. The paths are made absolute
+// since the server runs in the output directory.
+func tlsEnv() ([]string, error) {
+	if runOpts.tlsCert == "" && runOpts.tlsKey == "" {
+		return nil, nil
+	}
+	if runOpts.tlsCert == "" || runOpts.tlsKey == "" {
+		return nil, fmt.Errorf("--tls-cert and --tls-key must be given together")
+	}
+
+	var env []string
+	for _, v := range []struct{ name, path string }{
+		{"REAVIX_TLS_CERT", runOpts.tlsCert},
+		{"REAVIX_TLS_KEY", runOpts.tlsKey},
+	} {
+		abs, err := filepath.Abs(v.path)
+		if err != nil {
+			return nil, err
+		}
+		if !fileExists(abs) {
+			return nil, fmt.Errorf("%s does not exist", v.path)
+		}
+		env = append(env, v.name+"="+abs)
+	}
+	return env, nil
+}
+
 func init(){
+	runCmd.Flags().StringVarP(&runOpts.out, "out", "o", "", "Directory `reavix build --out` put the server in (default: outDir in reavix.json, build)")
+	runCmd.Flags().StringVar(&runOpts.tlsCert, "tls-cert", "", "PEM certificate chain for a --tls server, exported as REAVIX_TLS_CERT")
+	runCmd.Flags().BoolVar(&runOpts.verify, "verify", false, "Refuse to start unless the artifacts match the "+artifactManifestFile+" of the build")
+	runCmd.Flags().StringVar(&runOpts.mode, "mode", "production", "Mode whose .env.<mode> files at the project root are read")
+	runCmd.Flags().StringArrayVar(&runOpts.envFiles, "env-file", nil, "Read this env file instead of those at the project root (repeatable, later files win)")
+	runCmd.Flags().StringArrayVarP(&runOpts.env, "env", "e", nil, "Pass KEY=VALUE on to the server over the env files and the environment (repeatable)")
+	runCmd.Flags().IntVarP(&runOpts.port, "port", "p", 0, "Port the server listens on, exported as REAVIX_PORT (default: ports.server in reavix.json)")
+	runCmd.Flags().StringVar(&runOpts.staticDir, "static-dir", "", "Directory of static files for the server to serve, exported as REAVIX_STATIC_DIR")
+	runCmd.Flags().BoolVarP(&runOpts.detach, "detach", "d", false, "Start the server in the background, logging to --log-dir, and return; reavix status and reavix stop see to it then")
+	runCmd.Flags().BoolVar(&runOpts.supervise, "supervise", false, "Restart the server when it exits on its own, after 1s, then twice as long each time it crashes again, up to 30s")
+	runCmd.Flags().BoolVar(&runOpts.alwaysRestart, "always-restart", false, "Under --supervise, restart a server that exits with status 0 too")
+	runCmd.Flags().IntVar(&runOpts.maxRestarts, "max-restarts", 5, "Under --supervise, give up after this many restarts within 10 minutes; 0 for no limit")
+	runCmd.Flags().StringVar(&runOpts.health, "health", "", "Path polled on the server's port once it started, until it answers below 500; with --detach run returns only then (default: dev.healthPath in reavix.json, /)")
+	runCmd.Flags().DurationVar(&runOpts.healthTimeout, "health-timeout", 30*time.Second, "How long the server has to answer --health before it is stopped, its last lines shown, and run fails")
+	runCmd.Flags().BoolVar(&runOpts.noHealth, "no-health", false, "Do not wait for the server to answer --health")
+	runCmd.Flags().DurationVar(&runOpts.shutdownTimeout, "shutdown-timeout", 10*time.Second, "How long the server has to stop after SIGINT or SIGTERM before it is killed; 0 waits for it")
+	addInspectFlag(runCmd)
+	runCmd.Flags().StringVar(&runOpts.logDir, "log-dir", defaultLogDir, "Directory of the session log of the server, kept for logs.maxAgeDays of reavix.json; empty for none")
+	runCmd.Flags().StringVar(&runOpts.tlsKey, "tls-key", "", "PEM private key for a --tls server, exported as REAVIX_TLS_KEY")
 	rootCmd.AddCommand(runCmd)
 }
\ No newline at end of file
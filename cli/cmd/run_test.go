@@ -0,0 +1,114 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/Reavix-framework/cli/internal/project"
+)
+
+// writeServerProject writes a backend-only project to a temp dir whose
+// built server is the shell script server, and returns its root.
+func writeServerProject(t *testing.T, server string) string {
+	t.Helper()
+	root := t.TempDir()
+	m := project.Default()
+	m.Only = project.OnlyBackend
+	data, err := project.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, project.FileName), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	binary := filepath.Join(root, m.OutDir, m.Binary)
+	if err := os.MkdirAll(filepath.Dir(binary), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\n"+server), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestRunShowsOutputOfServerExitingBeforeHealthy(t *testing.T) {
+	// The pause keeps the two lines in order, as the server's stdout and
+	// stderr are copied apart.
+	root := writeServerProject(t, "echo booting\nsleep 0.2\necho 'fatal: no database' >&2\nexit 3\n")
+	stderr, err := runProject(t, root)
+	var exit *exitError
+	if !errors.As(err, &exit) || exit.code != 3 {
+		t.Fatalf("run = %v, want the server's exit status 3", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(root, defaultLogDir)); len(entries) == 0 {
+		t.Fatalf("no session log in %s", defaultLogDir)
+	}
+	want := "The last 2 line(s) the server wrote to its output:\n  booting\n  fatal: no database\n"
+	if !strings.Contains(stderr, want) {
+		t.Errorf("stderr = %q, want it to contain %q", stderr, want)
+	}
+}
+
+// runProject runs `reavix run` with args in the project at root and returns
+// what it wrote to stderr and its error.
+func runProject(t *testing.T, root string, args ...string) (string, error) {
+	t.Helper()
+	chdir(t, root)
+	saved := runOpts
+	t.Cleanup(func() { runOpts = saved })
+	var err error
+	_, stderr := captureOutput(t, func() {
+		rootCmd.SetArgs(append([]string{"run", "--port", strconv.Itoa(freePort(t))}, args...))
+		err = rootCmd.Execute()
+	})
+	return stderr, err
+}
+
+func TestRunExitStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		server string
+		mode   os.FileMode
+		remove bool
+		want   int
+	}{
+		{"missing binary", "exit 0\n", 0o755, true, exitNotBuilt},
+		{"not executable", "exit 0\n", 0o644, false, exitNotRunnable},
+		{"clean exit", "exit 0\n", 0o755, false, 0},
+		{"exit 3", "exit 3\n", 0o755, false, 3},
+		{"SIGTERM", "kill -TERM $$\n", 0o755, false, 128 + int(syscall.SIGTERM)},
+		{"SIGSEGV", "kill -SEGV $$\n", 0o755, false, 128 + int(syscall.SIGSEGV)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := writeServerProject(t, tt.server)
+			binary := filepath.Join(root, project.DefaultOutDir, project.DefaultBinary)
+			if tt.remove {
+				if err := os.Remove(binary); err != nil {
+					t.Fatal(err)
+				}
+			} else if err := os.Chmod(binary, tt.mode); err != nil {
+				t.Fatal(err)
+			}
+			_, err := runProject(t, root, "--no-health", "--log-dir", "")
+			code := 0
+			if err != nil {
+				code = exitFailure
+				var exit *exitError
+				if errors.As(err, &exit) {
+					code = exit.code
+				}
+			}
+			if code != tt.want {
+				t.Errorf("run exited with %d (%v), want %d", code, err, tt.want)
+			}
+		})
+	}
+}
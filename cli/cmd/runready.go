@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxHealthInterval caps the wait between two polls of the health path of
+// run, which starts at 100ms and doubles after each poll the server does
+// not answer.
+const maxHealthInterval = 2 * time.Second
+
+// runHealthURL is the URL on port run polls, at path, until the server
+// answers: over HTTPS when run gave it a certificate with --tls-cert.
+func runHealthURL(path string, port int, tls bool) string {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	scheme := "http"
+	if tls {
+		scheme = "https"
+	}
+	return scheme + "://127.0.0.1:" + strconv.Itoa(port) + path
+}
+
+// awaitHealthy polls url until the server answers it with a status below
+// 500, then prints that it is ready. It gives up with an error once timeout
+// passes, or as soon as gone is closed, as the server exited.
+func awaitHealthy(url string, timeout time.Duration, gone <-chan struct{}) error {
+	client := healthClient()
+	started := time.Now()
+	deadline := started.Add(timeout)
+	interval := 100 * time.Millisecond
+	for {
+		if status := healthStatus(client, url); status > 0 && status < 500 {
+			fmt.Printf("The server is ready: %s answered %d after %.1fs.\n", url, status, time.Since(started).Seconds())
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("the server did not answer %s with a status below 500 within %s", url, timeout)
+		}
+		select {
+		case <-gone:
+			return fmt.Errorf("the server exited before it answered %s", url)
+		case <-time.After(minDuration(interval, time.Until(deadline))):
+		}
+		if interval *= 2; interval > maxHealthInterval {
+			interval = maxHealthInterval
+		}
+	}
+}
+
+// healthStatus returns the status the server answers an HTTP GET of url
+// with, or 0 when it does not answer.
+func healthStatus(client *http.Client, url string) int {
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+// minDuration returns the shorter of a and b.
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// outputTail is a writer keeping the last devTailLines lines written to it,
+// for run to show those of a server that never answered its health path.
+type outputTail struct {
+	mu      sync.Mutex
+	lines   []string
+	partial []byte
+}
+
+func (t *outputTail) Write(b []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.partial = append(t.partial, b...)
+	for {
+		i := bytes.IndexByte(t.partial, '\n')
+		if i < 0 {
+			break
+		}
+		t.lines = keepTail(t.lines, strings.TrimSuffix(string(t.partial[:i]), "\r"))
+		t.partial = t.partial[i+1:]
+	}
+	return len(b), nil
+}
+
+// last returns the lines kept, with a line not ended yet.
+func (t *outputTail) last() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lines := append([]string(nil), t.lines...)
+	if len(t.partial) > 0 {
+		lines = keepTail(lines, string(t.partial))
+	}
+	return lines
+}
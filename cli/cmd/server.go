@@ -0,0 +1,686 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Reavix-framework/cli/internal/project"
+	utils "github.com/Reavix-framework/cli/internal/utils"
+)
+
+// CMake reads presets from these files in the server directory; create
+// writes the first, the second holds a developer's own presets.
+const (
+	cmakePresetsFile     = "CMakePresets.json"
+	cmakeUserPresetsFile = "CMakeUserPresets.json"
+)
+
+// serverBuild is the result of buildServer: dir holds the built executable
+// and buildType is the CMAKE_BUILD_TYPE it was configured with, empty when a
+// preset leaves it to CMake.
+type serverBuild struct {
+	dir       string
+	buildType string
+}
+
+// serverBuildOptions are the settings of a server build: the CMake preset
+// used with a CMakePresets.json, the CMAKE_BUILD_TYPE used without one, the
+// number of parallel compile jobs, 0 for the default of jobCount, the
+// generator, "ninja", "make" or "" for what the preset sets, whether to
+// configure even when the build directory is up to date, extra -D
+// arguments for the configure step and KEY=VALUE variables added to the
+// environment of CMake and the build tool.
+type serverBuildOptions struct {
+	preset      string
+	buildType   string
+	jobs        int
+	generator   string
+	reconfigure bool
+	defines     []string
+	env         []string
+}
+
+// cmakeGenerators maps the --generator values to CMake's generator names.
+var cmakeGenerators = map[string]string{
+	"ninja": "Ninja",
+	"make":  "Unix Makefiles",
+}
+
+// buildServer configures and compiles the server in backend. With a
+// CMakePresets.json it runs `cmake --preset` and `cmake --build --preset`
+// for opts.preset; projects without one get a plain CMake configure for
+// opts.buildType, "Debug" or "Release", followed by make or ninja in
+// backend/build.
+// A build directory configured for another build type or generator is reset
+// first, and the configure step is skipped when nothing it depends on
+// changed since the last one. The tools write to out.
+func buildServer(out cmdOutput, backend string, opts serverBuildOptions) (*serverBuild, error) {
+	if !hasCMakePresets(backend) {
+		backendDir := filepath.Join(backend, "build")
+		if err := os.MkdirAll(backendDir, 0755); err != nil {
+			return nil, err
+		}
+		if err := configureServer(out, backendDir, opts.buildType, opts.generator, opts.reconfigure, opts.env, opts.defines...); err != nil {
+			return nil, err
+		}
+		if err := linkCompileCommands(backend, backendDir); err != nil {
+			return nil, err
+		}
+		return &serverBuild{dir: backendDir, buildType: opts.buildType}, compileServer(out, backendDir, opts.jobs, opts.env)
+	}
+
+	p, err := resolvePreset(backend, opts.preset)
+	if err != nil {
+		return nil, err
+	}
+	generator := p.generator
+	configure := []string{"--preset", p.configure}
+	if opts.generator != "" {
+		// Arguments after --preset override what the preset sets.
+		generator = cmakeGenerators[opts.generator]
+		configure = append(configure, "-G", generator)
+	}
+	configure = append(configure, opts.defines...)
+	if err := resetStaleCache(out, p.binaryDir, p.buildType, generator); err != nil {
+		return nil, err
+	}
+	err = configureOnce(out, backend, p.binaryDir, opts.reconfigure, func() error {
+		if err := runCMake(out, backend, opts.env, configureArgs(configure...)...); err != nil {
+			return err
+		}
+		return linkCompileCommands(p.sourceDir, p.binaryDir)
+	}, append(configure, opts.env...)...)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"--build", p.binaryDir}
+	if p.build {
+		args = []string{"--build", "--preset", opts.preset}
+	}
+	if n := jobCount(p.binaryDir, opts.jobs); n > 0 {
+		args = append(args, "--parallel", strconv.Itoa(n))
+	}
+	if verbose {
+		// Tells make and Ninja alike to echo every compile command.
+		args = append(args, "--verbose")
+	}
+	return &serverBuild{dir: p.binaryDir, buildType: p.buildType}, buildPhases.track(phaseCompile, func() error {
+		return runInEnv(out, backend, opts.env, "cmake", args...)
+	})
+}
+
+// compileServer runs make, or ninja when buildDir was configured for it, in
+// the configured buildDir with jobs as for jobCount and env added to its
+// environment.
+func compileServer(out cmdOutput, buildDir string, jobs int, env []string) error {
+	tool := "make"
+	if usesNinja(buildDir) {
+		tool = "ninja"
+	}
+	var args []string
+	if n := jobCount(buildDir, jobs); n > 0 {
+		args = append(args, "-j"+strconv.Itoa(n))
+	}
+	if verbose && tool == "ninja" {
+		args = append(args, "-v")
+	} else if verbose {
+		args = append(args, "VERBOSE=1")
+	}
+	return buildPhases.track(phaseCompile, func() error {
+		return runInEnv(out, buildDir, env, tool, args...)
+	})
+}
+
+// crossBuildDir is where the server is cross-compiled for triple.
+func crossBuildDir(backend, triple string) string {
+	return filepath.Join(backend, "build-"+triple)
+}
+
+// targetTriple matches the target triples build accepts, which also name
+// build directories and artifacts.
+var targetTriple = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// buildServerFor cross-compiles the server in backend for triple into its
+// crossBuildDir with a plain CMake configure, presets or not. toolchain is
+// the CMake toolchain file; without one the triple is handed to the
+// compiler as its target, which clang understands. The triple is also set
+// as REAVIX_TARGET_TRIPLE so one toolchain file can serve several targets.
+func buildServerFor(out cmdOutput, backend, triple, toolchain string, opts serverBuildOptions) (*serverBuild, error) {
+	defines := []string{"-DREAVIX_TARGET_TRIPLE=" + triple}
+	if toolchain != "" {
+		defines = append(defines, "-DCMAKE_TOOLCHAIN_FILE="+toolchain)
+	} else {
+		defines = append(defines, "-DCMAKE_C_COMPILER_TARGET="+triple, "-DCMAKE_CXX_COMPILER_TARGET="+triple)
+	}
+	return buildServerIn(out, crossBuildDir(backend, triple), opts, defines...)
+}
+
+// buildServerIn configures the server with defines in buildDir, a direct
+// child of the server directory, and compiles it. The compile_commands.json
+// next to the sources stays the one of the default build.
+func buildServerIn(out cmdOutput, buildDir string, opts serverBuildOptions, defines ...string) (*serverBuild, error) {
+	if err := os.MkdirAll(buildDir, 0755); err != nil {
+		return nil, err
+	}
+	defines = append(defines, opts.defines...)
+	if err := configureServer(out, buildDir, opts.buildType, opts.generator, opts.reconfigure, opts.env, defines...); err != nil {
+		return nil, err
+	}
+	return &serverBuild{dir: buildDir, buildType: opts.buildType}, compileServer(out, buildDir, opts.jobs, opts.env)
+}
+
+// sanitizers are the values of --sanitize in the order they are combined,
+// with the short names of their build directories.
+var sanitizers = []struct{ name, short string }{
+	{"address", "asan"},
+	{"undefined", "ubsan"},
+	{"thread", "tsan"},
+}
+
+// resolveSanitizers checks the --sanitize values of build and dev and puts
+// them in a fixed order, so each combination has one build directory.
+func resolveSanitizers(values []string) ([]string, error) {
+	asked := map[string]bool{}
+	for _, v := range values {
+		known := false
+		for _, san := range sanitizers {
+			known = known || san.name == v
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown sanitizer %q (supported: address, undefined, thread)", v)
+		}
+		asked[v] = true
+	}
+	if asked["address"] && asked["thread"] {
+		return nil, fmt.Errorf("the address and thread sanitizers cannot be combined")
+	}
+	var resolved []string
+	for _, san := range sanitizers {
+		if asked[san.name] {
+			resolved = append(resolved, san.name)
+		}
+	}
+	return resolved, nil
+}
+
+// sanitizeBuildDir is where the server is built with the given sanitizers,
+// like server/build-asan-ubsan; sanitized and plain objects cannot be linked
+// together.
+func sanitizeBuildDir(backend string, names []string) string {
+	dir := "build"
+	for _, san := range sanitizers {
+		for _, n := range names {
+			if n == san.name {
+				dir += "-" + san.short
+			}
+		}
+	}
+	return filepath.Join(backend, dir)
+}
+
+// buildSanitized builds the server in backend with the given sanitizers
+// into their sanitizeBuildDir, adding -fsanitize to the compile and link
+// flags. It configures CMake directly, presets or not.
+func buildSanitized(out cmdOutput, backend string, names []string, opts serverBuildOptions) (*serverBuild, error) {
+	flag := "-fsanitize=" + strings.Join(names, ",")
+	return buildServerIn(out, sanitizeBuildDir(backend, names), opts,
+		"-DCMAKE_C_FLAGS="+flag+" -fno-omit-frame-pointer",
+		"-DCMAKE_CXX_FLAGS="+flag+" -fno-omit-frame-pointer",
+		"-DCMAKE_EXE_LINKER_FLAGS="+flag,
+	)
+}
+
+// cacheGenerator finds the generator recorded in a CMakeCache.txt.
+var cacheGenerator = regexp.MustCompile(`(?m)^CMAKE_GENERATOR:INTERNAL=(.*?)\r?$`)
+
+// usesNinja reports whether the CMake cache in buildDir was configured with
+// a Ninja generator.
+func usesNinja(buildDir string) bool {
+	content, err := os.ReadFile(filepath.Join(buildDir, "CMakeCache.txt"))
+	if err != nil {
+		return false
+	}
+	m := cacheGenerator.FindSubmatch(content)
+	return m != nil && strings.Contains(string(m[1]), "Ninja")
+}
+
+// resolveGenerator picks the generator of build and dev: --generator when
+// given, then the generator of reavix.json, then ninja when it is installed
+// and make otherwise. "auto" skips reavix.json. With CMakePresets.json the
+// presets' own generator is kept unless one was asked for, and "" returned.
+func resolveGenerator(flag string, m *project.Manifest) (string, error) {
+	name := flag
+	if name == "" {
+		name = m.Generator
+	}
+	if name == "" && hasCMakePresets(m.Backend) {
+		return "", nil
+	}
+	if name == "" || name == "auto" {
+		name = "make"
+		if _, err := exec.LookPath("ninja"); err == nil {
+			name = "ninja"
+		}
+	}
+	if _, ok := cmakeGenerators[name]; !ok {
+		return "", fmt.Errorf("unknown generator %q (supported: ninja, make, auto)", name)
+	}
+	return name, nil
+}
+
+// recordGenerator saves generator in reavix.json when it differs from the
+// one there, so build and dev keep using the same build directory layout.
+func recordGenerator(m *project.Manifest, generator string) error {
+	if generator == "" || generator == m.Generator || !fileExists(project.FileName) {
+		return nil
+	}
+	m.Generator = generator
+	return project.Update(".", func(saved *project.Manifest) { saved.Generator = generator })
+}
+
+// jobCount returns how many compile jobs to run in the configured buildDir:
+// jobs when set, otherwise one per CPU. Ninja already runs in parallel on
+// its own, so it only gets an explicit count.
+func jobCount(buildDir string, jobs int) int {
+	if jobs > 0 {
+		return jobs
+	}
+	if usesNinja(buildDir) {
+		return 0
+	}
+	return runtime.NumCPU()
+}
+
+// resolveJobs picks the compile job count of build and dev: --jobs when
+// given, then the jobs of reavix.json, then 0 for the default.
+func resolveJobs(flag int, changed bool, m *project.Manifest) (int, error) {
+	jobs := m.Jobs
+	if changed {
+		jobs = flag
+	}
+	if jobs < 0 {
+		return 0, fmt.Errorf("the job count cannot be negative (got %d)", jobs)
+	}
+	return jobs, nil
+}
+
+// configureArgs adds CMake's --debug-output to a configure command line
+// under --verbose.
+func configureArgs(args ...string) []string {
+	if verbose {
+		return append([]string{"--debug-output"}, args...)
+	}
+	return args
+}
+
+// resolveBuildType turns the --debug and --release flags of build and dev
+// into a CMAKE_BUILD_TYPE and the generated preset for it, falling back to
+// def when neither is given. An explicit --preset decides the build type
+// itself, so it cannot be combined with them.
+func resolveBuildType(debug, release bool, preset, def string) (buildType, presetName string, err error) {
+	switch {
+	case debug && release:
+		return "", "", fmt.Errorf("--debug and --release cannot be combined")
+	case (debug || release) && preset != "":
+		return "", "", fmt.Errorf("--preset sets the build type; drop --debug or --release")
+	case preset != "":
+		return def, preset, nil
+	case debug:
+		return "Debug", "dev", nil
+	case release:
+		return "Release", "release", nil
+	case def == "Debug":
+		return def, "dev", nil
+	}
+	return def, "release", nil
+}
+
+// cacheBuildType finds the CMAKE_BUILD_TYPE recorded in a CMakeCache.txt.
+var cacheBuildType = regexp.MustCompile(`(?m)^CMAKE_BUILD_TYPE:[A-Z]+=(.*?)\r?$`)
+
+// resetStaleCache removes the CMake cache and generated files of buildDir
+// when it was configured for a build type other than buildType, so
+// switching between Debug and Release starts from a clean configure instead
+// of mixing settings and objects of both. A cache made with another
+// generator than generator is reset too, as CMake refuses to switch one in
+// place. Empty values are not compared.
+func resetStaleCache(out cmdOutput, buildDir, buildType, generator string) error {
+	content, err := os.ReadFile(filepath.Join(buildDir, "CMakeCache.txt"))
+	if err != nil {
+		// Nothing configured yet.
+		return nil
+	}
+	if m := cacheGenerator.FindSubmatch(content); generator != "" && m != nil && string(m[1]) != generator {
+		fmt.Fprintf(out.stdout, "%s was configured with %s; reconfiguring it with %s\n", displayPath(buildDir), m[1], generator)
+		return removeCache(buildDir)
+	}
+	m := cacheBuildType.FindSubmatch(content)
+	if buildType == "" || m == nil || strings.EqualFold(string(m[1]), buildType) {
+		return nil
+	}
+	was := string(m[1])
+	if was == "" {
+		was = "no build type"
+	}
+	fmt.Fprintf(out.stdout, "%s was configured for %s; reconfiguring it for %s\n", displayPath(buildDir), was, buildType)
+	return removeCache(buildDir)
+}
+
+// removeCache deletes the CMake cache and generated files of buildDir,
+// leaving the compiled objects for make to clean up.
+func removeCache(buildDir string) error {
+	if err := os.Remove(filepath.Join(buildDir, "CMakeCache.txt")); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(buildDir, "CMakeFiles"))
+}
+
+// serverBuildDir returns the directory buildServer builds into for preset,
+// without building anything.
+func serverBuildDir(backend, preset string) (string, error) {
+	if !hasCMakePresets(backend) {
+		return filepath.Join(backend, "build"), nil
+	}
+	p, err := resolvePreset(backend, preset)
+	if err != nil {
+		return "", err
+	}
+	return p.binaryDir, nil
+}
+
+// configureServer runs CMake in backendDir for buildType, "Debug" or
+// "Release", with generator, "ninja", "make" or "" for CMake's default, env
+// added to its environment and defines as extra -D arguments.
+func configureServer(out cmdOutput, backendDir, buildType, generator string, reconfigure bool, env []string, defines ...string) error {
+	if err := resetStaleCache(out, backendDir, buildType, cmakeGenerators[generator]); err != nil {
+		return err
+	}
+	serverDir := filepath.Dir(backendDir)
+	var args []string
+	if generator != "" {
+		args = append(args, "-G", cmakeGenerators[generator])
+	}
+	args = append(append(args, defines...), "-DCMAKE_BUILD_TYPE="+buildType, "..")
+	return configureOnce(out, serverDir, backendDir, reconfigure, func() error {
+		return runCMake(out, backendDir, env, configureArgs(args...)...)
+	}, append(args, env...)...)
+}
+
+// runCMake runs the cmake that configures the server in dir, with env added
+// to its environment; tests stand in for it.
+var runCMake = func(out cmdOutput, dir string, env []string, args ...string) error {
+	return runInEnv(out, dir, env, "cmake", args...)
+}
+
+// configureStampFile, in a build directory, holds the configureStamp of its
+// last successful configure, followed by the names of the cache variables
+// it set with -D, one per line.
+const configureStampFile = ".reavix-configure"
+
+// configureOnce runs configure for the build directory buildDir of the
+// server in serverDir unless buildDir has a CMake cache and was last
+// configured with the same args from the same CMake files, or reconfigure
+// is set. CMakeLists.txt can read the environment, so args holds the
+// variables added to it too. CMake keeps a cache variable once set, so when
+// args no longer set one the last configure did, like the linker flags of
+// --static, the cache is reset first.
+func configureOnce(out cmdOutput, serverDir, buildDir string, reconfigure bool, configure func() error, args ...string) error {
+	stamp, err := configureStamp(serverDir, args)
+	if err != nil {
+		return err
+	}
+	defined := definedVariables(args)
+	stampPath := filepath.Join(buildDir, configureStampFile)
+	if last, err := os.ReadFile(stampPath); err == nil && fileExists(filepath.Join(buildDir, "CMakeCache.txt")) {
+		lines := strings.Split(strings.TrimSpace(string(last)), "\n")
+		if !reconfigure && lines[0] == stamp {
+			fmt.Fprintln(out.stdout, "CMake configuration is up to date, skipping configure")
+			return nil
+		}
+		for _, name := range lines[1:] {
+			if !defined[name] {
+				fmt.Fprintf(out.stdout, "%s no longer sets %s; reconfiguring it from scratch\n", displayPath(buildDir), name)
+				if err := removeCache(buildDir); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	// A configure that fails halfway must not be taken for a finished one.
+	if err := os.Remove(stampPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := buildPhases.track(phaseConfigure, configure); err != nil {
+		return err
+	}
+	content := stamp + "\n"
+	for _, name := range sortedKeys(defined) {
+		content += name + "\n"
+	}
+	return os.WriteFile(stampPath, []byte(content), 0644)
+}
+
+// definedVariables returns the names of the cache variables set by the -D
+// arguments in args.
+func definedVariables(args []string) map[string]bool {
+	names := map[string]bool{}
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-D") {
+			continue
+		}
+		name := strings.SplitN(strings.TrimPrefix(arg, "-D"), "=", 2)[0]
+		names[strings.SplitN(name, ":", 2)[0]] = true
+	}
+	return names
+}
+
+func sortedKeys(set map[string]bool) []string {
+	var keys []string
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// configureStamp hashes what configuring the server in serverDir depends
+// on: the configure arguments and the CMake files of the source tree, that
+// is every CMakeLists.txt, *.cmake and *.in file and the presets files.
+// Build directories, recognized by their CMakeCache.txt, are left out.
+func configureStamp(serverDir string, args []string) (string, error) {
+	return hashTree(serverDir, strings.Join(args, "\x00"), func(path string, d fs.DirEntry) bool {
+		name := d.Name()
+		if d.IsDir() {
+			return name == "node_modules" || strings.HasPrefix(name, ".") || fileExists(filepath.Join(path, "CMakeCache.txt"))
+		}
+		switch {
+		case name == "CMakeLists.txt", name == cmakePresetsFile, name == cmakeUserPresetsFile:
+			return false
+		case strings.HasSuffix(name, ".cmake"), strings.HasSuffix(name, ".in"):
+			return false
+		}
+		return true
+	})
+}
+
+// linkCompileCommands symlinks backendDir/compile_commands.json into
+// serverDir, copying it instead where symlinks are unavailable.
+func linkCompileCommands(serverDir, backendDir string) error {
+	src := filepath.Join(backendDir, "compile_commands.json")
+	if _, err := os.Stat(src); err != nil {
+		// Generators that don't support the export leave nothing to link.
+		return nil
+	}
+
+	dst := filepath.Join(serverDir, "compile_commands.json")
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if rel, err := filepath.Rel(serverDir, src); err == nil {
+		if err := os.Symlink(rel, dst); err == nil {
+			return nil
+		}
+	}
+	return utils.CopyFile(src, dst)
+}
+
+func hasCMakePresets(backend string) bool {
+	return fileExists(filepath.Join(backend, cmakePresetsFile))
+}
+
+// cmakePreset is the part of a configure or build preset the CLI needs to
+// find where a preset builds the server.
+type cmakePreset struct {
+	Name            string                     `json:"name"`
+	Hidden          bool                       `json:"hidden"`
+	Inherits        json.RawMessage            `json:"inherits"`
+	BinaryDir       string                     `json:"binaryDir"`
+	Generator       string                     `json:"generator"`
+	ConfigurePreset string                     `json:"configurePreset"`
+	CacheVariables  map[string]json.RawMessage `json:"cacheVariables"`
+}
+
+// cacheVariable returns the value p gives a cache variable, which CMake
+// accepts as a plain string or as an object with a value.
+func (p cmakePreset) cacheVariable(name string) string {
+	raw, ok := p.CacheVariables[name]
+	if !ok {
+		return ""
+	}
+	var value string
+	if json.Unmarshal(raw, &value) == nil {
+		return value
+	}
+	var typed struct {
+		Value string `json:"value"`
+	}
+	json.Unmarshal(raw, &typed)
+	return typed.Value
+}
+
+// parents returns the presets p inherits from, which CMake accepts as a
+// single name or a list.
+func (p cmakePreset) parents() []string {
+	var one string
+	if json.Unmarshal(p.Inherits, &one) == nil {
+		return []string{one}
+	}
+	var many []string
+	json.Unmarshal(p.Inherits, &many)
+	return many
+}
+
+// resolvedPreset is a preset name resolved against the presets files:
+// configure is the configure preset to run, build whether a build preset of
+// that name exists, sourceDir and binaryDir the absolute server and build
+// directories, and buildType and generator the CMAKE_BUILD_TYPE and the
+// generator the preset sets, if any.
+type resolvedPreset struct {
+	configure string
+	build     bool
+	sourceDir string
+	binaryDir string
+	buildType string
+	generator string
+}
+
+// resolvePreset looks name up as a build preset, then as a configure
+// preset, in CMakePresets.json and CMakeUserPresets.json, and expands the
+// binaryDir its configure preset inherits.
+func resolvePreset(backend, name string) (*resolvedPreset, error) {
+	source, err := filepath.Abs(backend)
+	if err != nil {
+		return nil, err
+	}
+
+	configures := map[string]cmakePreset{}
+	builds := map[string]cmakePreset{}
+	for _, file := range []string{cmakePresetsFile, cmakeUserPresetsFile} {
+		content, err := os.ReadFile(filepath.Join(backend, file))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var presets struct {
+			ConfigurePresets []cmakePreset `json:"configurePresets"`
+			BuildPresets     []cmakePreset `json:"buildPresets"`
+		}
+		if err := json.Unmarshal(content, &presets); err != nil {
+			return nil, fmt.Errorf("%s: %v", filepath.Join(backend, file), err)
+		}
+		for _, p := range presets.ConfigurePresets {
+			configures[p.Name] = p
+		}
+		for _, p := range presets.BuildPresets {
+			builds[p.Name] = p
+		}
+	}
+
+	r := &resolvedPreset{configure: name, sourceDir: source}
+	if b, ok := builds[name]; ok {
+		r.build = true
+		r.configure = b.ConfigurePreset
+	}
+	if _, ok := configures[r.configure]; !ok {
+		seen := map[string]bool{}
+		var names []string
+		for _, presets := range []map[string]cmakePreset{builds, configures} {
+			for n, p := range presets {
+				if !p.Hidden && !seen[n] {
+					seen[n] = true
+					names = append(names, n)
+				}
+			}
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown CMake preset %q (available: %s)", name, strings.Join(names, ", "))
+	}
+
+	// Walk the inheritance chain depth-first, as CMake does, until a preset
+	// sets the field.
+	var inherited func(string, int, func(cmakePreset) string) string
+	inherited = func(n string, depth int, field func(cmakePreset) string) string {
+		p, ok := configures[n]
+		if !ok || depth > len(configures) {
+			return ""
+		}
+		if v := field(p); v != "" {
+			return v
+		}
+		for _, parent := range p.parents() {
+			if v := inherited(parent, depth+1, field); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	r.buildType = inherited(r.configure, 0, func(p cmakePreset) string { return p.cacheVariable("CMAKE_BUILD_TYPE") })
+	r.generator = inherited(r.configure, 0, func(p cmakePreset) string { return p.Generator })
+	dir := inherited(r.configure, 0, func(p cmakePreset) string { return p.BinaryDir })
+	if dir == "" {
+		return nil, fmt.Errorf("CMake preset %q sets no binaryDir", r.configure)
+	}
+	dir = strings.NewReplacer(
+		"${sourceDir}", source,
+		"${sourceParentDir}", filepath.Dir(source),
+		"${sourceDirName}", filepath.Base(source),
+		"${presetName}", r.configure,
+	).Replace(dir)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(source, dir)
+	}
+	r.binaryDir = filepath.Clean(dir)
+	return r, nil
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeCMake stands in for runCMake for the rest of the test, writing the
+// CMakeCache.txt cmake would for the -G its arguments name. It returns the
+// number of configures so far and whether the last one found a cache left.
+func fakeCMake(t *testing.T) func() (int, bool) {
+	t.Helper()
+	saved := runCMake
+	t.Cleanup(func() { runCMake = saved })
+	configures, hadCache := 0, false
+	runCMake = func(out cmdOutput, dir string, env []string, args ...string) error {
+		configures++
+		cache := filepath.Join(dir, "CMakeCache.txt")
+		hadCache = fileExists(cache)
+		generator := "Unix Makefiles"
+		for i, arg := range args {
+			if arg == "-G" && i+1 < len(args) {
+				generator = args[i+1]
+			}
+		}
+		if err := os.MkdirAll(filepath.Join(dir, "CMakeFiles"), 0o755); err != nil {
+			return err
+		}
+		content := "CMAKE_GENERATOR:INTERNAL=" + generator + "\nCMAKE_BUILD_TYPE:STRING=Release\n"
+		return os.WriteFile(cache, []byte(content), 0o644)
+	}
+	return func() (int, bool) { return configures, hadCache }
+}
+
+func TestConfigureServerResetsStaleCache(t *testing.T) {
+	serverDir := t.TempDir()
+	writeFiles(t, serverDir, map[string]string{"CMakeLists.txt": "project(app C)\n"})
+	buildDir := filepath.Join(serverDir, "build")
+	if err := os.MkdirAll(buildDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	configures := fakeCMake(t)
+	out := cmdOutput{stdout: io.Discard, stderr: io.Discard}
+
+	steps := []struct {
+		name      string
+		before    func()
+		generator string
+		configure bool
+	}{
+		{"first configure", nil, "make", true},
+		{"unchanged", nil, "make", false},
+		{"make to ninja", nil, "ninja", true},
+		{"ninja to make", nil, "make", true},
+		{"deleted cache", func() {
+			if err := os.Remove(filepath.Join(buildDir, "CMakeCache.txt")); err != nil {
+				t.Fatal(err)
+			}
+		}, "make", true},
+		{"unchanged again", nil, "make", false},
+	}
+	for _, step := range steps {
+		if step.before != nil {
+			step.before()
+		}
+		before, _ := configures()
+		if err := configureServer(out, buildDir, "Release", step.generator, false, nil); err != nil {
+			t.Fatalf("%s: configureServer = %v", step.name, err)
+		}
+		after, hadCache := configures()
+		if ran := after > before; ran != step.configure {
+			t.Errorf("%s: configure ran = %v, want %v", step.name, ran, step.configure)
+		}
+		if step.configure && hadCache {
+			t.Errorf("%s: configure ran over the stale CMakeCache.txt", step.name)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Reavix-framework/cli/internal/project"
+	"github.com/Reavix-framework/cli/templates"
+	"github.com/spf13/cobra"
+)
+
+// systemUnitDir is where service install puts a system unit, and
+// userUnitDir, under the home directory, a --user one.
+const (
+	systemUnitDir = "/etc/systemd/system"
+	userUnitDir   = ".config/systemd/user"
+)
+
+// unitNameChars matches what a systemd unit name may not hold.
+var unitNameChars = regexp.MustCompile(`[^A-Za-z0-9:_.-]+`)
+
+var serviceOpts struct {
+	name   string
+	user   bool
+	runAs  string
+	enable bool
+	start  bool
+}
+
+// serviceUnit is what the systemd unit of the app is rendered from:
+// everything but User comes from reavix.json and the project root, so the
+// unit stays right across rebuilds.
+type serviceUnit struct {
+	Name             string
+	User             string
+	WorkingDirectory string
+	ExecStart        string
+	Port             int
+	EnvironmentFile  string
+	WantedBy         string
+}
+
+var serviceCmd = &cobra.Command{
+	Use:   "service",
+	Short: "Run the built app as a systemd service",
+	Long: "Install, remove and inspect a systemd unit running the server `reavix build` put in outDir\n" +
+		"of reavix.json, named after the project unless --name says otherwise. A system unit\n" +
+		"goes to /etc/systemd/system, through sudo when needed; with --user, a unit of the current\n" +
+		"user goes to ~/.config/systemd/user.",
+}
+
+var serviceInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Write the systemd unit of the app and reload systemd",
+	Long: "Render the systemd unit of the app: ExecStart is the absolute path of the server binary, run\n" +
+		"in its output directory as `reavix run` does, on ports.server of reavix.json unless\n" +
+		".env.production, its EnvironmentFile, sets REAVIX_PORT, and restarted when it fails. Write it,\n" +
+		"run `systemctl daemon-reload`, and with --enable and --start enable and start it. Where\n" +
+		"systemd is not running, the unit is printed instead, for you to install elsewhere.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if serviceOpts.user && serviceOpts.runAs != "" {
+			return fmt.Errorf("--run-as is for system units; a --user unit runs as you")
+		}
+		cmd.SilenceUsage = true
+		m, err := requireProject()
+		if err != nil {
+			return err
+		}
+		if !m.HasBackend() {
+			return fmt.Errorf("this project has no server to run as a service")
+		}
+		name, err := serviceName(m)
+		if err != nil {
+			return err
+		}
+		unit, err := renderServiceUnit(m, name)
+		if err != nil {
+			return err
+		}
+		if !systemdRunning() {
+			fmt.Fprintf(os.Stderr, "systemd is not running here, so this is the unit %s.service would be:\n\n", name)
+			os.Stdout.Write(unit)
+			return nil
+		}
+		if binary := filepath.Join(m.OutDir, m.Binary); !fileExists(binary) {
+			fmt.Fprintf(os.Stderr, "Warning: %s does not exist yet; run `reavix build` before starting the service.\n", displayPath(binary))
+		}
+		path, err := unitPath(name)
+		if err != nil {
+			return err
+		}
+		if err := writeUnit(path, unit); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s.\n", path)
+		if err := systemctl("daemon-reload"); err != nil {
+			return err
+		}
+		if serviceOpts.enable {
+			if err := systemctl("enable", name+".service"); err != nil {
+				return err
+			}
+		}
+		if serviceOpts.start {
+			if err := systemctl("restart", name+".service"); err != nil {
+				return err
+			}
+			fmt.Printf("Started %s.service; `reavix service status` shows how it is doing.\n", name)
+			return nil
+		}
+		fmt.Printf("`%s start %s.service` starts it.\n", strings.Join(systemctlArgs(), " "), name)
+		return nil
+	},
+}
+
+var serviceUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Stop and disable the systemd unit of the app and remove it",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		m, err := requireProject()
+		if err != nil {
+			return err
+		}
+		name, err := serviceName(m)
+		if err != nil {
+			return err
+		}
+		if !systemdRunning() {
+			return fmt.Errorf("systemd is not running here, so there is no unit to uninstall")
+		}
+		path, err := unitPath(name)
+		if err != nil {
+			return err
+		}
+		if !fileExists(path) {
+			fmt.Printf("%s does not exist; nothing to uninstall.\n", path)
+			return nil
+		}
+		if err := systemctl("disable", "--now", name+".service"); err != nil {
+			return err
+		}
+		if err := removeUnit(path); err != nil {
+			return err
+		}
+		fmt.Printf("Removed %s.\n", path)
+		return systemctl("daemon-reload")
+	},
+}
+
+var serviceStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show what systemctl status says of the unit of the app",
+	Long: "Run `systemctl status` for the unit of the app, exiting with its status: 0 when it is\n" +
+		"running, 3 when it is not, 4 when it is not installed.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		m, err := requireProject()
+		if err != nil {
+			return err
+		}
+		name, err := serviceName(m)
+		if err != nil {
+			return err
+		}
+		if !systemdRunning() {
+			return fmt.Errorf("systemd is not running here")
+		}
+		// Reading the status needs no sudo.
+		status := []string{"status", "--no-pager", name + ".service"}
+		if serviceOpts.user {
+			status = append([]string{"--user"}, status...)
+		}
+		c := exec.Command("systemctl", status...)
+		c.Stdout, c.Stderr = os.Stdout, os.Stderr
+		if err := c.Run(); err != nil {
+			if exit, ok := err.(*exec.ExitError); ok {
+				return &exitError{code: exit.ExitCode(), err: err, reported: true}
+			}
+			return err
+		}
+		return nil
+	},
+}
+
+// serviceName is the name of the unit of m, without .service: --name, else
+// the name the project was created with, or for a manifest that lacks it
+// the name of the project directory, with what systemd does not allow in
+// unit names replaced by '-'.
+func serviceName(m *project.Manifest) (string, error) {
+	name, from := serviceOpts.name, ""
+	if name == "" {
+		name, from = m.Name, "the project name"
+	}
+	if name == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		name, from = filepath.Base(wd), "the project directory"
+	}
+	unit := strings.Trim(unitNameChars.ReplaceAllString(strings.TrimSuffix(name, ".service"), "-"), "-")
+	switch {
+	case unit != "":
+		return unit, nil
+	case from == "":
+		return "", fmt.Errorf("invalid --name %q: use letters, digits, ':', '_', '.' and '-'", name)
+	default:
+		return "", fmt.Errorf("no unit name can be made of %s %q; pass --name with letters, digits, ':', '_', '.' and '-'", from, name)
+	}
+}
+
+// renderServiceUnit renders the systemd unit of m, called name, from
+// shared/systemd.service.tmpl, with the absolute paths of the project root
+// requireProject changed into.
+func renderServiceUnit(m *project.Manifest, name string) ([]byte, error) {
+	outDir, err := filepath.Abs(m.OutDir)
+	if err != nil {
+		return nil, err
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	unit := serviceUnit{
+		Name:             name,
+		User:             serviceOpts.runAs,
+		WorkingDirectory: outDir,
+		ExecStart:        filepath.Join(outDir, m.Binary),
+		Port:             m.Ports.Server,
+		EnvironmentFile:  filepath.Join(root, ".env.production"),
+		WantedBy:         "multi-user.target",
+	}
+	if strings.ContainsAny(unit.ExecStart, " \t") {
+		// systemd splits ExecStart at spaces outside quotes.
+		unit.ExecStart = strconv.Quote(unit.ExecStart)
+	}
+	if serviceOpts.user {
+		unit.WantedBy = "default.target"
+	}
+	tmpl, err := template.ParseFS(templates.FS, "shared/systemd.service.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, unit); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// systemdRunning reports whether systemd is the init of this machine, as
+// sd_booted does.
+func systemdRunning() bool {
+	if info, err := os.Stat("/run/systemd/system"); err != nil || !info.IsDir() {
+		return false
+	}
+	_, err := exec.LookPath("systemctl")
+	return err == nil
+}
+
+// unitPath is where the unit name goes: systemUnitDir, or userUnitDir
+// under the home directory with --user.
+func unitPath(name string) (string, error) {
+	if !serviceOpts.user {
+		return filepath.Join(systemUnitDir, name+".service"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, userUnitDir, name+".service"), nil
+}
+
+// needsSudo reports whether changing a system unit needs sudo, as reavix
+// does not run as root.
+func needsSudo() bool {
+	return !serviceOpts.user && os.Geteuid() != 0
+}
+
+// writeUnit writes unit to path, through sudo install when needsSudo says
+// so.
+func writeUnit(path string, unit []byte) error {
+	if !needsSudo() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(path, unit, 0o644)
+	}
+	tmp, err := os.CreateTemp("", "reavix-*.service")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(unit); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return runSystemCommand("sudo", "install", "-m", "0644", tmp.Name(), path)
+}
+
+// removeUnit removes the unit at path, through sudo when needsSudo says so.
+func removeUnit(path string) error {
+	if !needsSudo() {
+		return os.Remove(path)
+	}
+	return runSystemCommand("sudo", "rm", "-f", path)
+}
+
+// systemctlArgs is the systemctl command of the unit: with --user, or
+// through sudo when needsSudo says so.
+func systemctlArgs() []string {
+	switch {
+	case serviceOpts.user:
+		return []string{"systemctl", "--user"}
+	case needsSudo():
+		return []string{"sudo", "systemctl"}
+	}
+	return []string{"systemctl"}
+}
+
+// systemctl runs systemctl with args, as systemctlArgs says, printing it
+// first.
+func systemctl(args ...string) error {
+	command := append(systemctlArgs(), args...)
+	return runSystemCommand(command[0], command[1:]...)
+}
+
+// runSystemCommand runs name with args, printing it first, its output going
+// to the terminal, where sudo can ask for a password.
+func runSystemCommand(name string, args ...string) error {
+	fmt.Printf("Running %s\n", strings.Join(append([]string{name}, args...), " "))
+	c := exec.Command(name, args...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+func init() {
+	serviceCmd.PersistentFlags().StringVar(&serviceOpts.name, "name", "", "Name of the unit, without .service (default: the name of the project)")
+	serviceCmd.PersistentFlags().BoolVar(&serviceOpts.user, "user", false, "Use a unit of the current user, in ~/.config/systemd/user, rather than a system one")
+	serviceInstallCmd.Flags().StringVar(&serviceOpts.runAs, "run-as", "", "User the server of a system unit runs as, ideally one of its own (default: root)")
+	serviceInstallCmd.Flags().BoolVar(&serviceOpts.enable, "enable", false, "Enable the unit, so it starts at boot, or at login with --user")
+	serviceInstallCmd.Flags().BoolVar(&serviceOpts.start, "start", false, "Start the unit, or restart it when it runs already")
+	serviceCmd.AddCommand(serviceInstallCmd, serviceUninstallCmd, serviceStatusCmd)
+	rootCmd.AddCommand(serviceCmd)
+}
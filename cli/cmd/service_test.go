@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Reavix-framework/cli/internal/project"
+)
+
+func TestServiceName(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "my app")
+	writeFiles(t, dir, map[string]string{"README.md": ""})
+	chdir(t, dir)
+	saved := serviceOpts
+	t.Cleanup(func() { serviceOpts = saved })
+
+	tests := []struct {
+		name     string
+		flag     string
+		manifest string
+		want     string
+		err      string
+	}{
+		{"manifest name", "", "shop", "shop", ""},
+		{"flag over manifest", "api.service", "shop", "api", ""},
+		{"old manifest", "", "", "my-app", ""},
+		{"flag without valid characters", "日本", "shop", "", `invalid --name "日本": use letters, digits, ':', '_', '.' and '-'`},
+		{"derived without valid characters", "", "日本", "", `no unit name can be made of the project name "日本"; pass --name with letters, digits, ':', '_', '.' and '-'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serviceOpts.name = tt.flag
+			m := project.Default()
+			m.Name = tt.manifest
+			got, err := serviceName(&m)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("serviceName = %q, %v; want error %q", got, err, tt.err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("serviceName = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Reavix-framework/cli/internal/project"
+)
+
+// defaultLogDir is where dev and run write their session logs, at the
+// project root.
+const defaultLogDir = ".reavix/logs"
+
+// sessionTime is how the start of a session shows in the names of its log
+// files; Windows does not allow the colons of a time in file names.
+const sessionTime = "2006-01-02T15-04-05"
+
+// sessionLogFile matches the name of a session log: the command, the dev
+// server or server whose output it holds, the start of the session and,
+// for the files after the first of a session, their number.
+var sessionLogFile = regexp.MustCompile(`^(dev|run)-(app|server)-(\d{4}-\d\d-\d\dT\d\d-\d\d-\d\d)(?:\.(\d+))?\.log$`)
+
+// sessionLog is the log file of one dev server or server during a session
+// of dev or run, like dev-app-2024-05-01T10-00-00.log. Each line goes to
+// the file as soon as it is complete, behind the time it was written at,
+// so a crash of reavix loses none. Once the file is past the size limit,
+// the log moves on to dev-app-2024-05-01T10-00-00.2.log, and so on.
+type sessionLog struct {
+	mu      sync.Mutex
+	dir     string
+	base    string
+	part    int
+	f       *os.File
+	size    int64
+	maxSize int64
+}
+
+// startSessionLogs opens the session logs of command for each of names in
+// dir, a session starting at started, after removing the logs older than
+// the logs.maxAgeDays of m. A log that cannot be opened is warned about
+// and left out.
+func startSessionLogs(dir, command string, names []string, started time.Time, m *project.Manifest) map[string]*sessionLog {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: no session logs, as %s could not be created: %v\n", displayPath(dir), err)
+		return nil
+	}
+	pruneSessionLogs(dir, time.Duration(m.LogMaxAge())*24*time.Hour)
+	stamp := started.Format(sessionTime)
+	opened := map[string]*sessionLog{}
+	for _, name := range names {
+		l := &sessionLog{dir: dir, base: command + "-" + name + "-" + stamp, part: 1, maxSize: int64(m.LogMaxSize()) << 20}
+		if err := l.open(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: no session log for the %s: %v\n", name, err)
+			continue
+		}
+		opened[name] = l
+	}
+	if len(opened) > 0 {
+		fmt.Printf("Logging to %s; `reavix logs` shows them.\n", filepath.ToSlash(filepath.Join(displayPath(dir), command+"-*-"+stamp+".log")))
+	}
+	return opened
+}
+
+// openDetachedLog opens the log of the server of `reavix run --detach` in
+// dir, of a session starting at started, after removing the logs older
+// than the logs.maxAgeDays of m. The server writes to it itself, so its
+// lines have no times.
+func openDetachedLog(dir string, started time.Time, m *project.Manifest) (*os.File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	pruneSessionLogs(dir, time.Duration(m.LogMaxAge())*24*time.Hour)
+	return os.OpenFile(filepath.Join(dir, "run-server-"+started.Format(sessionTime)+".log"), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+}
+
+// pruneSessionLogs removes the session logs in dir last written to more
+// than maxAge ago.
+func pruneSessionLogs(dir string, maxAge time.Duration) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !sessionLogFile.MatchString(entry.Name()) {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) > maxAge {
+			os.Remove(filepath.Join(dir, entry.Name()))
+		}
+	}
+}
+
+// name is the file name of part of the log.
+func (l *sessionLog) name(part int) string {
+	if part == 1 {
+		return l.base + ".log"
+	}
+	return l.base + "." + strconv.Itoa(part) + ".log"
+}
+
+// open opens the file of the current part of l.
+func (l *sessionLog) open() error {
+	f, err := os.OpenFile(filepath.Join(l.dir, l.name(l.part)), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	l.f, l.size = f, 0
+	if info, err := f.Stat(); err == nil {
+		l.size = info.Size()
+	}
+	return nil
+}
+
+// writer returns a writer of lines to l, for one output of a command; the
+// outputs written to l at once each need their own, so their partial lines
+// do not run into each other.
+func (l *sessionLog) writer() *sessionLogWriter {
+	return &sessionLogWriter{log: l}
+}
+
+// writeLine writes line without the colors of the terminal, moving on to
+// the next part first when line would take the current one past maxSize.
+// It never fails: a log that cannot be written to is warned about once and
+// given up.
+func (l *sessionLog) writeLine(line []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return
+	}
+	text := time.Now().Format("15:04:05.000") + " " + ansiEscape.ReplaceAllString(string(bytes.TrimSuffix(line, []byte("\r"))), "") + "\n"
+	if l.size > 0 && l.size+int64(len(text)) > l.maxSize {
+		l.f.Close()
+		l.part++
+		if err := l.open(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: stopped the session log %s: %v\n", l.name(l.part), err)
+			l.f = nil
+			return
+		}
+	}
+	n, err := l.f.WriteString(text)
+	l.size += int64(n)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: stopped the session log %s: %v\n", l.name(l.part), err)
+		l.f.Close()
+		l.f = nil
+	}
+}
+
+// close closes the log; flush its writers first.
+func (l *sessionLog) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f != nil {
+		l.f.Close()
+		l.f = nil
+	}
+}
+
+// sessionLogWriter writes the lines written to it to its log, each as soon
+// as its newline arrives, holding back a partial line until then or until
+// Flush is called.
+type sessionLogWriter struct {
+	log *sessionLog
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *sessionLogWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, b...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(b), nil
+		}
+		w.log.writeLine(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// Flush writes out the partial line held back, ending it.
+func (w *sessionLogWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.log.writeLine(w.buf)
+		w.buf = nil
+	}
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// stateLibrary is a --state choice. The pinned deps are added to the
+// install list; the store files come from the variant manifest.
+type stateLibrary struct {
+	id   string
+	deps map[string]string
+}
+
+var stateLibraries = []stateLibrary{
+	{id: "none"},
+	{id: "zustand", deps: map[string]string{"zustand": "4.5.5"}},
+	{id: "redux", deps: map[string]string{"@reduxjs/toolkit": "2.3.0", "react-redux": "9.1.2"}},
+}
+
+// lookupState resolves a --state value; "" selects none.
+func lookupState(id string) (*stateLibrary, error) {
+	if id == "" {
+		id = "none"
+	}
+	var ids []string
+	for i := range stateLibraries {
+		if stateLibraries[i].id == id {
+			return &stateLibraries[i], nil
+		}
+		ids = append(ids, stateLibraries[i].id)
+	}
+	return nil, fmt.Errorf("unknown state library %q (supported: %s)", id, strings.Join(ids, ", "))
+}
+
+// resolveState checks --state against the chosen frontend. Both libraries
+// are scaffolded with their React bindings, so they need --frontend react.
+func resolveState() error {
+	library, err := lookupState(createOpts.state)
+	if err != nil {
+		return err
+	}
+	createOpts.state = library.id
+	if library.id != "none" && frontendID() != "react" {
+		return fmt.Errorf("--state %s needs --frontend react", library.id)
+	}
+	return nil
+}
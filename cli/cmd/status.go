@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// statusLogLines is how many of the last lines of its log status shows of
+// the server.
+const statusLogLines = 5
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show how the server reavix run --detach started is doing",
+	Long: "Show the server `reavix run --detach` started, the one of .reavix/app.pid: its PID, how long\n" +
+		"it has been up, whether it listens on its port, its resident memory and the last lines of its\n" +
+		"log, and with --supervise those of the supervisor too. A pid file whose server is gone is\n" +
+		"removed rather than shown. Exits with 1 when no server is running.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		if _, err := requireProject(); err != nil {
+			return err
+		}
+
+		d, err := runningDaemon()
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			return fmt.Errorf("no server is running; `reavix run --detach` starts one")
+		}
+		fmt.Printf("The %s runs as pid %d, up %s.\n", d.name, d.pid, time.Since(d.started).Round(time.Second))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		listening, server := daemonListening(d)
+		fmt.Fprintf(w, "  port\t%s\n", listening)
+		if server != 0 && server != d.pid {
+			fmt.Fprintf(w, "  server\tpid %d, %s\n", server, residentMemory(server))
+		}
+		fmt.Fprintf(w, "  memory\t%s\n", residentMemory(d.pid))
+		if d.log != "" {
+			fmt.Fprintf(w, "  log\t%s\n", displayPath(d.log))
+		}
+		w.Flush()
+
+		if d.log == "" {
+			return nil
+		}
+		lines, err := logTail(d.log, statusLogLines)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: its log cannot be read: %v\n", err)
+			return nil
+		}
+		if len(lines) == 0 {
+			fmt.Println("It wrote nothing to its log yet.")
+			return nil
+		}
+		fmt.Printf("The last %d line(s) of its log:\n", len(lines))
+		for _, line := range lines {
+			fmt.Println("  " + line)
+		}
+		return nil
+	},
+}
+
+// daemonListening says whether the server of d listens on its port, or
+// what holds the port instead, and returns the PID of the server when it
+// listens, or 0: d itself or, under a supervisor, a process of its group.
+func daemonListening(d *daemon) (string, int) {
+	if d.port == 0 {
+		return "unknown", 0
+	}
+	port := strconv.Itoa(d.port)
+	owner := portOwner(d.port)
+	var pid int
+	if i := strings.LastIndex(owner, "(pid "); i >= 0 {
+		pid, _ = strconv.Atoi(strings.TrimSuffix(owner[i+len("(pid "):], ")"))
+	}
+	switch {
+	case pid != 0 && (pid == d.pid || inProcessGroup(pid, d.pid)):
+		return port + ", listening", pid
+	case owner != "":
+		return port + ", held by " + owner + " rather than the server", 0
+	case portListening(d.port):
+		return port + ", listening", 0
+	}
+	return port + ", not listening", 0
+}
+
+// residentMemory says how much memory the process pid has resident.
+func residentMemory(pid int) string {
+	if rss := processRSS(pid); rss >= 0 {
+		return formatSize(rss) + " resident"
+	}
+	return "unknown"
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var stopOpts struct {
+	timeout time.Duration
+}
+
+var stopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the server reavix run --detach started",
+	Long: "Stop the server `reavix run --detach` started, the one of .reavix/app.pid: send it SIGTERM,\n" +
+		"wait --timeout, 10s by default, for it to stop, then kill what is left of its process group,\n" +
+		"and remove the pid file. With --supervise, the supervisor gets the SIGTERM and stops its server\n" +
+		"first. A pid file whose server is gone already is removed too.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		if _, err := requireProject(); err != nil {
+			return err
+		}
+
+		d, err := runningDaemon()
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			fmt.Println("No server is running; `reavix run --detach` starts one.")
+			return nil
+		}
+		fmt.Printf("Stopping the %s, pid %d...\n", d.name, d.pid)
+		if err := stopDaemon(d, stopOpts.timeout); err != nil {
+			return err
+		}
+		fmt.Printf("The %s stopped.\n", d.name)
+		return nil
+	},
+}
+
+func init() {
+	stopCmd.Flags().DurationVar(&stopOpts.timeout, "timeout", 10*time.Second, "How long the server, or its supervisor, has to stop after SIGTERM before it is killed; 0 waits for it")
+	rootCmd.AddCommand(stopCmd)
+}
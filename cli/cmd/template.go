@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Work with project templates",
+}
+
+var templateVerifyOpts struct {
+	template string
+	dir      string
+}
+
+var templateVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Render every template and check the generated files",
+	Long: "Render every template against representative project data and check the output:\n" +
+		"JSON files must parse, CMakeLists.txt must define the server target and frontend\n" +
+		"sources must not contain unresolved template actions. Exits non-zero on any problem.",
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var problems []string
+		checked := 0
+
+		if templateVerifyOpts.dir != "" {
+			variant, err := loadTemplateDir(templateVerifyOpts.dir, templateVerifyOpts.dir)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			n, p := verifyTemplateDir(variant)
+			checked, problems = checked+n, append(problems, p...)
+		} else {
+			names := variantNames()
+			if templateVerifyOpts.template != "" {
+				names = []string{templateVerifyOpts.template}
+			}
+			for _, name := range names {
+				variant, err := loadVariant(name)
+				if err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
+				n, p := verifyVariant(variant)
+				checked, problems = checked+n, append(problems, p...)
+			}
+		}
+
+		for _, p := range problems {
+			fmt.Printf("  FAIL %s\n", p)
+		}
+		if len(problems) > 0 {
+			fmt.Printf("%d of %d rendered file(s) failed verification\n", len(problems), checked)
+			os.Exit(1)
+		}
+		fmt.Printf("All %d rendered file(s) passed verification\n", checked)
+	},
+}
+
+func init() {
+	templateVerifyCmd.Flags().StringVar(&templateVerifyOpts.template, "template", "", "Verify only the named built-in template")
+	templateVerifyCmd.Flags().StringVar(&templateVerifyOpts.dir, "dir", "", "Verify a local template directory laid out like a remote template")
+	templateCmd.AddCommand(templateVerifyCmd)
+	rootCmd.AddCommand(templateCmd)
+}
+
+// verifyOptions sets createOpts to render variant with the frontend, lang
+// and CSS strategy given and every optional file enabled, so each
+// conditional branch and extra template gets exercised.
+func verifyOptions(variant *projectVariant, frontend, lang, css, state, router string) {
+	createOpts = createOptions{
+		template:       variant.id,
+		frontend:       frontend,
+		lang:           lang,
+		backend:        "c",
+		strict:         variant.supports("strict"),
+		tls:            variant.supports("tls"),
+		db:             "none",
+		realtime:       "none",
+		example:        "none",
+		noTailwind:     css != "tailwind",
+		css:            css,
+		state:          state,
+		router:         router,
+		packageManager: "npm",
+		license:        "mit",
+		author:         "Reavix Developer",
+		description:    "Template verification project",
+		docker:         true,
+		ci:             "github",
+		devcontainer:   true,
+		lint:           true,
+		tests:          true,
+		storybook:      frontend == "react" && variant.supports("storybook"),
+		pwa:            true,
+		vscode:         true,
+		noOverrides:    true,
+		vars:           map[string]string{},
+	}
+	if variant.supports("db") {
+		createOpts.db = "sqlite"
+	}
+	if variant.supports("realtime") {
+		createOpts.realtime = "sse"
+	}
+	if variant.supports("example") {
+		createOpts.example = "crud"
+	}
+	for _, v := range variant.Variables {
+		value := v.Default
+		if value == "" {
+			value = "example-" + v.Name
+		}
+		createOpts.vars[v.Name] = value
+	}
+}
+
+// verifyVariant renders a built-in variant in every frontend, lang, CSS
+// strategy, state library and router combination it supports, once per --only
+// half, once with a --c-std and without --db or --example and once with the
+// C++ server; the realtime mechanisms besides sse get a pass per frontend and
+// lang. It returns the number of files checked and the problems found.
+func verifyVariant(variant *projectVariant) (int, []string) {
+	strategies := []string{"vanilla"}
+	if variant.Tailwind {
+		strategies = cssStrategies
+	}
+	states := []string{"none"}
+	if variant.supports("state") {
+		states = nil
+		for _, library := range stateLibraries {
+			states = append(states, library.id)
+		}
+	}
+	routers := []string{"none"}
+	if variant.supports("router") {
+		routers = nil
+		for _, router := range clientRouters {
+			routers = append(routers, router.id)
+		}
+	}
+
+	checked := 0
+	var problems []string
+	for i, framework := range frontendFrameworks {
+		for _, lang := range []string{"ts", "js"} {
+			for _, css := range strategies {
+				for _, state := range states {
+					for _, router := range routers {
+						if (state != "none" || router != "none") && framework.id != "react" {
+							continue
+						}
+						verifyOptions(variant, framework.id, lang, css, state, router)
+						label := fmt.Sprintf("%s [%s, %s, %s, state=%s, router=%s]", variant.id, framework.id, lang, css, state, router)
+						files := projectFiles()
+						if i == 0 && lang == "ts" && css == strategies[0] && state == states[0] && router == routers[0] {
+							// The other licenses only need rendering once.
+							for _, l := range projectLicenses[1:] {
+								files = append(files, projectFile{path: "LICENSE", template: l.template})
+							}
+						}
+						n, p := verifyFiles(label, files)
+						checked, problems = checked+n, append(problems, p...)
+					}
+				}
+			}
+		}
+	}
+
+	if variant.supports("realtime") {
+		for _, framework := range frontendFrameworks {
+			for _, lang := range []string{"ts", "js"} {
+				for _, realtime := range []string{"ws", "none"} {
+					verifyOptions(variant, framework.id, lang, strategies[0], "none", "none")
+					createOpts.realtime = realtime
+					n, p := verifyFiles(fmt.Sprintf("%s [%s, %s, --realtime %s]", variant.id, framework.id, lang, realtime), projectFiles())
+					checked, problems = checked+n, append(problems, p...)
+				}
+			}
+		}
+	}
+	for _, only := range onlyHalves {
+		verifyOptions(variant, "react", "ts", strategies[0], "none", "none")
+		createOpts.only, createOpts.docker = only, false
+		n, p := verifyFiles(fmt.Sprintf("%s [--only %s]", variant.id, only), projectFiles())
+		checked, problems = checked+n, append(problems, p...)
+	}
+	if variant.supports("c-std") {
+		verifyOptions(variant, "react", "ts", strategies[0], "none", "none")
+		createOpts.cStd, createOpts.db, createOpts.realtime, createOpts.example = "gnu17", "none", "none", "none"
+		n, p := verifyFiles(variant.id+" [--c-std gnu17]", projectFiles())
+		checked, problems = checked+n, append(problems, p...)
+	}
+	if variant.supports("backend") {
+		verifyOptions(variant, "react", "ts", strategies[0], "none", "none")
+		createOpts.backend, createOpts.db, createOpts.realtime, createOpts.example = "cpp", "none", "none", "none"
+		createOpts.tls = false
+		n, p := verifyFiles(variant.id+" [--backend cpp]", projectFiles())
+		checked, problems = checked+n, append(problems, p...)
+	}
+	return checked, problems
+}
+
+// verifyTemplateDir renders the files of a local template directory.
+func verifyTemplateDir(variant *projectVariant) (int, []string) {
+	css := "vanilla"
+	if variant.Tailwind {
+		css = "tailwind"
+	}
+	verifyOptions(variant, "react", "ts", css, "none", "none")
+	return verifyFiles(variant.id, variant.files())
+}
+
+func verifyFiles(label string, files []projectFile) (int, []string) {
+	data := newProjectData("verify-app")
+	var problems []string
+	for _, file := range files {
+		content, err := renderTemplate(file, data)
+		if err == nil && !file.raw {
+			err = checkRenderedFile(file.path, content)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s %s (%s): %v", label, file.path, file.template, err))
+		}
+	}
+	return len(files), problems
+}
+
+var (
+	serverTarget = regexp.MustCompile(`add_executable\(\s*server\b`)
+
+	// unresolvedAction matches template actions left behind in frontend
+	// sources, which use [[ ]] delimiters; see templateDelims.
+	unresolvedAction = regexp.MustCompile(`\[\[-?\s*(\.|if\b|else\b|end\b|range\b|with\b|template\b)|\{\{-?\s*(\.|if\b|else\b|end\b)`)
+)
+
+// checkRenderedFile runs the structural checks for the kind of file at path.
+func checkRenderedFile(filePath string, content []byte) error {
+	if bytes.Contains(content, []byte("<no value>")) {
+		return fmt.Errorf("renders a missing value as <no value>")
+	}
+
+	base := path.Base(filePath)
+	switch ext := filepath.Ext(base); {
+	case ext == ".json" || ext == ".webmanifest" || base == ".prettierrc":
+		var v interface{}
+		if err := json.Unmarshal(content, &v); err != nil {
+			return fmt.Errorf("invalid JSON: %v", err)
+		}
+	case base == "CMakeLists.txt":
+		if !serverTarget.Match(content) {
+			return fmt.Errorf("does not define the server executable target")
+		}
+	case frontendExts[ext]:
+		for i, line := range strings.Split(string(content), "\n") {
+			if m := unresolvedAction.FindString(line); m != "" {
+				return fmt.Errorf("unresolved template action %q on line %d", m, i+1)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// cbreakTerminal makes the terminal f hand over each key as it is pressed,
+// without echoing it, through stty. Ctrl+C still interrupts, and output is
+// left as it was. restore puts the terminal back the way it was.
+func cbreakTerminal(f *os.File) (restore func(), err error) {
+	stty := func(args ...string) ([]byte, error) {
+		c := exec.Command("stty", args...)
+		c.Stdin = f
+		return c.Output()
+	}
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("-icanon", "-echo", "min", "1", "time", "0"); err != nil {
+		return nil, err
+	}
+	return func() { stty(strings.TrimSpace(string(saved))) }, nil
+}
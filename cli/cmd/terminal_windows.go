@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"os"
+	"syscall"
+)
+
+// The console modes cbreakTerminal turns off.
+const (
+	enableLineInput = 0x2
+	enableEchoInput = 0x4
+)
+
+var setConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+// cbreakTerminal makes the console f hand over each key as it is pressed,
+// without echoing it. Ctrl+C still interrupts, and output is left as it
+// was. restore puts the console back the way it was.
+func cbreakTerminal(f *os.File) (restore func(), err error) {
+	h := syscall.Handle(f.Fd())
+	var mode uint32
+	if err := syscall.GetConsoleMode(h, &mode); err != nil {
+		return nil, err
+	}
+	if r, _, err := setConsoleMode.Call(uintptr(h), uintptr(mode&^(enableLineInput|enableEchoInput))); r == 0 {
+		return nil, err
+	}
+	return func() { setConsoleMode.Call(uintptr(h), uintptr(mode)) }, nil
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// reservedNames collide with directories the scaffold or the CLI itself uses.
+var reservedNames = map[string]bool{
+	"app":          true,
+	"build":        true,
+	"server":       true,
+	"script":       true,
+	"static":       true,
+	"node_modules": true,
+	"favicon.ico":  true,
+}
+
+var (
+	invalidNameChars = regexp.MustCompile(`[^a-z0-9._~-]`)
+	repeatedDashes   = regexp.MustCompile(`-{2,}`)
+)
+
+// validateAppName checks that name is usable as a directory, an npm package
+// name and a CMake project name. The returned error names the failed rule and
+// suggests a sanitized alternative where one exists.
+func validateAppName(name string) error {
+	var rule string
+	switch {
+	case name == "":
+		return fmt.Errorf("app name must not be empty")
+	case strings.ContainsAny(name, `/\`):
+		rule = "must not contain path separators"
+	case strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_"):
+		rule = "must not start with a dot or underscore"
+	case strings.ContainsAny(name, " \t\n"):
+		rule = "must not contain spaces"
+	case strings.ToLower(name) != name:
+		rule = "must be lowercase (npm package names cannot contain capital letters)"
+	case len(name) > 214:
+		rule = "must be at most 214 characters long"
+	case invalidNameChars.MatchString(name):
+		rule = "may only contain lowercase letters, digits, '-', '.', '_' and '~'"
+	case reservedNames[name]:
+		return fmt.Errorf("invalid app name %q: %q is reserved by Reavix, choose a different name (for example %q)", name, name, "my-"+name)
+	default:
+		return nil
+	}
+
+	if suggestion := sanitizeAppName(name); suggestion != "" {
+		return fmt.Errorf("invalid app name %q: name %s; try %q instead", name, rule, suggestion)
+	}
+	return fmt.Errorf("invalid app name %q: name %s", name, rule)
+}
+
+// sanitizeAppName derives a valid app name from name, or returns "" when
+// nothing usable is left.
+func sanitizeAppName(name string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.Join(strings.Fields(name), "-")
+	name = invalidNameChars.ReplaceAllString(name, "-")
+	name = repeatedDashes.ReplaceAllString(name, "-")
+	name = strings.TrimLeft(name, "._-")
+	name = strings.TrimRight(name, "-")
+	if len(name) > 214 {
+		name = name[:214]
+	}
+	if reservedNames[name] {
+		name = "my-" + name
+	}
+	if validateAppName(name) != nil {
+		return ""
+	}
+	return name
+}
+
+// ignoredEntries may already exist in a target directory without it counting
+// as non-empty, so scaffolding into a fresh GitHub repository works.
+var ignoredEntries = map[string]bool{
+	".git":       true,
+	".gitignore": true,
+	"LICENSE":    true,
+	"README.md":  true,
+}
+
+// preservedFiles are scaffold files that are left alone when the target
+// directory already has its own copy.
+var preservedFiles = map[string]bool{
+	".gitignore": true,
+	"LICENSE":    true,
+	"README.md":  true,
+}
+
+// ensureEmptyTarget fails when dir exists and already has content in it
+// other than the entries in ignoredEntries.
+func ensureEmptyTarget(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var found []string
+	for _, entry := range entries {
+		if !ignoredEntries[entry.Name()] {
+			found = append(found, entry.Name())
+		}
+	}
+	if len(found) > 0 {
+		return fmt.Errorf("directory %s already exists and is not empty (found %s)", dir, strings.Join(found, ", "))
+	}
+	return nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,344 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Reavix-framework/cli/internal/project"
+	"github.com/Reavix-framework/cli/templates"
+)
+
+// defaultVariant is scaffolded when --template is not given.
+const defaultVariant = "full"
+
+// variantManifest lists the files of a variant directory in templates.FS and
+// variantMeta describes the variant for --list-templates.
+const (
+	variantManifest = "manifest.json"
+	variantMeta     = "meta.json"
+)
+
+// optionalCreateFlags are the create flags a template may or may not support.
+var optionalCreateFlags = []string{"lang", "backend", "c-std", "strict", "tls", "db", "frontend", "no-tailwind", "css", "state", "router", "realtime", "example", "license", "docker", "ci", "devcontainer", "lint", "tests", "storybook", "pwa", "vscode"}
+
+// noneableFlags are the optional create flags whose "none" value adds
+// nothing to the project, so every template accepts it.
+var noneableFlags = []string{"state", "router", "db", "realtime", "example"}
+
+// templateMeta is the metadata every template carries: meta.json for
+// built-in variants, reavix-template.json for remote ones.
+type templateMeta struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Stack       string   `json:"stack"`
+	Tags        []string `json:"tags"`
+	// Flags lists the optional create flags the template supports; nil
+	// means all of them.
+	Flags      []string `json:"flags"`
+	MinVersion string   `json:"minVersion"`
+}
+
+// validate checks the metadata of the template known as id.
+func (m *templateMeta) validate(id string) error {
+	if m.Description == "" {
+		return fmt.Errorf("template %s: metadata needs a description", id)
+	}
+	for _, flag := range m.Flags {
+		if !containsString(optionalCreateFlags, flag) {
+			return fmt.Errorf("template %s: unknown flag %q in metadata (known: %s)", id, flag, strings.Join(optionalCreateFlags, ", "))
+		}
+	}
+	if m.MinVersion != "" && !versionAtLeast(version, m.MinVersion) {
+		return fmt.Errorf("template %s needs reavix %s or newer (this is %s)", id, m.MinVersion, version)
+	}
+	return nil
+}
+
+// supports reports whether the template declares support for flag.
+func (m *templateMeta) supports(flag string) bool {
+	return m.Flags == nil || containsString(m.Flags, flag)
+}
+
+// projectVariant is a project layout selected with --template, either a
+// built-in variant or a remote template repository.
+type projectVariant struct {
+	templateMeta
+
+	// id is the --template value the variant was loaded from.
+	id       string
+	Tailwind bool          `json:"tailwind"`
+	Dirs     []string      `json:"dirs"`
+	Files    []variantFile `json:"files"`
+
+	// Variables and PostCreate are declared by remote templates; see
+	// remoteManifest. Hooks, also declared by them, are written into the
+	// reavix.json of the projects they create.
+	Variables  []templateVariable `json:"variables"`
+	PostCreate []string           `json:"postCreate"`
+	Hooks      *project.Hooks     `json:"hooks"`
+
+	// fsys holds the templates the files are rendered from. It is nil for
+	// built-in variants, which live in templates.FS.
+	fsys fs.FS
+}
+
+// variantFile is one manifest entry. Lang restricts the file to the ts or
+// js frontend, Backend to the c or cpp server, Frontends to the listed UI
+// frameworks, CSS to one styling strategy, State to one state library,
+// Routers to the listed routers, DB to one --db database, Realtimes to the
+// listed --realtime mechanisms, Example to one --example and TLS to --tls
+// projects.
+type variantFile struct {
+	Path      string   `json:"path"`
+	Template  string   `json:"template"`
+	Lang      string   `json:"lang,omitempty"`
+	Backend   string   `json:"backend,omitempty"`
+	Frontends []string `json:"frontends,omitempty"`
+	CSS       string   `json:"css,omitempty"`
+	State     string   `json:"state,omitempty"`
+	Routers   []string `json:"routers,omitempty"`
+	DB        string   `json:"db,omitempty"`
+	Realtimes []string `json:"realtimes,omitempty"`
+	Example   string   `json:"example,omitempty"`
+	TLS       bool     `json:"tls,omitempty"`
+
+	// raw marks remote template files without a .tmpl suffix, which are
+	// copied verbatim.
+	raw bool
+}
+
+// variantNames lists the directories of templates.FS that carry a manifest.
+func variantNames() []string {
+	entries, err := fs.ReadDir(templates.FS, ".")
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if _, err := fs.Stat(templates.FS, path.Join(e.Name(), variantManifest)); err == nil {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadedVariants memoizes loadVariant so a remote template is fetched once
+// per run.
+var loadedVariants = map[string]*projectVariant{}
+
+// loadVariant reads and checks the manifest of the named variant. Git URLs
+// are cloned into the template cache first.
+func loadVariant(name string) (*projectVariant, error) {
+	if name == "" {
+		name = defaultVariant
+	}
+	if variant, ok := loadedVariants[name]; ok {
+		return variant, nil
+	}
+
+	var variant *projectVariant
+	var err error
+	if isRemoteTemplate(name) {
+		variant, err = loadRemoteVariant(name)
+	} else {
+		variant, err = loadBuiltinVariant(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	loadedVariants[name] = variant
+	return variant, nil
+}
+
+func loadBuiltinVariant(name string) (*projectVariant, error) {
+	raw, err := fs.ReadFile(templates.FS, path.Join(name, variantManifest))
+	if err != nil || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("unknown template %q (available: %s)", name, strings.Join(variantNames(), ", "))
+	}
+
+	variant := &projectVariant{id: name}
+	if err := json.Unmarshal(raw, variant); err != nil {
+		return nil, fmt.Errorf("template %s: invalid %s: %w", name, variantManifest, err)
+	}
+	meta, err := builtinMeta(name)
+	if err != nil {
+		return nil, err
+	}
+	variant.templateMeta = *meta
+	for _, f := range variant.Files {
+		if f.Path == "" || f.Template == "" {
+			return nil, fmt.Errorf("template %s: manifest entry needs both a path and a template", name)
+		}
+		if f.Lang != "" && f.Lang != "ts" && f.Lang != "js" {
+			return nil, fmt.Errorf("template %s: %s has unknown lang %q", name, f.Path, f.Lang)
+		}
+		if f.Backend != "" && f.Backend != "c" && f.Backend != "cpp" {
+			return nil, fmt.Errorf("template %s: %s has unknown backend %q", name, f.Path, f.Backend)
+		}
+		if f.CSS != "" && !containsString(cssStrategies, f.CSS) {
+			return nil, fmt.Errorf("template %s: %s has unknown css %q", name, f.Path, f.CSS)
+		}
+		if f.State != "" {
+			if _, err := lookupState(f.State); err != nil {
+				return nil, fmt.Errorf("template %s: %s: %w", name, f.Path, err)
+			}
+		}
+		for _, id := range f.Routers {
+			if _, err := lookupRouter(id); err != nil {
+				return nil, fmt.Errorf("template %s: %s: %w", name, f.Path, err)
+			}
+		}
+		if f.DB != "" && !containsString(serverDatabases, f.DB) {
+			return nil, fmt.Errorf("template %s: %s has unknown db %q", name, f.Path, f.DB)
+		}
+		for _, id := range f.Realtimes {
+			if _, err := lookupRealtime(id); err != nil {
+				return nil, fmt.Errorf("template %s: %s: %w", name, f.Path, err)
+			}
+		}
+		for _, id := range f.Frontends {
+			if _, err := lookupFrontend(id); err != nil {
+				return nil, fmt.Errorf("template %s: %s: %w", name, f.Path, err)
+			}
+		}
+		if _, err := fs.Stat(templates.FS, f.Template); err != nil {
+			return nil, fmt.Errorf("template %s: %s references missing template %s", name, f.Path, f.Template)
+		}
+	}
+	return variant, nil
+}
+
+// files returns the manifest entries that apply to the current options.
+func (v *projectVariant) files() []projectFile {
+	var files []projectFile
+	for _, f := range v.Files {
+		if f.Lang != "" && f.Lang != createOpts.lang {
+			continue
+		}
+		if f.Backend != "" && f.Backend != createOpts.backend {
+			continue
+		}
+		if f.Frontends != nil && !containsString(f.Frontends, frontendID()) {
+			continue
+		}
+		if f.CSS != "" && f.CSS != createOpts.css {
+			continue
+		}
+		if f.State != "" && f.State != createOpts.state {
+			continue
+		}
+		if f.Routers != nil && !containsString(f.Routers, createOpts.router) {
+			continue
+		}
+		if f.DB != "" && f.DB != createOpts.db {
+			continue
+		}
+		if f.Realtimes != nil && !containsString(f.Realtimes, createOpts.realtime) {
+			continue
+		}
+		if f.Example != "" && f.Example != createOpts.example {
+			continue
+		}
+		if f.TLS && !createOpts.tls {
+			continue
+		}
+		files = append(files, projectFile{path: f.Path, template: f.Template, fsys: v.fsys, raw: f.raw})
+	}
+	return files
+}
+
+// builtinMeta reads and validates the meta.json of a built-in variant.
+func builtinMeta(name string) (*templateMeta, error) {
+	raw, err := fs.ReadFile(templates.FS, path.Join(name, variantMeta))
+	if err != nil {
+		return nil, fmt.Errorf("template %s: missing %s", name, variantMeta)
+	}
+	meta := &templateMeta{}
+	if err := json.Unmarshal(raw, meta); err != nil {
+		return nil, fmt.Errorf("template %s: invalid %s: %w", name, variantMeta, err)
+	}
+	if meta.Name != name {
+		return nil, fmt.Errorf("template %s: %s names it %q", name, variantMeta, meta.Name)
+	}
+	if err := meta.validate(name); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// checkVariantFlags rejects optional flags the chosen template does not
+// support. --no-tailwind and --css vanilla are always accepted by templates
+// without Tailwind, and --backend c and the none value of noneableFlags by
+// every template, since that is what they scaffold anyway.
+func checkVariantFlags(cmd *cobra.Command, variant *projectVariant) error {
+	for _, flag := range optionalCreateFlags {
+		if !cmd.Flags().Changed(flag) || variant.supports(flag) {
+			continue
+		}
+		if flag == "no-tailwind" && !variant.Tailwind {
+			continue
+		}
+		if flag == "css" && createOpts.css == "vanilla" && !variant.Tailwind {
+			continue
+		}
+		if flag == "backend" && createOpts.backend == "c" {
+			continue
+		}
+		if containsString(noneableFlags, flag) && cmd.Flags().Lookup(flag).Value.String() == "none" {
+			continue
+		}
+		return fmt.Errorf("template %s does not support --%s", variant.id, flag)
+	}
+	return nil
+}
+
+// versionAtLeast compares dotted version numbers, ignoring a leading "v" and
+// any pre-release suffix.
+func versionAtLeast(have, want string) bool {
+	parse := func(v string) []int {
+		v = strings.TrimPrefix(v, "v")
+		if i := strings.IndexAny(v, "-+"); i >= 0 {
+			v = v[:i]
+		}
+		var parts []int
+		for _, p := range strings.Split(v, ".") {
+			n, _ := strconv.Atoi(p)
+			parts = append(parts, n)
+		}
+		return parts
+	}
+	h, w := parse(have), parse(want)
+	for i := 0; i < len(h) || i < len(w); i++ {
+		var a, b int
+		if i < len(h) {
+			a = h[i]
+		}
+		if i < len(w) {
+			b = w[i]
+		}
+		if a != b {
+			return a > b
+		}
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
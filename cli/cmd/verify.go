@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <dir>",
+	Short: "Check build artifacts against their manifest",
+	Long: "Hash the files in dir, the output directory of `reavix build` or an unpacked `reavix package`\n" +
+		"archive, again and compare them with the sizes and SHA-256 hashes its " + artifactManifestFile + " lists.\n" +
+		"Every file that was modified or removed, or added to static/, is printed, and the command then\n" +
+		"fails. `reavix run --verify` does the same before it starts the server.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return checkArtifacts(args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+}
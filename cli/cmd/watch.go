@@ -0,0 +1,358 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Reavix-framework/cli/internal/project"
+	utils "github.com/Reavix-framework/cli/internal/utils"
+)
+
+// How often build --watch looks for changes, and how long the sources must
+// then stay unchanged before it rebuilds, so that saving several files at
+// once builds once.
+const (
+	watchInterval = 300 * time.Millisecond
+	watchDebounce = 500 * time.Millisecond
+)
+
+// watchedFile is what a watch snapshot knows of a file. The hash of the
+// contents tells an edit from a file rewritten as it was, like the sources
+// a preBuild hook generates on every build.
+type watchedFile struct {
+	size    int64
+	modTime time.Time
+	sum     [sha256.Size]byte
+}
+
+type watchSnapshot map[string]watchedFile
+
+// watchDirs are the directories build --watch watches with all they hold.
+func watchDirs(m *project.Manifest) []string {
+	var dirs []string
+	if m.HasFrontend() {
+		dirs = append(dirs, filepath.Join(m.Frontend, "src"), filepath.Join(m.Frontend, "public"))
+	}
+	if m.HasBackend() {
+		dirs = append(dirs, filepath.Join(m.Backend, "src"), filepath.Join(m.Backend, "include"))
+	}
+	return dirs
+}
+
+// isWatchedConfig reports whether name, a file directly in the frontend or
+// the server directory, configures its build. Vite's temporary
+// vite.config.*.timestamp-* files are left out, as every build writes one.
+func isWatchedConfig(name string, frontend bool) bool {
+	if strings.HasPrefix(name, ".env") {
+		return true
+	}
+	if !frontend {
+		return isCMakeConfig(name)
+	}
+	switch name {
+	case "index.html", "package.json", "package-lock.json", "pnpm-lock.yaml", "yarn.lock", "bun.lock", "bun.lockb":
+		return true
+	}
+	if strings.HasPrefix(name, "tsconfig") && strings.HasSuffix(name, ".json") {
+		return true
+	}
+	return strings.Contains(name, ".config.") && !strings.Contains(name, ".timestamp-")
+}
+
+// isCMakeConfig reports whether name, a file directly in the server
+// directory, is read by the CMake configure step.
+func isCMakeConfig(name string) bool {
+	return name == "CMakeLists.txt" || name == cmakePresetsFile || name == cmakeUserPresetsFile || strings.HasSuffix(name, ".cmake")
+}
+
+// scanWatched snapshots the files build --watch watches: reavix.json, the
+// watchDirs and the configs of both halves, but those settings ignores.
+// Files whose size and time match prev are not read again. Missing
+// directories are skipped.
+func scanWatched(m *project.Manifest, settings *watchSettings, prev watchSnapshot) (watchSnapshot, error) {
+	snap := watchSnapshot{}
+	if info, err := os.Stat(project.FileName); err == nil {
+		if err := snap.add(project.FileName, info, prev); err != nil {
+			return nil, err
+		}
+	}
+	if m.HasFrontend() {
+		if err := snap.addConfigs(m.Frontend, func(name string) bool { return isWatchedConfig(name, true) }, settings.ignored, prev); err != nil {
+			return nil, err
+		}
+	}
+	if m.HasBackend() {
+		if err := snap.addConfigs(m.Backend, func(name string) bool { return isWatchedConfig(name, false) }, settings.ignored, prev); err != nil {
+			return nil, err
+		}
+	}
+	for _, dir := range watchDirs(m) {
+		if err := snap.addTree(dir, settings.ignored, prev); err != nil {
+			return nil, err
+		}
+	}
+	return snap, nil
+}
+
+// add records the file at path, reusing its entry in prev when its size and
+// time have not changed.
+func (snap watchSnapshot) add(path string, info fs.FileInfo, prev watchSnapshot) error {
+	if old, ok := prev[path]; ok && old.size == info.Size() && old.modTime.Equal(info.ModTime()) {
+		snap[path] = old
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	snap[path] = watchedFile{size: info.Size(), modTime: info.ModTime(), sum: sha256.Sum256(content)}
+	return nil
+}
+
+// addConfigs records the files directly in dir whose names match, unless
+// ignored.
+func (snap watchSnapshot) addConfigs(dir string, match func(name string) bool, ignored func(path string) bool, prev watchSnapshot) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, e := range entries {
+		if !e.Type().IsRegular() || !match(e.Name()) || ignored(filepath.Join(dir, e.Name())) {
+			continue
+		}
+		info, err := e.Info()
+		if err == nil {
+			err = snap.add(filepath.Join(dir, e.Name()), info, prev)
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// addTree records every file in dir but the ignored ones, without going
+// into ignored directories.
+func (snap watchSnapshot) addTree(dir string, ignored func(path string) bool, prev watchSnapshot) error {
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && ignored(path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return snap.add(path, info, prev)
+	})
+	// A file deleted while it was walked turns up in the next scan.
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// changedFiles lists the files added, removed or edited from old to cur.
+func changedFiles(old, cur watchSnapshot) []string {
+	var changed []string
+	for path, f := range cur {
+		if o, ok := old[path]; !ok || !bytes.Equal(o.sum[:], f.sum[:]) {
+			changed = append(changed, path)
+		}
+	}
+	for path := range old {
+		if _, ok := cur[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// logTriggers lists, with --verbose, every path that triggered a rebuild,
+// for tuning watch.ignore of reavix.json.
+func logTriggers(paths []string) {
+	if !verbose {
+		return
+	}
+	for _, path := range paths {
+		fmt.Printf("Changed: %s\n", filepath.ToSlash(path))
+	}
+}
+
+// describeChanges names what triggered a rebuild, like "app/src/App.tsx
+// and 2 more".
+func describeChanges(paths []string) string {
+	first := filepath.ToSlash(paths[0])
+	if len(paths) == 1 {
+		return first
+	}
+	return fmt.Sprintf("%s and %d more", first, len(paths)-1)
+}
+
+// watchBuild runs cmd, build, then rebuilds whenever a watched file changes
+// until interrupted. Every build goes into a staging directory next to the
+// output directory, seeded with its contents, which then takes the output
+// directory's place with a rename, so no one reading it sees a half-copied
+// static/. The build's own hashes keep each rebuild to the halves that
+// changed. Builds print one line each, unless --verbose.
+func watchBuild(cmd *cobra.Command) error {
+	if buildOpts.andRun {
+		return fmt.Errorf("--watch cannot be combined with --and-run")
+	}
+	// --out is relative to where the command was started, so it is
+	// resolved before requireProject changes into the project root.
+	flagOut, err := absPathFlag(buildOpts.out)
+	if err != nil {
+		return err
+	}
+	m, err := requireProject()
+	if err != nil {
+		return err
+	}
+	outDir := flagOut
+	if outDir == "" {
+		if outDir, err = filepath.Abs(m.OutDir); err != nil {
+			return err
+		}
+	}
+	cmd.SilenceUsage = true
+	staging := filepath.Join(filepath.Dir(outDir), "."+filepath.Base(outDir)+".reavix-next")
+	old := filepath.Join(filepath.Dir(outDir), "."+filepath.Base(outDir)+".reavix-old")
+
+	saved := buildOpts
+	defer func() { buildOpts = saved }()
+	buildOpts.watch, buildOpts.out = false, staging
+
+	// Ctrl+C stops the build in flight, but never between the two renames
+	// that put a finished one in place.
+	var swapping sync.Mutex
+	stdout := os.Stdout
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupted
+		running.killAll()
+		swapping.Lock()
+		os.RemoveAll(staging)
+		fmt.Fprintln(stdout, "\nStopped watching.")
+		os.Exit(0)
+	}()
+
+	build := func(quiet bool) error {
+		if err := os.RemoveAll(staging); err != nil {
+			return err
+		}
+		if fileExists(outDir) {
+			if err := utils.CopyDir(outDir, staging); err != nil {
+				return fmt.Errorf("staging %s: %w", displayPath(outDir), err)
+			}
+		}
+		if quiet {
+			// Only the summary line, warnings and failures are shown.
+			if devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+				saved := stdOutput
+				os.Stdout, stdOutput.stdout = devNull, devNull
+				defer func() { os.Stdout, stdOutput = stdout, saved; devNull.Close() }()
+			}
+		}
+		if err := cmd.RunE(cmd, nil); err != nil {
+			os.RemoveAll(staging)
+			return err
+		}
+		swapping.Lock()
+		defer swapping.Unlock()
+		if err := os.RemoveAll(old); err != nil {
+			return err
+		}
+		if fileExists(outDir) {
+			if err := os.Rename(outDir, old); err != nil {
+				return fmt.Errorf("replacing %s: %w", displayPath(outDir), err)
+			}
+		}
+		if err := os.Rename(staging, outDir); err != nil {
+			os.Rename(old, outDir)
+			return fmt.Errorf("replacing %s: %w", displayPath(outDir), err)
+		}
+		return os.RemoveAll(old)
+	}
+
+	settings := newWatchSettings(m, watchDebounce)
+	snap, err := scanWatched(m, settings, nil)
+	if err != nil {
+		return err
+	}
+	started := time.Now()
+	if err := build(!verbose); err != nil {
+		fmt.Printf("[%s] Build failed after %.1fs: %v\n", started.Format("15:04:05"), time.Since(started).Seconds(), err)
+	} else {
+		fmt.Printf("[%s] Built in %.1fs\n", started.Format("15:04:05"), time.Since(started).Seconds())
+	}
+	// --clean and --force are for the first build; the next ones reuse
+	// what did not change.
+	buildOpts.clean, buildOpts.force = false, false
+	var watched []string
+	for _, dir := range watchDirs(m) {
+		watched = append(watched, filepath.ToSlash(dir))
+	}
+	fmt.Printf("Watching %s and the configs for changes; press Ctrl+C to stop.\n", strings.Join(watched, ", "))
+
+	pending := map[string]bool{}
+	var lastChange time.Time
+	for {
+		time.Sleep(watchInterval)
+		settings.refresh()
+		cur, err := scanWatched(m, settings, snap)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not scan for changes: %v\n", err)
+			continue
+		}
+		if changed := changedFiles(snap, cur); len(changed) > 0 {
+			for _, path := range changed {
+				pending[path] = true
+			}
+			lastChange = time.Now()
+		}
+		snap = cur
+		if len(pending) == 0 || time.Since(lastChange) < settings.debounce {
+			continue
+		}
+
+		var paths []string
+		for path := range pending {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		pending = map[string]bool{}
+		logTriggers(paths)
+		started = time.Now()
+		err = build(!verbose)
+		stamp := started.Format("15:04:05")
+		took := time.Since(started).Seconds()
+		if err != nil {
+			fmt.Printf("[%s] Build failed after %.1fs (%s changed): %v\n", stamp, took, describeChanges(paths), err)
+		} else {
+			fmt.Printf("[%s] Rebuilt in %.1fs (%s changed)\n", stamp, took, describeChanges(paths))
+		}
+	}
+}
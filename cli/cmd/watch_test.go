@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Reavix-framework/cli/internal/project"
+)
+
+func TestWatchIgnoresScaffoldedPaths(t *testing.T) {
+	root, m := scaffoldProject(t)
+	m.Watch = &project.Watch{Ignore: []string{"node_modules", ".reavix/**", "./app/src/generated/**"}}
+	settings := newWatchSettings(m, time.Second)
+
+	scan := func(prev watchSnapshot) watchSnapshot {
+		t.Helper()
+		snap, err := scanWatched(m, settings, prev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return snap
+	}
+	server := func(prev watchSnapshot) watchSnapshot {
+		t.Helper()
+		snap, err := scanServer(m, settings, prev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return snap
+	}
+	before, serverBefore := scan(nil), server(nil)
+	if len(before) == 0 || len(serverBefore) == 0 {
+		t.Fatal("the scaffolded project has no watched files")
+	}
+
+	ignored := map[string]string{
+		"app/node_modules/x/index.js":     "1\n",
+		"app/src/node_modules/x/index.js": "1\n",
+		"app/src/generated/api.ts":        "export {}\n",
+		"app/src/App.tsx~":                "backup\n",
+		"server/build/CMakeCache.txt":     "cache\n",
+		"server/build/main.o":             "obj\n",
+		"server/src/main.o":               "obj\n",
+		"server/compile_commands.json":    "[]\n",
+		".reavix/state.json":              "{}\n",
+		"build/reavix-app":                "binary\n",
+	}
+	writeFiles(t, root, ignored)
+	if changed := changedFiles(before, scan(before)); len(changed) != 0 {
+		t.Errorf("build --watch sees ignored changes: %v", changed)
+	}
+	if changed := changedFiles(serverBefore, server(serverBefore)); len(changed) != 0 {
+		t.Errorf("dev sees ignored server changes: %v", changed)
+	}
+
+	watched := map[string]string{
+		"server/src/extra.c":        "int extra(void) { return 1; }\n",
+		"server/include/extra.h":    "int extra(void);\n",
+		"server/CMakeLists.txt":     "project(app C)\n",
+		"app/src/components/Xy.tsx": "export {}\n",
+	}
+	writeFiles(t, root, watched)
+	want := []string{
+		filepath.FromSlash("app/src/components/Xy.tsx"),
+		filepath.FromSlash("server/CMakeLists.txt"),
+		filepath.FromSlash("server/include/extra.h"),
+		filepath.FromSlash("server/src/extra.c"),
+	}
+	if changed := changedFiles(before, scan(before)); !reflect.DeepEqual(changed, want) {
+		t.Errorf("build --watch sees %v, want %v", changed, want)
+	}
+	if changed := changedFiles(serverBefore, server(serverBefore)); !reflect.DeepEqual(changed, want[1:]) {
+		t.Errorf("dev sees server changes %v, want %v", changed, want[1:])
+	}
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Reavix-framework/cli/internal/project"
+)
+
+// defaultWatchIgnore are the paths the watchers always ignore, on top of
+// the server's build directory and watch.ignore of reavix.json: objects,
+// compile_commands.json and the lock, backup and swap files of Emacs and
+// Vim.
+var defaultWatchIgnore = []string{"**/*.o", "**/.#*", "**/#*#", "**/*~", "**/*.swp", "**/*.swo", "**/*.swx", "**/4913", "**/compile_commands.json"}
+
+// watchSettings are the ignore patterns and debounce of the watchers, from
+// the watch section of reavix.json. They are read again when reavix.json
+// changes, so a change to them applies without restarting the watcher.
+type watchSettings struct {
+	backend    string
+	defDelay   time.Duration
+	debounce   time.Duration
+	ignore     []string
+	configTime time.Time
+}
+
+// newWatchSettings returns the watch settings of m, whose debounce is def
+// unless watch.debounceMs sets it.
+func newWatchSettings(m *project.Manifest, def time.Duration) *watchSettings {
+	w := &watchSettings{backend: filepath.ToSlash(filepath.Clean(m.Backend)), defDelay: def}
+	if info, err := os.Stat(project.FileName); err == nil {
+		w.configTime = info.ModTime()
+	}
+	w.apply(m)
+	return w
+}
+
+// apply takes the settings of m, warning about the patterns that are not
+// valid globs, which are left out.
+func (w *watchSettings) apply(m *project.Manifest) {
+	w.debounce = w.defDelay
+	w.ignore = append([]string{w.backend + "/build/**"}, defaultWatchIgnore...)
+	if m.Watch == nil {
+		return
+	}
+	if m.Watch.DebounceMs > 0 {
+		w.debounce = time.Duration(m.Watch.DebounceMs) * time.Millisecond
+	}
+	for _, pattern := range m.Watch.Ignore {
+		if _, err := path.Match(pattern, ""); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: watch.ignore pattern %q in %s is not a valid glob, so it is left out.\n", pattern, project.FileName)
+			continue
+		}
+		w.ignore = append(w.ignore, strings.TrimPrefix(pattern, "./"))
+	}
+}
+
+// refresh reads the watch section of reavix.json again when reavix.json
+// changed since it was last read, and reports whether it was.
+func (w *watchSettings) refresh() bool {
+	info, err := os.Stat(project.FileName)
+	if err != nil || info.ModTime().Equal(w.configTime) {
+		return false
+	}
+	w.configTime = info.ModTime()
+	m, err := project.Load(".")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not read the watch settings again: %v\n", err)
+		return false
+	}
+	w.apply(m)
+	if verbose {
+		fmt.Printf("Read the watch settings of %s again.\n", project.FileName)
+	}
+	return true
+}
+
+// ignored reports whether a change to p, a path relative to the project
+// root, is ignored.
+func (w *watchSettings) ignored(p string) bool {
+	p = filepath.ToSlash(p)
+	for _, pattern := range w.ignore {
+		if matchGlob(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob reports whether pattern, a glob of / separated segments in the
+// syntax of path.Match, matches the path p, where a ** segment matches any
+// number of directories. A pattern without a / matches the name of a file
+// or directory anywhere, as in .gitignore.
+func matchGlob(pattern, p string) bool {
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(p, "/"))
+}
+
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
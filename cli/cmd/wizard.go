@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// runCreateWizard interactively collects the answers normally given as
+// arguments and flags to create. It fills createOpts and returns the target
+// argument.
+func runCreateWizard() (string, error) {
+	p := newPrompter()
+	fmt.Println("Create a new Reavix application")
+
+	target, err := p.askString("Project name", "my-reavix-app", func(answer string) error {
+		_, name, err := resolveTarget(answer)
+		if err != nil {
+			return err
+		}
+		return validateAppName(name)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	description, err := p.askString("Description (optional)", createOpts.description, nil)
+	if err != nil {
+		return "", err
+	}
+	createOpts.description = description
+
+	install, err := p.askBool("Install frontend dependencies now?", !createOpts.noInstall)
+	if err != nil {
+		return "", err
+	}
+	createOpts.noInstall = !install
+
+	if install {
+		def := createOpts.packageManager
+		if def == "" {
+			def = detectPackageManager(".")
+		}
+		pm, err := p.askChoice("Package manager", packageManagers, def)
+		if err != nil {
+			return "", err
+		}
+		createOpts.packageManager = pm
+	}
+
+	git, err := p.askBool("Initialize a git repository?", createOpts.git)
+	if err != nil {
+		return "", err
+	}
+	createOpts.git = git
+
+	fmt.Printf("\nEquivalent command:\n  %s\n\n", equivalentCreateCommand(target))
+	return target, nil
+}
+
+// equivalentCreateCommand renders the non-interactive form of the current
+// options so a wizard run can be scripted later.
+func equivalentCreateCommand(target string) string {
+	parts := []string{"reavix", "create", target}
+	if createOpts.description != "" {
+		parts = append(parts, "--description", strconv.Quote(createOpts.description))
+	}
+	if createOpts.noInstall {
+		parts = append(parts, "--no-install")
+	}
+	if createOpts.packageManager != "" {
+		parts = append(parts, "--pm", createOpts.packageManager)
+	}
+	if createOpts.git {
+		parts = append(parts, "--git")
+	} else {
+		parts = append(parts, "--git=false")
+	}
+	return strings.Join(parts, " ")
+}
@@ -3,7 +3,8 @@ module github.com/Reavix-framework/cli
 go 1.18
 
 require (
-	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/cobra v1.9.1 // indirect
-	github.com/spf13/pflag v1.0.6 // indirect
+	github.com/spf13/cobra v1.9.1
+	github.com/spf13/pflag v1.0.6
 )
+
+require github.com/inconshreveable/mousetrap v1.1.0 // indirect
@@ -0,0 +1,166 @@
+// Package logs interleaves the output of commands running side by side, like
+// the frontend and the server of a project, one whole line at a time, each
+// line behind the name of the command it came from.
+package logs
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+)
+
+// Color is the ANSI escape sequence a stream's name is printed in.
+type Color string
+
+// The colors of the streams; Cyan is the frontend's, Magenta the server's
+// and Yellow that of the mock API standing in for the server.
+const (
+	Cyan    Color = "\x1b[36m"
+	Magenta Color = "\x1b[35m"
+	Yellow  Color = "\x1b[33m"
+
+	red   = "\x1b[31m"
+	reset = "\x1b[0m"
+)
+
+// ColorEnabled reports whether output to f may be colored: f is a terminal,
+// noColor, the --no-color flag, is false and NO_COLOR is not set, as
+// no-color.org asks.
+func ColorEnabled(f *os.File, noColor bool) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Mux writes the lines of its streams to stdout and stderr. Lines of
+// different streams never run into each other.
+type Mux struct {
+	mu             sync.Mutex
+	stdout, stderr io.Writer
+	color          bool
+	only           string
+}
+
+// NewMux returns a Mux writing to stdout and stderr, coloring the names of
+// the streams when color is true. Only the stream named only is shown,
+// unless only is "".
+func NewMux(stdout, stderr io.Writer, color bool, only string) *Mux {
+	return &Mux{stdout: stdout, stderr: stderr, color: color, only: only}
+}
+
+// Stream is the output of one command: its lines start with "[name] ", and
+// those written to Stderr with "[name]! " and, in color, are red, so they
+// stand out from the rest.
+type Stream struct {
+	Stdout, Stderr *Writer
+}
+
+// Stream returns the stream called name, like "app", printed in color.
+func (m *Mux) Stream(name string, color Color) *Stream {
+	label := "[" + name + "]"
+	if m.color {
+		label = string(color) + label + reset
+	}
+	hidden := m.only != "" && m.only != name
+	return &Stream{
+		Stdout: &Writer{mux: m, w: m.stdout, prefix: label + " ", hidden: hidden},
+		Stderr: &Writer{mux: m, w: m.stderr, prefix: label + "! ", hidden: hidden, stderr: true},
+	}
+}
+
+// OnLine calls f with each line of s, without its prefix or newline, as it
+// is written, hidden or not. f runs with the mux locked, so it must not
+// write to the mux itself.
+func (s *Stream) OnLine(f func(line string)) {
+	s.Stdout.OnLine(f)
+	s.Stderr.OnLine(f)
+}
+
+// Tee writes each line of s, of Stdout and Stderr alike, without its prefix
+// and followed by a newline, to w as well, hidden or not. w is written to
+// with the mux locked, so it must not write to the mux itself.
+func (s *Stream) Tee(w io.Writer) {
+	s.Stdout.tee, s.Stderr.tee = w, w
+}
+
+// Flush writes out the partial lines of both of s's writers. Call it once
+// the command has exited.
+func (s *Stream) Flush() {
+	s.Stdout.Flush()
+	s.Stderr.Flush()
+}
+
+// Writer writes whole lines to its stream behind the stream's prefix,
+// holding back a partial line until its newline arrives or Flush is
+// called.
+type Writer struct {
+	mux    *Mux
+	w      io.Writer
+	prefix string
+	hidden bool
+	stderr bool
+	onLine func(line string)
+	tee    io.Writer
+	buf    []byte
+}
+
+func (p *Writer) Write(b []byte) (int, error) {
+	p.mux.mu.Lock()
+	defer p.mux.mu.Unlock()
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			return len(b), nil
+		}
+		if err := p.writeLine(p.buf[:i]); err != nil {
+			return len(b), err
+		}
+		p.buf = p.buf[i+1:]
+	}
+}
+
+// OnLine calls f with each line written to p, as Stream.OnLine does for
+// both writers of a stream.
+func (p *Writer) OnLine(f func(line string)) {
+	p.onLine = f
+}
+
+// Flush writes out the partial line held back, ending it.
+func (p *Writer) Flush() {
+	p.mux.mu.Lock()
+	defer p.mux.mu.Unlock()
+	if len(p.buf) > 0 {
+		p.writeLine(p.buf)
+		p.buf = nil
+	}
+}
+
+// writeLine writes line, without its newline, with p.mux.mu held.
+func (p *Writer) writeLine(line []byte) error {
+	if p.onLine != nil {
+		p.onLine(string(bytes.TrimSuffix(line, []byte("\r"))))
+	}
+	if p.tee != nil {
+		// A copy, as appending to line would write over p.buf.
+		p.tee.Write(append(append([]byte(nil), bytes.TrimSuffix(line, []byte("\r"))...), '\n'))
+	}
+	if p.hidden {
+		return nil
+	}
+	var b bytes.Buffer
+	b.WriteString(p.prefix)
+	if p.stderr && p.mux.color {
+		b.WriteString(red)
+		b.Write(bytes.TrimSuffix(line, []byte("\r")))
+		b.WriteString(reset)
+	} else {
+		b.Write(line)
+	}
+	b.WriteByte('\n')
+	_, err := p.w.Write(b.Bytes())
+	return err
+}
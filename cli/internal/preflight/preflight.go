@@ -0,0 +1,247 @@
+// Package preflight checks that the tools a command runs are installed, and
+// recent enough, before the command starts, so a missing one is reported up
+// front with a hint on how to install it instead of failing halfway through.
+package preflight
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Tool is something a command needs on PATH. Commands are the executables
+// that provide it, the first one found wins; MinVersion is the lowest
+// supported version, "" for any. Hints maps a GOOS to the command that
+// installs the tool there.
+type Tool struct {
+	Name       string
+	Commands   []string
+	MinVersion string
+	Hints      map[string]string
+}
+
+// Problem is a tool that is missing, when Found is "", or older than its
+// MinVersion.
+type Problem struct {
+	Tool  Tool
+	Found string
+}
+
+// Minimum versions of the tools the generated projects are built with.
+const (
+	// MinNode is what Vite 5 needs.
+	MinNode = "18"
+	// MinCMakePresets is the first CMake that reads version 2 of
+	// CMakePresets.json.
+	MinCMakePresets = "3.20"
+)
+
+// CMake is the cmake executable, at least version min.
+func CMake(min string) Tool {
+	return Tool{
+		Name:       "cmake",
+		Commands:   []string{"cmake"},
+		MinVersion: min,
+		Hints: map[string]string{
+			"linux":   "sudo apt install cmake",
+			"darwin":  "brew install cmake",
+			"windows": "choco install cmake",
+		},
+	}
+}
+
+// Make is the make CMake's Unix Makefiles generator drives.
+func Make() Tool {
+	return Tool{
+		Name:     "make",
+		Commands: []string{"make"},
+		Hints: map[string]string{
+			"linux":   "sudo apt install make",
+			"darwin":  "xcode-select --install",
+			"windows": "choco install make",
+		},
+	}
+}
+
+// Ninja is the ninja build tool, for build directories configured with it.
+func Ninja() Tool {
+	return Tool{
+		Name:     "ninja",
+		Commands: []string{"ninja"},
+		Hints: map[string]string{
+			"linux":   "sudo apt install ninja-build",
+			"darwin":  "brew install ninja",
+			"windows": "choco install ninja",
+		},
+	}
+}
+
+// Compiler is the C compiler, or the C++ one when lang is "cpp".
+func Compiler(lang string) Tool {
+	if lang == "cpp" {
+		return Tool{
+			Name:     "C++ compiler",
+			Commands: []string{"c++", "g++", "clang++"},
+			Hints: map[string]string{
+				"linux":   "sudo apt install g++",
+				"darwin":  "xcode-select --install",
+				"windows": "choco install mingw",
+			},
+		}
+	}
+	return Tool{
+		Name:     "C compiler",
+		Commands: []string{"cc", "gcc", "clang"},
+		Hints: map[string]string{
+			"linux":   "sudo apt install gcc",
+			"darwin":  "xcode-select --install",
+			"windows": "choco install mingw",
+		},
+	}
+}
+
+// Node is Node.js, at least MinNode.
+func Node() Tool {
+	return Tool{
+		Name:       "node",
+		Commands:   []string{"node"},
+		MinVersion: MinNode,
+		Hints: map[string]string{
+			"linux":   "sudo apt install nodejs npm",
+			"darwin":  "brew install node",
+			"windows": "choco install nodejs-lts",
+		},
+	}
+}
+
+// PackageManager is the frontend's package manager. npm ships with Node;
+// the others install through it.
+func PackageManager(pm string) Tool {
+	if pm == "npm" {
+		t := Node()
+		t.Name, t.Commands, t.MinVersion = "npm", []string{"npm"}, ""
+		return t
+	}
+	hint := "npm install -g " + pm
+	return Tool{
+		Name:     pm,
+		Commands: []string{pm},
+		Hints:    map[string]string{"linux": hint, "darwin": hint, "windows": hint},
+	}
+}
+
+// Check looks up every tool and returns those that are missing or too old.
+// A tool listed twice is checked once.
+func Check(tools []Tool) []Problem {
+	var problems []Problem
+	seen := map[string]bool{}
+	for _, t := range tools {
+		if seen[t.Name] {
+			continue
+		}
+		seen[t.Name] = true
+
+		path := lookPath(t.Commands)
+		if path == "" {
+			problems = append(problems, Problem{Tool: t})
+			continue
+		}
+		if t.MinVersion == "" {
+			continue
+		}
+		// A version that cannot be read is given the benefit of the doubt.
+		if found := toolVersion(path); found != "" && CompareVersions(found, t.MinVersion) < 0 {
+			problems = append(problems, Problem{Tool: t, Found: found})
+		}
+	}
+	return problems
+}
+
+// Report renders problems as one message, with the install hints for goos.
+func Report(problems []Problem, goos string) string {
+	var b strings.Builder
+	if len(problems) == 1 {
+		b.WriteString("a required tool is not available:\n")
+	} else {
+		b.WriteString("some required tools are not available:\n")
+	}
+	for _, p := range problems {
+		name := p.Tool.Name
+		if p.Tool.MinVersion != "" {
+			name += " " + p.Tool.MinVersion + " or newer"
+		}
+		if p.Found == "" {
+			fmt.Fprintf(&b, "  %s: not found", name)
+			if len(p.Tool.Commands) > 1 {
+				fmt.Fprintf(&b, " (looked for %s)", strings.Join(p.Tool.Commands, ", "))
+			}
+			b.WriteString("\n")
+		} else {
+			fmt.Fprintf(&b, "  %s: found %s\n", name, p.Found)
+		}
+		if hint := p.Tool.Hints[goos]; hint != "" {
+			fmt.Fprintf(&b, "      install with: %s\n", hint)
+		}
+	}
+	if len(problems) == 1 {
+		b.WriteString("Install it and try again, or pass --skip-checks to go ahead anyway.")
+	} else {
+		b.WriteString("Install them and try again, or pass --skip-checks to go ahead anyway.")
+	}
+	return b.String()
+}
+
+// Version is the version of the first of t's commands on PATH, "" when none
+// is installed or its version cannot be read.
+func Version(t Tool) string {
+	path := lookPath(t.Commands)
+	if path == "" {
+		return ""
+	}
+	return toolVersion(path)
+}
+
+func lookPath(commands []string) string {
+	for _, c := range commands {
+		if path, err := exec.LookPath(c); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// versionNumber finds the first dotted version in --version output, as in
+// "cmake version 3.28.1" or node's "v20.11.0".
+var versionNumber = regexp.MustCompile(`\d+(\.\d+)+`)
+
+func toolVersion(path string) string {
+	out, err := exec.Command(path, "--version").Output()
+	if err != nil {
+		return ""
+	}
+	return versionNumber.FindString(string(out))
+}
+
+// CompareVersions compares dotted versions numerically, treating missing
+// components as zero, and returns -1, 0 or 1.
+func CompareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,278 @@
+// Package project reads and writes reavix.json, the manifest at the root of
+// every Reavix project.
+package project
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileName is the manifest's name at the project root.
+const FileName = "reavix.json"
+
+// Defaults for projects whose manifest leaves a field out.
+const (
+	DefaultFrontend    = "app"
+	DefaultBackend     = "server"
+	DefaultBinary      = "reavix-app"
+	DefaultOutDir      = "build"
+	DefaultRouter      = "none"
+	DefaultBackendLang = "c"
+	DefaultAppPort     = 5173
+	DefaultServerPort  = 8081
+	DefaultHealthPath  = "/"
+	DefaultLogMaxSize  = 10
+	DefaultLogMaxAge   = 7
+)
+
+// Only values for projects scaffolded with a single half.
+const (
+	OnlyFrontend = "frontend"
+	OnlyBackend  = "backend"
+)
+
+// ErrNotFound is returned by Find outside a Reavix project.
+var ErrNotFound = errors.New("not inside a Reavix project")
+
+// Ports are the development ports the project was scaffolded with.
+type Ports struct {
+	App    int `json:"app"`
+	Server int `json:"server"`
+}
+
+// Manifest describes a project, as reavix.json at its root records it.
+type Manifest struct {
+	// Name is the name the project was created with, empty in manifests
+	// from before it was recorded.
+	Name string `json:"name,omitempty"`
+	// Version is the CLI version that created the project.
+	Version string `json:"version"`
+	// AppVersion is the version build embeds in the app, when not the one
+	// git describes.
+	AppVersion     string `json:"appVersion,omitempty"`
+	Template       string `json:"template"`
+	PackageManager string `json:"packageManager"`
+	// Frontend and Backend are directories relative to the project root.
+	Frontend string `json:"frontend"`
+	Backend  string `json:"backend"`
+	Binary   string `json:"binary"`
+	// OutDir is where build puts the server binary and static/, relative
+	// to the project root or absolute.
+	OutDir string `json:"outDir,omitempty"`
+	// Jobs caps the parallel compile jobs of the server build.
+	Jobs int `json:"jobs,omitempty"`
+	// Generator is the CMake generator build and dev use for the server,
+	// "ninja" or "make".
+	Generator string `json:"generator,omitempty"`
+	// BackendLang is the server's language, "c" or "cpp".
+	BackendLang string `json:"backendLang"`
+	// CStd is the --c-std and Strict the --strict the server was
+	// scaffolded with; TLS is set when it was scaffolded with --tls.
+	CStd   string `json:"cStd,omitempty"`
+	Strict bool   `json:"strict,omitempty"`
+	TLS    bool   `json:"tls,omitempty"`
+	Ports  Ports  `json:"ports"`
+	// Router is the client-side router the frontend was scaffolded with,
+	// so generators can follow its conventions.
+	Router string `json:"router"`
+	// Only is set when the project has just one half, OnlyFrontend or
+	// OnlyBackend.
+	Only  string `json:"only,omitempty"`
+	Cache *Cache `json:"cache,omitempty"`
+	Dev   *Dev   `json:"dev,omitempty"`
+	Watch *Watch `json:"watch,omitempty"`
+	Logs  *Logs  `json:"logs,omitempty"`
+	Run   *Run   `json:"run,omitempty"`
+	Hooks *Hooks `json:"hooks,omitempty"`
+	// LockfileHash is the hash of the frontend's lockfile at the last build
+	// or cache warm --prod with network access, which build --frozen checks
+	// the lockfile against.
+	LockfileHash string `json:"lockfileHash,omitempty"`
+}
+
+// Hooks are shell commands run at the project root: PreBuild before build
+// builds anything and PostBuild once the artifacts are in place, PreDev
+// before dev starts and PostCreate once create has scaffolded the project.
+type Hooks struct {
+	PreBuild   []string `json:"preBuild,omitempty"`
+	PostBuild  []string `json:"postBuild,omitempty"`
+	PreDev     []string `json:"preDev,omitempty"`
+	PostCreate []string `json:"postCreate,omitempty"`
+}
+
+// Cache is the cache section of the manifest. CCache set to false keeps
+// build and dev from compiling the server through ccache when it is
+// installed.
+type Cache struct {
+	CCache *bool `json:"ccache,omitempty"`
+}
+
+// CCacheEnabled reports whether the manifest leaves ccache on, the default.
+func (m *Manifest) CCacheEnabled() bool {
+	return m.Cache == nil || m.Cache.CCache == nil || *m.Cache.CCache
+}
+
+// Dev is the dev section of the manifest. HealthPath is the path on the
+// server's port dev polls until the server answers, to know it is ready,
+// and Open makes dev open the app in the browser then, as --open does.
+// ReloadOnRestart makes the pages of the app reload once dev restarted the
+// server, instead of only checking the API again.
+type Dev struct {
+	HealthPath      string `json:"healthPath,omitempty"`
+	Open            bool   `json:"open,omitempty"`
+	ReloadOnRestart bool   `json:"reloadOnRestart,omitempty"`
+}
+
+// Watch is the watch section of the manifest. Ignore lists glob patterns of
+// paths, relative to the project root, whose changes dev and build --watch
+// ignore on top of their defaults; DebounceMs is how long, in
+// milliseconds, the sources must stay unchanged before they rebuild.
+type Watch struct {
+	Ignore     []string `json:"ignore,omitempty"`
+	DebounceMs int      `json:"debounceMs,omitempty"`
+}
+
+// Logs is the logs section of the manifest. MaxSizeMb is the size in
+// megabytes past which a session log of dev or run moves on to a new file,
+// and MaxAgeDays how many days session logs are kept.
+type Logs struct {
+	MaxSizeMb  int `json:"maxSizeMb,omitempty"`
+	MaxAgeDays int `json:"maxAgeDays,omitempty"`
+}
+
+// Run is the run section of the manifest. RestartOnHup makes a SIGHUP to
+// run restart the server, instead of being passed on to it.
+type Run struct {
+	RestartOnHup bool `json:"restartOnHup,omitempty"`
+}
+
+// LogMaxSize returns the size in megabytes of a session log file,
+// DefaultLogMaxSize unless the manifest sets logs.maxSizeMb.
+func (m *Manifest) LogMaxSize() int {
+	if m.Logs == nil || m.Logs.MaxSizeMb <= 0 {
+		return DefaultLogMaxSize
+	}
+	return m.Logs.MaxSizeMb
+}
+
+// LogMaxAge returns how many days session logs are kept, DefaultLogMaxAge
+// unless the manifest sets logs.maxAgeDays.
+func (m *Manifest) LogMaxAge() int {
+	if m.Logs == nil || m.Logs.MaxAgeDays <= 0 {
+		return DefaultLogMaxAge
+	}
+	return m.Logs.MaxAgeDays
+}
+
+// HealthPath returns the path dev polls for the server to be ready, "/"
+// unless the manifest sets dev.healthPath.
+func (m *Manifest) HealthPath() string {
+	if m.Dev == nil || m.Dev.HealthPath == "" {
+		return DefaultHealthPath
+	}
+	return m.Dev.HealthPath
+}
+
+// HasFrontend reports whether the project has a frontend in m.Frontend.
+func (m *Manifest) HasFrontend() bool { return m.Only != OnlyBackend }
+
+// HasBackend reports whether the project has a server in m.Backend.
+func (m *Manifest) HasBackend() bool { return m.Only != OnlyFrontend }
+
+// Default returns the manifest of a project laid out the way create has
+// always scaffolded it.
+func Default() Manifest {
+	return Manifest{
+		Frontend:    DefaultFrontend,
+		Backend:     DefaultBackend,
+		Binary:      DefaultBinary,
+		OutDir:      DefaultOutDir,
+		BackendLang: DefaultBackendLang,
+		Ports:       Ports{App: DefaultAppPort, Server: DefaultServerPort},
+		Router:      DefaultRouter,
+	}
+}
+
+// Marshal encodes m the way it is written to disk.
+func Marshal(m Manifest) ([]byte, error) {
+	out, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// Load reads the manifest in root, filling in defaults for missing fields.
+func Load(root string) (*Manifest, error) {
+	path := filepath.Join(root, FileName)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := Default()
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	def := Default()
+	if m.Frontend == "" {
+		m.Frontend = def.Frontend
+	}
+	if m.Backend == "" {
+		m.Backend = def.Backend
+	}
+	if m.Binary == "" {
+		m.Binary = def.Binary
+	}
+	if m.OutDir == "" {
+		m.OutDir = def.OutDir
+	}
+	if m.BackendLang == "" {
+		m.BackendLang = def.BackendLang
+	}
+	if m.Ports.App == 0 {
+		m.Ports.App = def.Ports.App
+	}
+	if m.Ports.Server == 0 {
+		m.Ports.Server = def.Ports.Server
+	}
+	if m.Router == "" {
+		m.Router = def.Router
+	}
+	return &m, nil
+}
+
+// Update loads the manifest in root, applies change and writes it back.
+func Update(root string, change func(*Manifest)) error {
+	m, err := Load(root)
+	if err != nil {
+		return err
+	}
+	change(m)
+	out, err := Marshal(*m)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(root, FileName), out, 0644)
+}
+
+// Find looks for a manifest in dir and its parents and returns the project
+// root together with the manifest. It returns ErrNotFound when there is none.
+func Find(dir string) (string, *Manifest, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	for d := abs; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, FileName)); err == nil {
+			m, err := Load(d)
+			return d, m, err
+		}
+		if parent := filepath.Dir(d); parent == d {
+			return "", nil, ErrNotFound
+		}
+	}
+}
@@ -7,22 +7,41 @@ import (
 
 )
 
+// CopyFile copies src to dst with src's permissions.
 func CopyFile(src, dst string) error{
+	return CopyFileTee(src, dst, io.Discard)
+}
+
+// CopyFileTee is CopyFile that also writes what it copies to w, like a
+// hash of the file.
+func CopyFileTee(src, dst string, w io.Writer) error {
 	source, err := os.Open(src)
 	if err != nil {
 		return err
 	}
 	defer source.Close()
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
 	destination, err := os.Create(dst)
 	if err != nil {
 		return err
 	}
 	defer destination.Close()
-	_, err = io.Copy(destination, source)
-	return err
+	if _, err = io.Copy(io.MultiWriter(destination, w), source); err != nil {
+		return err
+	}
+	// Keep the executable bit of binaries like the server.
+	return destination.Chmod(info.Mode().Perm())
 }
 
 func CopyDir(src, dst string) error {
+	return CopyDirWith(src, dst, CopyFile)
+}
+
+// CopyDirWith is CopyDir copying each file with copyFile.
+func CopyDirWith(src, dst string, copyFile func(src, dst string) error) error {
 	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -36,6 +55,6 @@ func CopyDir(src, dst string) error {
 		if info.IsDir() {
 			return os.MkdirAll(targetPath, info.Mode())
 		}
-		return CopyFile(path, targetPath)
+		return copyFile(path, targetPath)
 	})
 }
\ No newline at end of file
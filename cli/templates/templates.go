@@ -0,0 +1,15 @@
+// Package templates embeds the files used to scaffold new Reavix projects.
+//
+// Each project variant is a directory holding a manifest.json that lists
+// the files it renders and a meta.json describing it for --list-templates;
+// manifests may reference their own templates or the ones under shared/.
+// The CLI checks every template a manifest names when the variant is
+// loaded, so a missing file fails loudly instead of producing an empty one.
+package templates
+
+import "embed"
+
+// FS holds every scaffolding template and variant manifest.
+//
+//go:embed shared full minimal
+var FS embed.FS